@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// alertActions change alerts shown in alerts panel
+// Actions are called from their own goroutine and list is refreshed after
+// they return
+type alertActions struct {
+	list func() []cq.Alert
+	add  func(cq.Alert) error
+	// set changes alert with id
+	set    func(id int, change func(*cq.Alert)) error
+	remove func(id int) error
+}
+
+// showAlertsPanel shows dialog listing alerts with controls to enable,
+// edit, remove and add alerts for pairs
+// Alerts for pairs that are not streamed are listed as inactive.
+func showAlertsPanel(w fyne.Window, pairs []cq.Pair, actions alertActions) {
+	list := widget.NewVBox()
+	errLabel := widget.NewLabel("")
+
+	// alert form adds alerts for pairs or edits alert selected in list
+	// pairs are named by key since watchlist may hold pairs of several
+	// exchanges
+	names := []string{}
+	for _, p := range pairs {
+		names = append(names, p.Key())
+	}
+	pair := widget.NewSelect(names, nil)
+	if len(names) > 0 {
+		pair.Selected = names[0]
+	}
+	// pair of edited alert can't be changed
+	editPair := widget.NewLabel("")
+	editPair.Hide()
+	conds := []string{}
+	for _, c := range cq.AlertConds {
+		conds = append(conds, c.String())
+	}
+	cond := widget.NewSelect(conds, nil)
+	cond.Selected = conds[0]
+	value := widget.NewEntry()
+	value.SetPlaceHolder("price, % change, spread or volume")
+	rearm := widget.NewCheck("Re-arm", nil)
+	cooldown := widget.NewEntry()
+	cooldown.SetPlaceHolder("seconds")
+
+	// editing is alert being edited, nil when form adds alerts
+	var editing *cq.Alert
+	var submit, cancel *widget.Button
+	edit := func(a *cq.Alert) {
+		editing = a
+		if a == nil {
+			editPair.Hide()
+			pair.Show()
+			value.SetText("")
+			rearm.SetChecked(false)
+			cooldown.SetText("")
+			submit.SetText("Add Alert")
+			cancel.Hide()
+			return
+		}
+		pair.Hide()
+		editPair.SetText(a.Pair.Key())
+		editPair.Show()
+		cond.SetSelected(a.Cond.String())
+		value.SetText(a.Value.String())
+		rearm.SetChecked(a.Rearm)
+		cooldown.SetText(strconv.Itoa(a.Cooldown))
+		submit.SetText("Save Alert")
+		cancel.Show()
+	}
+
+	var refresh func()
+	run := func(action func() error) {
+		go func() {
+			err := action()
+			if err != nil {
+				errLabel.SetText(err.Error())
+			} else {
+				errLabel.SetText("")
+			}
+			refresh()
+		}()
+	}
+	refresh = func() {
+		list.Children = nil
+		alerts := actions.list()
+		if len(alerts) == 0 {
+			list.Append(widget.NewLabel("No alerts"))
+		}
+		for _, a := range alerts {
+			alert := a
+			enabled := widget.NewCheck(alert.String(), nil)
+			enabled.Checked = alert.Enabled
+			enabled.OnChanged = func(on bool) {
+				run(func() error {
+					return actions.set(alert.ID, func(a *cq.Alert) { a.Enabled = on })
+				})
+			}
+			editAlert := widget.NewButton("Edit", func() {
+				edit(&alert)
+			})
+			remove := widget.NewButton("Remove", func() {
+				if editing != nil && editing.ID == alert.ID {
+					edit(nil)
+				}
+				run(func() error { return actions.remove(alert.ID) })
+			})
+			state := alertState(alert, isStreamed(pairs, alert.Pair))
+			list.Append(widget.NewHBox(enabled, layout.NewSpacer(), widget.NewLabel(state), editAlert, remove))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	submit = widget.NewButton("Add Alert", func() {
+		if editing != nil {
+			e, err := setAlert(*editing, cond.Selected, value.Text, rearm.Checked, cooldown.Text)
+			if err != nil {
+				errLabel.SetText(err.Error())
+				return
+			}
+			edit(nil)
+			// only fields from form are changed so alert's current enabled
+			// and fired state are kept
+			run(func() error {
+				return actions.set(e.ID, func(a *cq.Alert) {
+					a.Cond, a.Value, a.Rearm, a.Cooldown = e.Cond, e.Value, e.Rearm, e.Cooldown
+				})
+			})
+			return
+		}
+		a, err := newAlert(pairs, pair.Selected, cond.Selected, value.Text, rearm.Checked, cooldown.Text)
+		if err != nil {
+			errLabel.SetText(err.Error())
+			return
+		}
+		run(func() error { return actions.add(a) })
+	})
+	cancel = widget.NewButton("Cancel", func() {
+		edit(nil)
+	})
+	cancel.Hide()
+	form := widget.NewHBox(pair, editPair, cond, value, rearm, widget.NewLabel("Cooldown"), cooldown, submit, cancel)
+
+	// fix size of scrolling list
+	bg := canvas.NewRectangle(theme.BackgroundColor())
+	bg.SetMinSize(fyne.NewSize(760, 300))
+	scroll := widget.NewScrollContainer(list)
+	bottom := widget.NewVBox(form, errLabel)
+	content := fyne.NewContainerWithLayout(layout.NewBorderLayout(nil, bottom, nil, nil),
+		bottom, fyne.NewContainerWithLayout(layout.NewMaxLayout(), bg, scroll))
+
+	dialog.ShowCustom("Alerts", "Close", content, w)
+}
+
+// isStreamed reports whether pair is one of streamed pairs
+func isStreamed(pairs []cq.Pair, pair cq.Pair) bool {
+	for _, p := range pairs {
+		if p == pair {
+			return true
+		}
+	}
+	return false
+}
+
+// alertState describes whether alert can fire
+// Alerts for pairs that are not streamed never fire and are inactive.
+func alertState(a cq.Alert, streamed bool) string {
+	switch {
+	case !streamed:
+		return "inactive, pair not streamed"
+	case a.Enabled && a.Rearm && a.Cooldown > 0:
+		return fmt.Sprintf("armed, %vs cooldown", a.Cooldown)
+	case a.Enabled:
+		return "armed"
+	case !a.Fired.IsZero():
+		return "fired " + a.Fired.Local().Format("Jan 2 15:04:05")
+	}
+	return "disabled"
+}
+
+// newAlert returns alert from values entered in alerts panel
+func newAlert(pairs []cq.Pair, pairName, condName, value string, rearm bool, cooldown string) (cq.Alert, error) {
+	a := cq.Alert{}
+
+	found := false
+	for _, p := range pairs {
+		if p.Key() == pairName {
+			a.Pair, found = p, true
+		}
+	}
+	if !found {
+		return a, fmt.Errorf("select a pair")
+	}
+	return setAlert(a, condName, value, rearm, cooldown)
+}
+
+// setAlert returns alert with condition, value, re-arm and cooldown
+// entered in alerts panel
+func setAlert(a cq.Alert, condName, value string, rearm bool, cooldown string) (cq.Alert, error) {
+	a.Rearm = rearm
+	err := a.Cond.UnmarshalText([]byte(condName))
+	if err != nil {
+		return a, err
+	}
+
+	a.Value, err = cq.ParseDecimal(value)
+	if err != nil {
+		return a, fmt.Errorf("invalid value: %v", value)
+	}
+
+	a.Cooldown = 0
+	if c := strings.TrimSpace(cooldown); c != "" {
+		a.Cooldown, err = strconv.Atoi(c)
+		if err != nil || a.Cooldown < 0 {
+			return a, fmt.Errorf("invalid cooldown: %v", cooldown)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,94 @@
+package bitfinex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// defaultSymbols are the symbols of default watchlist
+var defaultSymbols = []string{
+	"BTCUSD",
+	"ETHUSD",
+	"ETHBTC",
+	"LTCUSD",
+	"LTCBTC",
+	"ZECUSD",
+	"ZRXUSD",
+}
+
+// Exchange implements the cq.Exchange interface
+type Exchange struct {
+	cq.BaseExchange
+}
+
+// New returns new instance which implements cq.Exchange interface
+// Sets id, available Pair(s) loaded with rest, and default watchlist
+func New(rest REST) (*Exchange, error) {
+	e := &Exchange{
+		cq.BaseExchange{},
+	}
+	instruments, err := rest.GetInstruments()
+	if err != nil {
+		return nil, errors.New("unable to get available pairs")
+	}
+	e.SetID(cq.Bitfinex)
+	e.AddInstrument(instruments...)
+	e.SetWatchlist(e.GetDefaultPairs()...)
+
+	return e, nil
+}
+
+// GetDefaultPairs returns a slice of cq.Pair(s) for Bitfinex exchange
+func (e *Exchange) GetDefaultPairs() []cq.Pair {
+	pairs := []cq.Pair{}
+	for _, s := range defaultSymbols {
+		if p, err := NewPair(s); err == nil {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// NewPair takes a pair in the format used by Bitfinex APIs (ie, "BTCUSD",
+// "tBTCUSD" or "DOGE:USD") and returns an instance of cq.Pair.
+// Currencies longer than 3 letters are separated by ":".  Pairs of
+// derivative currencies such as "tBTCF0:USTF0" are perpetual swaps.
+// Symbols without ":" that are not two 3 letter currencies return an error.
+func NewPair(s string) (cq.Pair, error) {
+	// trading symbols are prefixed with lower case "t"
+	symbol := strings.TrimPrefix(s, "t")
+	if t := strings.SplitN(symbol, ":", 2); len(t) == 2 {
+		if t[0] == "" || t[1] == "" {
+			return cq.Pair{}, fmt.Errorf("unable to split bitfinex symbol %q into currencies", s)
+		}
+		return cq.NewMarketPair(cq.Bitfinex, t[0], t[1], marketType(t[0], t[1])), nil
+	}
+	if len(symbol) != 6 {
+		return cq.Pair{}, fmt.Errorf("unable to split bitfinex symbol %q into currencies", s)
+	}
+	return cq.NewMarketPair(cq.Bitfinex, symbol[:3], symbol[3:], cq.Spot), nil
+}
+
+// derivativeSuffix ends currencies of Bitfinex perpetual swaps
+const derivativeSuffix = "F0"
+
+// marketType returns Perpetual for pairs of derivative currencies and Spot
+// otherwise
+func marketType(base, quote string) cq.MarketType {
+	if strings.HasSuffix(base, derivativeSuffix) && strings.HasSuffix(quote, derivativeSuffix) {
+		return cq.Perpetual
+	}
+	return cq.Spot
+}
+
+// NewSymbol takes a cq.Pair and returns a trading symbol formatted
+// for use by API (ie, "tBTCUSD")
+func NewSymbol(p cq.Pair) string {
+	if len(p.BaseCurrency()) > 3 || len(p.QuoteCurrency()) > 3 {
+		return "t" + p.BaseCurrency() + ":" + p.QuoteCurrency()
+	}
+	return "t" + p.BaseCurrency() + p.QuoteCurrency()
+}
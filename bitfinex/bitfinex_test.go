@@ -0,0 +1,50 @@
+package bitfinex
+
+import (
+	"testing"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+func TestNewPair(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   cq.Pair
+	}{
+		{"tBTCUSD", cq.NewMarketPair(cq.Bitfinex, "BTC", "USD", cq.Spot)},
+		{"ETHBTC", cq.NewMarketPair(cq.Bitfinex, "ETH", "BTC", cq.Spot)},
+		{"tDOGE:USD", cq.NewMarketPair(cq.Bitfinex, "DOGE", "USD", cq.Spot)},
+		{"tBTCF0:USTF0", cq.NewMarketPair(cq.Bitfinex, "BTCF0", "USTF0", cq.Perpetual)},
+		{"tETHF0:USTF0", cq.NewMarketPair(cq.Bitfinex, "ETHF0", "USTF0", cq.Perpetual)},
+	}
+	for _, test := range tests {
+		p, err := NewPair(test.symbol)
+		if err != nil || p != test.want {
+			t.Errorf("NewPair(%v) = %v, want %v", test.symbol, p.Key(), test.want.Key())
+		}
+		if p.Market() == cq.Spot {
+			continue
+		}
+		if s := NewSymbol(p); s != test.symbol {
+			t.Errorf("NewSymbol(%v) = %v, want %v", p.Key(), s, test.symbol)
+		}
+	}
+}
+
+func TestNewPairError(t *testing.T) {
+	// currencies would be empty or split at the wrong letter
+	for _, s := range []string{"", "t", "tBTC", "BTCUSDT", "tDOGEUSD", "tBTC:", ":USD"} {
+		if p, err := NewPair(s); err == nil {
+			t.Errorf("NewPair(%q) = %v, want error", s, p.Key())
+		}
+	}
+}
+
+// mustPair returns pair of symbol and panics if it cannot be split
+func mustPair(s string) cq.Pair {
+	p, err := NewPair(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
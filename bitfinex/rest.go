@@ -0,0 +1,208 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// restAPI is the base url of Bitfinex's public REST API
+const restAPI = "https://api-pub.bitfinex.com/v2"
+
+// REST implements cq.MarketData with Bitfinex's REST API
+// API is the base url of REST API.  Empty API uses Bitfinex's api.
+type REST struct {
+	API string
+}
+
+// url returns url of path on REST API
+func (r REST) url(path string) string {
+	if len(r.API) == 0 {
+		return restAPI + path
+	}
+	return r.API + path
+}
+
+// getJSON performs http GET request and decodes response body into v
+// Non-200 responses are returned as errors with message from API if present
+// Error responses are arrays: ["error", CODE, "message"]
+func getJSON(api string, v interface{}) error {
+	resp, err := http.Get(api)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e := []interface{}{}
+		if json.Unmarshal(body, &e) == nil && len(e) == 3 {
+			return fmt.Errorf("bitfinex api error %v: %v", e[1], e[2])
+		}
+		return fmt.Errorf("bitfinex api error: %v", resp.Status)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// decodeNumbers decodes JSON array of at least n numbers
+// Numbers are kept as json.Number so prices are not rounded
+func decodeNumbers(b []byte, n int) ([]json.Number, error) {
+	nums := []json.Number{}
+	err := json.Unmarshal(b, &nums)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) < n {
+		return nil, fmt.Errorf("expected %v values but got %v", n, len(nums))
+	}
+	return nums, nil
+}
+
+// GetPairs queries REST API to get all available crypto pairs.
+// Returns a slice of cq.Pair
+// https://docs.bitfinex.com/reference#rest-public-conf
+func (r REST) GetPairs() ([]cq.Pair, error) {
+	pairs := []cq.Pair{}
+
+	resp := [][]string{}
+	err := getJSON(r.url("/conf/pub:list:pair:exchange"), &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return pairs, nil
+	}
+
+	// listing only holds symbols of spot pairs so symbols that cannot be
+	// split are skipped
+	for _, s := range resp[0] {
+		p, err := NewPair(s)
+		if err != nil {
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+
+	return pairs, nil
+}
+
+// GetInstruments returns instruments for all available crypto pairs
+// Bitfinex prices have 5 significant digits rather than a fixed tick size
+// and its public api has no size increments so trading rules are left
+// unknown and default formatting is used.
+func (r REST) GetInstruments() ([]cq.Instrument, error) {
+	pairs, err := r.GetPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := []cq.Instrument{}
+	for _, p := range pairs {
+		instruments = append(instruments, cq.NewInstrument(p))
+	}
+	return instruments, nil
+}
+
+// TickerEntry holds data for trading pair ticker array
+// REST tickers are prefixed with symbol which websocket tickers omit
+// https://docs.bitfinex.com/reference#rest-public-tickers
+type TickerEntry struct {
+	Bid                 json.Number
+	BidSize             json.Number
+	Ask                 json.Number
+	AskSize             json.Number
+	DailyChange         json.Number
+	DailyChangeRelative json.Number
+	LastPrice           json.Number
+	Volume              json.Number
+	High                json.Number
+	Low                 json.Number
+}
+
+// UnmarshalJSON decodes ticker array
+func (t *TickerEntry) UnmarshalJSON(b []byte) error {
+	nums, err := decodeNumbers(b, 10)
+	if err != nil {
+		return fmt.Errorf("invalid ticker: %v", err)
+	}
+	*t = TickerEntry{nums[0], nums[1], nums[2], nums[3], nums[4], nums[5], nums[6], nums[7], nums[8], nums[9]}
+	return nil
+}
+
+// quote converts ticker to cq.Quote
+// Open price is calculated from last price and daily change
+func (t TickerEntry) quote(pair cq.Pair) cq.Quote {
+	last, _ := cq.ParseDecimal(t.LastPrice.String())
+	change, _ := cq.ParseDecimal(t.DailyChange.String())
+
+	return cq.Quote{
+		ExchangeID: cq.Bitfinex,
+		ID:         pair,
+		Price:      t.LastPrice.String(),
+		Bid:        t.Bid.String(),
+		Ask:        t.Ask.String(),
+		Low:        t.Low.String(),
+		High:       t.High.String(),
+		Open:       last.Sub(change).String(),
+		Volume:     t.Volume.String(),
+	}
+}
+
+// GetQuotes queries tickers for pairs
+func (r REST) GetQuotes(pairs ...cq.Pair) ([]cq.Quote, error) {
+	quotes := []cq.Quote{}
+	if len(pairs) == 0 {
+		return quotes, nil
+	}
+
+	symbols := []string{}
+	for _, p := range pairs {
+		symbols = append(symbols, NewSymbol(p))
+	}
+
+	resp := [][]json.RawMessage{}
+	api := r.url("/tickers?symbols=" + url.QueryEscape(strings.Join(symbols, ",")))
+	err := getJSON(api, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range resp {
+		if len(row) < 11 {
+			continue
+		}
+		var symbol string
+		err := json.Unmarshal(row[0], &symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := json.Marshal(row[1:])
+		if err != nil {
+			return nil, err
+		}
+		ticker := TickerEntry{}
+		err = json.Unmarshal(b, &ticker)
+		if err != nil {
+			return nil, err
+		}
+
+		pair, err := NewPair(symbol)
+		if err != nil {
+			continue
+		}
+		quotes = append(quotes, ticker.quote(pair))
+	}
+
+	return quotes, nil
+}
@@ -0,0 +1,201 @@
+package bitfinex
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// newFakeREST returns REST client for fake server which serves routes by
+// path
+func newFakeREST(routes map[string]http.HandlerFunc) (REST, *httptest.Server) {
+	srv := fakeapi.NewREST(routes, `["error",10020,"not found"]`)
+	return REST{API: srv.URL}, srv
+}
+
+func TestGetPairs(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/conf/pub:list:pair:exchange": fakeapi.Respond(`[["BTCUSD","ETHBTC","DOGE:USD","BTCUSDT"]]`),
+	})
+	defer srv.Close()
+
+	pairs, err := rest.GetPairs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cq.Pair{
+		cq.NewMarketPair(cq.Bitfinex, "BTC", "USD", cq.Spot),
+		cq.NewMarketPair(cq.Bitfinex, "ETH", "BTC", cq.Spot),
+		cq.NewMarketPair(cq.Bitfinex, "DOGE", "USD", cq.Spot),
+	}
+	if len(pairs) != len(want) {
+		t.Fatalf("got %v, want %v", pairs, want)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Errorf("pair %v = %v, want %v", i, pairs[i].Key(), want[i].Key())
+		}
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/tickers": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("symbols") != "tBTCUSD" {
+				t.Errorf("symbols = %v, want tBTCUSD", r.URL.Query().Get("symbols"))
+			}
+			w.Write([]byte(`[["tBTCUSD",7000,1.5,7001,2,100,0.0145,7000.5,1234.5,7100,6800]]`))
+		},
+	})
+	defer srv.Close()
+	pair := mustPair("BTCUSD")
+
+	quotes, err := rest.GetQuotes(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cq.Quote{
+		ExchangeID: cq.Bitfinex,
+		ID:         pair,
+		Price:      "7000.5",
+		Bid:        "7000",
+		Ask:        "7001",
+		Low:        "6800",
+		High:       "7100",
+		Open:       "6900.5",
+		Volume:     "1234.5",
+	}
+	if len(quotes) != 1 || quotes[0] != want {
+		t.Errorf("got %+v, want %+v", quotes, want)
+	}
+}
+
+func TestGetQuotesError(t *testing.T) {
+	rest, srv := newFakeREST(nil)
+	defer srv.Close()
+
+	_, err := rest.GetQuotes(mustPair("BTCUSD"))
+	if err == nil || err.Error() != "bitfinex api error 10020: not found" {
+		t.Errorf("got error %v, want api error", err)
+	}
+}
+
+func TestGetTrades(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/trades/tBTCUSD/hist": fakeapi.Respond(`[[401,1577934245123,-0.25,7000.5],[400,1577934244000,1,6999]]`),
+	})
+	defer srv.Close()
+	pair := mustPair("BTCUSD")
+
+	trades, err := rest.GetTrades(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cq.Trade{
+		{
+			Pair:      pair,
+			ID:        401,
+			Price:     "7000.5",
+			Size:      "0.25",
+			Side:      cq.Sell,
+			Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC),
+		},
+		{
+			Pair:      pair,
+			ID:        400,
+			Price:     "6999",
+			Size:      "1",
+			Side:      cq.Buy,
+			Timestamp: time.Date(2020, 1, 2, 3, 4, 4, 0, time.UTC),
+		},
+	}
+	if len(trades) != len(want) {
+		t.Fatalf("got %+v, want %+v", trades, want)
+	}
+	for i := range want {
+		if trades[i] != want[i] {
+			t.Errorf("trade %v = %+v, want %+v", i, trades[i], want[i])
+		}
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/candles/trade:1m:tBTCUSD/hist": fakeapi.Respond(`[[1577934300000,7001,7002,7003,7000,1.5],[1577934240000,7000,7001,7002,6999,2.25]]`),
+	})
+	defer srv.Close()
+
+	candles, err := rest.GetCandles(mustPair("BTCUSD"), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cq.CandleData{
+		{Timestamp: time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC), Open: "7000", Close: "7001", Max: "7002", Min: "6999", Volume: "2.25"},
+		{Timestamp: time.Date(2020, 1, 2, 3, 5, 0, 0, time.UTC), Open: "7001", Close: "7002", Max: "7003", Min: "7000", Volume: "1.5"},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("got %+v, want %+v", candles, want)
+	}
+	for i := range want {
+		if candles[i] != want[i] {
+			t.Errorf("candle %v = %+v, want %+v", i, candles[i], want[i])
+		}
+	}
+}
+
+func TestGetOlderTrades(t *testing.T) {
+	ends := []string{}
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/trades/tBTCUSD/hist": func(w http.ResponseWriter, r *http.Request) {
+			end := r.URL.Query().Get("end")
+			ends = append(ends, end)
+			switch end {
+			case "1577934245123":
+				// more trades than limit share before's millisecond
+				w.Write([]byte(`[[403,1577934245123,1,7000],[402,1577934245123,1,7000]]`))
+			case "1577934245122":
+				w.Write([]byte(`[[399,1577934245000,-1,6999],[398,1577934244000,2,6998]]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		},
+	})
+	defer srv.Close()
+	pair := mustPair("BTCUSD")
+	before := cq.Trade{ID: 402, Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC)}
+
+	trades, err := rest.GetOlderTrades(pair, before, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].ID != 399 || trades[1].ID != 398 {
+		t.Errorf("got %+v, want trades 399 and 398", trades)
+	}
+	if len(ends) != 2 {
+		t.Errorf("requested ends %v, want end stepped back once", ends)
+	}
+}
+
+func TestNew(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/conf/pub:list:pair:exchange": fakeapi.Respond(`[["BTCUSD","ETHUSD","ETHBTC"]]`),
+	})
+	defer srv.Close()
+
+	e, err := New(rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.GetAvailablePairs(); len(got) != 3 {
+		t.Errorf("got available pairs %v, want pairs served by rest", got)
+	}
+
+	srv.Close()
+	if _, err := New(rest); err == nil {
+		t.Error("expected error when rest api is unavailable")
+	}
+}
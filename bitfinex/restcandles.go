@@ -0,0 +1,155 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// maxCandlesPage is the largest limit accepted by candles endpoint
+const maxCandlesPage = 10000
+
+// timeframes maps candle interval in minutes to Bitfinex time frame
+var timeframes = map[int]string{
+	1:     "1m",
+	5:     "5m",
+	15:    "15m",
+	30:    "30m",
+	60:    "1h",
+	180:   "3h",
+	360:   "6h",
+	720:   "12h",
+	1440:  "1D",
+	10080: "7D",
+	20160: "14D",
+	43200: "1M",
+}
+
+// candleKey returns key for candles endpoint and channel
+// ie, "trade:5m:tBTCUSD"
+func candleKey(pair cq.Pair, interval int) (string, error) {
+	tf, ok := timeframes[interval]
+	if !ok {
+		return "", fmt.Errorf("unsupported candle interval: %v minutes", interval)
+	}
+	return fmt.Sprintf("trade:%v:%v", tf, NewSymbol(pair)), nil
+}
+
+// CandleEntry holds data for candle array: [MTS, OPEN, CLOSE, HIGH, LOW, VOLUME]
+// https://docs.bitfinex.com/reference#rest-public-candles
+type CandleEntry struct {
+	MTS    json.Number
+	Open   json.Number
+	Close  json.Number
+	High   json.Number
+	Low    json.Number
+	Volume json.Number
+}
+
+// UnmarshalJSON decodes candle array
+func (c *CandleEntry) UnmarshalJSON(b []byte) error {
+	nums, err := decodeNumbers(b, 6)
+	if err != nil {
+		return fmt.Errorf("invalid candle: %v", err)
+	}
+	*c = CandleEntry{nums[0], nums[1], nums[2], nums[3], nums[4], nums[5]}
+	return nil
+}
+
+// GetCandles performs http request/s to retrieve the most recent candles
+// for pair with interval given in minutes
+// Requests for more than one page of candles are paged automatically
+func (r REST) GetCandles(pair cq.Pair, interval int, limit int) ([]cq.CandleData, error) {
+	key, err := candleKey(pair, interval)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	candles := []cq.CandleData{}
+	seen := map[time.Time]struct{}{}
+	var end int64
+	for len(candles) < limit {
+		n := limit - len(candles)
+		if n > maxCandlesPage {
+			n = maxCandlesPage
+		}
+
+		params := url.Values{}
+		params.Set("limit", strconv.Itoa(n))
+		params.Set("sort", "-1")
+		if end > 0 {
+			params.Set("end", strconv.FormatInt(end, 10))
+		}
+		entries := []CandleEntry{}
+		err := getJSON(r.url(fmt.Sprintf("/candles/%v/hist?%v", key, params.Encode())), &entries)
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := newCandles(entries)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, c := range page {
+			if _, ok := seen[c.Timestamp]; ok {
+				continue
+			}
+			seen[c.Timestamp] = struct{}{}
+			candles = append(candles, c)
+			added++
+
+			ms := c.Timestamp.UnixNano() / int64(time.Millisecond)
+			if end == 0 || ms < end {
+				end = ms
+			}
+		}
+		if len(page) < n || added == 0 {
+			break
+		}
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp.Before(candles[j].Timestamp)
+	})
+
+	return candles, nil
+}
+
+// newCandles converts slice of CandleEntry to slice of cq.CandleData
+func newCandles(entries []CandleEntry) ([]cq.CandleData, error) {
+	candles := []cq.CandleData{}
+	for _, e := range entries {
+		c, err := newCandle(e)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+	return candles, nil
+}
+
+// newCandle converts CandleEntry instance to cq.CandleData instance
+func newCandle(e CandleEntry) (cq.CandleData, error) {
+	ms, err := e.MTS.Int64()
+	if err != nil {
+		return cq.CandleData{}, fmt.Errorf("invalid candle timestamp: %v", e.MTS)
+	}
+
+	return cq.CandleData{
+		Timestamp: msTime(ms),
+		Open:      e.Open.String(),
+		Close:     e.Close.String(),
+		Min:       e.Low.String(),
+		Max:       e.High.String(),
+		Volume:    e.Volume.String(),
+	}, nil
+}
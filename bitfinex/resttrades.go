@@ -0,0 +1,119 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// TradeEntry holds data for trade array: [ID, MTS, AMOUNT, PRICE]
+// Negative amount is a sell
+// https://docs.bitfinex.com/reference#rest-public-trades
+type TradeEntry struct {
+	ID     json.Number
+	MTS    json.Number
+	Amount json.Number
+	Price  json.Number
+}
+
+// UnmarshalJSON decodes trade array
+func (t *TradeEntry) UnmarshalJSON(b []byte) error {
+	nums, err := decodeNumbers(b, 4)
+	if err != nil {
+		return fmt.Errorf("invalid trade: %v", err)
+	}
+	*t = TradeEntry{nums[0], nums[1], nums[2], nums[3]}
+	return nil
+}
+
+// GetTrades performs http request to retrieve 100 most recent trades
+func (r REST) GetTrades(pair cq.Pair) ([]cq.Trade, error) {
+	trades := []TradeEntry{}
+	t := []cq.Trade{}
+
+	api := r.url(fmt.Sprintf("/trades/%v/hist?limit=100", NewSymbol(pair)))
+	err := getJSON(api, &trades)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trade := range trades {
+		tr := newTrade(trade)
+		tr.Pair = pair
+		t = append(t, tr)
+	}
+
+	return t, nil
+}
+
+// GetOlderTrades performs http request/s to retrieve up to limit trades
+// older than trade, newest first
+// Trades are paged by millisecond so end is stepped back when every trade
+// in a page shares before's millisecond
+func (r REST) GetOlderTrades(pair cq.Pair, before cq.Trade, limit int) ([]cq.Trade, error) {
+	end := before.Timestamp.UnixNano() / int64(time.Millisecond)
+	for {
+		api := r.url(fmt.Sprintf("/trades/%v/hist?limit=%v&end=%v&sort=-1", NewSymbol(pair), limit, end))
+		entries := []TradeEntry{}
+		err := getJSON(api, &entries)
+		if err != nil {
+			return nil, err
+		}
+
+		t := []cq.Trade{}
+		for _, e := range entries {
+			trade := newTrade(e)
+			// end is inclusive
+			if trade.ID >= before.ID {
+				continue
+			}
+			trade.Pair = pair
+			t = append(t, trade)
+		}
+		if len(t) > 0 || len(entries) < limit {
+			return t, nil
+		}
+		end--
+	}
+}
+
+// newTrade converts TradeEntry instance to cq.Trade instance
+// converts timestamp to local timezone
+func newTrade(t TradeEntry) cq.Trade {
+	id, _ := t.ID.Float64()
+	side := cq.Buy
+	if strings.HasPrefix(t.Amount.String(), "-") {
+		side = cq.Sell
+	}
+	return cq.Trade{
+		ID:        id,
+		Price:     t.Price.String(),
+		Size:      strings.TrimPrefix(t.Amount.String(), "-"),
+		Side:      side,
+		Timestamp: parseTime(t.MTS),
+	}
+}
+
+// parseTime converts millisecond timestamp to time.Time
+// Returns zero time if timestamp is invalid
+func parseTime(mts json.Number) time.Time {
+	ms, err := mts.Int64()
+	if err != nil {
+		return time.Time{}
+	}
+	return msTime(ms)
+}
+
+// msTime converts millisecond timestamp to time.Time
+func msTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+// formatFloat formats calculated values without trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,528 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+const (
+	// wsAPI is the url of Bitfinex's public websocket api
+	wsAPI = "wss://api-pub.bitfinex.com/ws/2"
+	// staleAfter is the time without messages before connection is marked Stale
+	// Heartbeats are sent every 15 seconds on each channel
+	staleAfter = 20 * time.Second
+)
+
+// errReconnect is returned by handle when server asks clients to reconnect
+var errReconnect = errors.New("server requested reconnect")
+
+type WSCtlr struct {
+	// client owns connection and matches responses to requests
+	client *cq.WSClient
+
+	// fields below are only accessed by event loop
+	// out holds channels to main event loop
+	out cq.StreamChans
+	// subs holds active subscriptions so they can be replayed after reconnect
+	// keys are created with subKey()
+	subs map[string]SubscribeMsg
+	// chans maps channel ids from server to subscriptions
+	chans map[int64]chanInfo
+}
+
+// SubscribeMsg contains info to subscribe to websocket data
+// Event is "subscribe" or "unsubscribe"
+// https://docs.bitfinex.com/docs/ws-general#subscribe-to-channels
+type SubscribeMsg struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel,omitempty"`
+	Symbol  string `json:"symbol,omitempty"`
+	Key     string `json:"key,omitempty"`
+	ChanID  int64  `json:"chanId,omitempty"`
+}
+
+// chanInfo describes subscribed channel
+type chanInfo struct {
+	key     string
+	channel string
+	pair    cq.Pair
+}
+
+// subKey returns key used to track subscriptions
+// Subscribe, unsubscribe, subscribed and error messages for the same channel
+// return the same key
+func subKey(channel string, symbol string, key string) string {
+	return channel + ":" + symbol + ":" + key
+}
+
+// NewWSCtlr returns an instance that is connected to websocket at
+// "wss://api-pub.bitfinex.com/ws/2"
+func NewWSCtlr() (*WSCtlr, error) {
+	return Connect(wsAPI)
+}
+
+// Connect returns an instance that is connected to websocket at api
+func Connect(api string) (*WSCtlr, error) {
+	ws := &WSCtlr{
+		subs:  make(map[string]SubscribeMsg),
+		chans: make(map[int64]chanInfo),
+	}
+	client, err := cq.ConnectWS(cq.WSConfig{
+		Exchange:   cq.Bitfinex,
+		API:        api,
+		StaleAfter: staleAfter,
+	}, ws)
+	if err != nil {
+		return nil, err
+	}
+	ws.client = client
+
+	return ws, nil
+}
+
+// Status returns channel that receives connection state changes
+func (ws *WSCtlr) Status() <-chan cq.ConnStatusMsg {
+	return ws.client.Status()
+}
+
+// Errors returns channel that receives errors from malformed or unexpected
+// websocket messages
+// These errors do not interrupt streaming
+func (ws *WSCtlr) Errors() <-chan error {
+	return ws.client.Errors()
+}
+
+// SubQuotes subscribes to ticker and trades channels via websocket api
+func (ws *WSCtlr) SubQuotes(pairs ...cq.Pair) error {
+	return ws.channelRequests("subscribe", []string{"ticker", "trades"}, pairs...)
+}
+
+// UnsubQuotes unsubscribes from ticker and trades channels
+func (ws *WSCtlr) UnsubQuotes(pairs ...cq.Pair) error {
+	return ws.channelRequests("unsubscribe", []string{"ticker", "trades"}, pairs...)
+}
+
+// SubTrades subscribes to trades channel via websocket api
+func (ws *WSCtlr) SubTrades(pairs ...cq.Pair) error {
+	return ws.channelRequests("subscribe", []string{"trades"}, pairs...)
+}
+
+// UnsubTrades unsubscribes from trades channel
+// Trades channel is shared with quotes so this also stops trade updates in
+// watchlist
+func (ws *WSCtlr) UnsubTrades(pairs ...cq.Pair) error {
+	return ws.channelRequests("unsubscribe", []string{"trades"}, pairs...)
+}
+
+// SubOrderBook is not yet supported
+func (ws *WSCtlr) SubOrderBook(pair cq.Pair) error {
+	return cq.ErrNotSupported
+}
+
+// UnsubOrderBook is not yet supported
+func (ws *WSCtlr) UnsubOrderBook(pair cq.Pair) error {
+	return cq.ErrNotSupported
+}
+
+// channelRequests sends request for each channel and pair
+// Remaining channels for a pair are skipped after its first failure
+func (ws *WSCtlr) channelRequests(event string, channels []string, pairs ...cq.Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	failedSubs := []string{}
+	for _, p := range pairs {
+		s := NewSymbol(p)
+		for _, channel := range channels {
+			err := ws.request(SubscribeMsg{
+				Event:   event,
+				Channel: channel,
+				Symbol:  s,
+			})
+			if err != nil {
+				failedSubs = append(failedSubs, fmt.Sprintf("%v (%v)", s, err))
+				break
+			}
+		}
+	}
+
+	if len(failedSubs) > 0 {
+		return fmt.Errorf("failed to %v the following symbols: %v", event, strings.Join(failedSubs, ", "))
+	}
+
+	return nil
+}
+
+// SubCandles subscribes to candles channel
+// Server sends snapshot of recent candles followed by updates
+func (ws *WSCtlr) SubCandles(pair cq.Pair, interval int, maxBars int) error {
+	key, err := candleKey(pair, interval)
+	if err != nil {
+		return err
+	}
+
+	return ws.request(SubscribeMsg{
+		Event:   "subscribe",
+		Channel: "candles",
+		Key:     key,
+	})
+}
+
+// UnsubCandles unsubscribes from candles channel
+func (ws *WSCtlr) UnsubCandles(pair cq.Pair, interval int, maxBars int) error {
+	key, err := candleKey(pair, interval)
+	if err != nil {
+		return err
+	}
+
+	return ws.request(SubscribeMsg{
+		Event:   "unsubscribe",
+		Channel: "candles",
+		Key:     key,
+	})
+}
+
+// request sends message to event loop to be written to websocket and waits
+// for server's response
+// Returns error from server if request was rejected
+func (ws *WSCtlr) request(msg SubscribeMsg) error {
+	return ws.client.Request(msg)
+}
+
+// Shutdown stops event loop and closes websocket connection
+func (ws *WSCtlr) Shutdown() error {
+	return ws.client.Shutdown()
+}
+
+// Stream connects to Bitfinex websocket API to get streaming data
+// Dropped connections are reconnected and active subscriptions are replayed
+func (ws *WSCtlr) Stream(chans cq.StreamChans, pairs ...cq.Pair) error {
+	ws.out = chans
+	err := ws.client.Stream()
+	if err != nil {
+		return err
+	}
+
+	if len(pairs) > 0 {
+		return ws.SubQuotes(pairs...)
+	}
+	return nil
+}
+
+// Send writes request to websocket and adds it to pending requests keyed by
+// subKey()
+// Requests that need no change on server are answered immediately
+func (ws *WSCtlr) Send(req cq.WSRequest) error {
+	msg := req.Msg.(SubscribeMsg)
+	key := subKey(msg.Channel, msg.Symbol, msg.Key)
+	if p, ok := ws.client.Pending(key); ok {
+		// subscribe made while replayed subscription is pending is answered
+		// with replay's response
+		if p.Replayed() && p.Msg.(SubscribeMsg).Event == msg.Event && !req.Replayed() {
+			ws.client.Attach(key, req)
+			return nil
+		}
+		return errors.New("request already pending")
+	}
+
+	out := msg
+	switch msg.Event {
+	case "subscribe":
+		if _, ok := ws.subs[key]; ok && !req.Replayed() {
+			req.Reply(nil)
+			return nil
+		}
+	case "unsubscribe":
+		id, ok := ws.findChan(key)
+		if !ok {
+			delete(ws.subs, key)
+			req.Reply(nil)
+			return nil
+		}
+		// unsubscribe is sent with channel id only
+		out = SubscribeMsg{
+			Event:  msg.Event,
+			ChanID: id,
+		}
+	}
+
+	err := ws.client.Write(out)
+	if err != nil {
+		if req.Replayed() {
+			// keep replayed subscription for next reconnect
+			ws.subs[key] = msg
+		}
+		return err
+	}
+	ws.client.Pend(key, msg.Event+" "+key, req)
+	return nil
+}
+
+// findChan returns channel id for subscription key
+func (ws *WSCtlr) findChan(key string) (int64, bool) {
+	for id, info := range ws.chans {
+		if info.key == key {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// resolve answers pending request for key
+// Successful requests update subscriptions so they are replayed after reconnect
+func (ws *WSCtlr) resolve(key string, err error) {
+	req, ok := ws.client.Resolve(key)
+	if !ok {
+		if err != nil {
+			ws.client.ReportErr(err)
+		}
+		return
+	}
+	msg := req.Msg.(SubscribeMsg)
+
+	switch true {
+	case err == nil && msg.Event == "subscribe":
+		ws.subs[key] = msg
+	case err == nil:
+		delete(ws.subs, key)
+	case req.Replayed():
+		// replayed subscription was rejected so stop replaying it
+		delete(ws.subs, key)
+		ws.client.ReportErr(fmt.Errorf("resubscribe %v: %v", key, err))
+	}
+
+	req.Reply(err)
+}
+
+// Resubscribe replays all active subscriptions on new connection followed
+// by requests made while disconnected
+// Replayed subscriptions are added back to subs by resolve once server
+// confirms them.  Unsubscribe requests are answered before replay since new
+// connection has no channels.
+// Requests that fail to send are answered so none are returned.
+func (ws *WSCtlr) Resubscribe(queued []cq.WSRequest) ([]cq.WSRequest, error) {
+	send := func(req cq.WSRequest) {
+		err := ws.Send(req)
+		if err != nil {
+			req.Reply(err)
+		}
+	}
+
+	for _, req := range queued {
+		if req.Msg.(SubscribeMsg).Event == "unsubscribe" {
+			send(req)
+		}
+	}
+
+	subs := []SubscribeMsg{}
+	for _, msg := range ws.subs {
+		subs = append(subs, msg)
+	}
+	ws.subs = make(map[string]SubscribeMsg)
+
+	for _, msg := range subs {
+		send(cq.WSRequest{Msg: msg})
+	}
+	for _, req := range queued {
+		if req.Msg.(SubscribeMsg).Event == "subscribe" {
+			send(req)
+		}
+	}
+	return nil, nil
+}
+
+// Disconnected drops channel ids of lost connection
+func (ws *WSCtlr) Disconnected() {
+	ws.chans = make(map[int64]chanInfo)
+}
+
+// Decode parses websocket message
+func (ws *WSCtlr) Decode(b []byte) (interface{}, error) {
+	return decodeWSMsg(b)
+}
+
+// Handle routes data from websocket message to main event loop
+// Returns errReconnect if server asks client to reconnect
+func (ws *WSCtlr) Handle(msg interface{}) error {
+	return ws.handle(msg.(WSMsg))
+}
+
+// handle routes data from websocket message to main event loop
+// Malformed messages are reported on error channel and dropped
+// Returns errReconnect if server asks client to reconnect
+func (ws *WSCtlr) handle(msg WSMsg) error {
+	if msg.Event != nil {
+		return ws.handleEvent(*msg.Event)
+	}
+
+	id, err := msg.chanID()
+	if err != nil {
+		ws.client.ReportErr(err)
+		return nil
+	}
+	info, ok := ws.chans[id]
+	if !ok {
+		return nil
+	}
+
+	switch msg.msgType() {
+	case "hb":
+		return nil
+	case "te":
+		if info.channel != "trades" || len(msg.Data) < 3 {
+			return nil
+		}
+		t := TradeEntry{}
+		err := json.Unmarshal(msg.Data[2], &t)
+		if err != nil {
+			ws.client.ReportErr(fmt.Errorf("malformed trade message: %v", err))
+			return nil
+		}
+		ws.trade(info.pair, t)
+		return nil
+	case "":
+	default:
+		// "tu" repeats "te" with trade id
+		return nil
+	}
+
+	payload := msg.Data[1]
+	switch info.channel {
+	case "ticker":
+		t := TickerEntry{}
+		err := json.Unmarshal(payload, &t)
+		if err != nil {
+			ws.client.ReportErr(fmt.Errorf("malformed ticker message: %v", err))
+			return nil
+		}
+		q := t.quote(info.pair)
+		ws.out.Quotes <- cq.UpdateMsg{
+			Quote: cq.Quote{
+				ID:     q.ID,
+				Ask:    q.Ask,
+				Bid:    q.Bid,
+				Low:    q.Low,
+				High:   q.High,
+				Open:   q.Open,
+				Volume: q.Volume,
+			},
+			Type: cq.TickerUpd,
+		}
+	case "trades":
+		if !isSnapshot(payload) {
+			return nil
+		}
+		trades := []TradeEntry{}
+		err := json.Unmarshal(payload, &trades)
+		if err != nil {
+			ws.client.ReportErr(fmt.Errorf("malformed trades snapshot: %v", err))
+			return nil
+		}
+		for _, t := range trades {
+			trade := newTrade(t)
+			trade.Pair = info.pair
+			ws.out.Trades <- trade
+		}
+	case "candles":
+		entries := []CandleEntry{}
+		updType := cq.CandleUpd
+		if isSnapshot(payload) {
+			updType = cq.CandleSnapshot
+			err = json.Unmarshal(payload, &entries)
+		} else {
+			e := CandleEntry{}
+			err = json.Unmarshal(payload, &e)
+			entries = append(entries, e)
+		}
+		if err != nil {
+			ws.client.ReportErr(fmt.Errorf("malformed candles message: %v", err))
+			return nil
+		}
+		candles, err := newCandles(entries)
+		if err != nil {
+			ws.client.ReportErr(err)
+			return nil
+		}
+		// snapshots are sent newest first
+		sort.Slice(candles, func(i, j int) bool {
+			return candles[i].Timestamp.Before(candles[j].Timestamp)
+		})
+		ws.out.Candles <- cq.CandleUpdMsg{
+			Type:    updType,
+			Pair:    info.pair,
+			Candles: candles,
+		}
+	}
+
+	return nil
+}
+
+// trade routes executed trade to quote router and history router
+func (ws *WSCtlr) trade(pair cq.Pair, t TradeEntry) {
+	trade := newTrade(t)
+	trade.Pair = pair
+
+	ws.out.Quotes <- cq.UpdateMsg{
+		Quote: cq.Quote{
+			ID:    pair,
+			Price: trade.Price,
+			Size:  trade.Size,
+		},
+		Type: cq.TradeUpd,
+	}
+	ws.out.Trades <- trade
+}
+
+// handleEvent handles subscription responses and info messages
+func (ws *WSCtlr) handleEvent(e EventMsg) error {
+	switch e.Event {
+	case "subscribed":
+		key := subKey(e.Channel, e.Symbol, e.Key)
+		symbol := e.Symbol
+		if e.Channel == "candles" {
+			// key format is "trade:5m:tBTCUSD"
+			parts := strings.SplitN(e.Key, ":", 3)
+			if len(parts) == 3 {
+				symbol = parts[2]
+			}
+		}
+		pair, err := NewPair(symbol)
+		if err != nil {
+			// channel's messages cannot be routed without pair
+			ws.resolve(key, err)
+			return nil
+		}
+		ws.chans[e.ChanID] = chanInfo{
+			key:     key,
+			channel: e.Channel,
+			pair:    pair,
+		}
+		ws.resolve(key, nil)
+	case "unsubscribed":
+		info, ok := ws.chans[e.ChanID]
+		if !ok {
+			return nil
+		}
+		delete(ws.chans, e.ChanID)
+		ws.resolve(info.key, nil)
+	case "error":
+		err := fmt.Errorf("%v (code %v)", e.Msg, e.Code)
+		if info, ok := ws.chans[e.ChanID]; ok && e.ChanID != 0 {
+			ws.resolve(info.key, err)
+			return nil
+		}
+		ws.resolve(subKey(e.Channel, e.Symbol, e.Key), err)
+	case "info":
+		if e.Code == infoReconnect {
+			return errReconnect
+		}
+	}
+	return nil
+}
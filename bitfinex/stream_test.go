@@ -0,0 +1,247 @@
+package bitfinex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// newFakeFeed returns feed standing in for Bitfinex's websocket api which
+// answers subscribe messages with reply
+func newFakeFeed(reply func(SubscribeMsg) interface{}) *fakeapi.Feed {
+	return fakeapi.NewFeed(func(b []byte) interface{} {
+		msg := SubscribeMsg{}
+		json.Unmarshal(b, &msg)
+		return reply(msg)
+	})
+}
+
+// next returns next subscribe message received by feed
+func next(t *testing.T, f *fakeapi.Feed) SubscribeMsg {
+	t.Helper()
+	msg := SubscribeMsg{}
+	f.Next(t, &msg)
+	return msg
+}
+
+// subscribed answers requests with channel ids from ids keyed by channel
+// and symbol or key
+// Symbols missing from ids are rejected
+func subscribed(ids map[string]int64) func(SubscribeMsg) interface{} {
+	return func(msg SubscribeMsg) interface{} {
+		if msg.Event == "unsubscribe" {
+			return map[string]interface{}{"event": "unsubscribed", "status": "OK", "chanId": msg.ChanID}
+		}
+		id, ok := ids[msg.Channel+":"+msg.Symbol+msg.Key]
+		if !ok {
+			return map[string]interface{}{
+				"event":   "error",
+				"msg":     "symbol: invalid",
+				"code":    10300,
+				"channel": msg.Channel,
+				"symbol":  msg.Symbol,
+				"key":     msg.Key,
+			}
+		}
+		return map[string]interface{}{
+			"event":   "subscribed",
+			"channel": msg.Channel,
+			"chanId":  id,
+			"symbol":  msg.Symbol,
+			"key":     msg.Key,
+		}
+	}
+}
+
+// startStream connects to feed and starts streaming quotes for pairs
+func startStream(t *testing.T, f *fakeapi.Feed, c fakeapi.Chans, pairs ...cq.Pair) *WSCtlr {
+	t.Helper()
+	ws, err := Connect(f.WSURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Stream(c.Stream(), pairs...)
+	if err != nil {
+		ws.Shutdown()
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestStreamChannels(t *testing.T) {
+	f := newFakeFeed(subscribed(map[string]int64{
+		"ticker:tBTCUSD": 10,
+		"trades:tBTCUSD": 11,
+		"ticker:tETHBTC": 20,
+		"trades:tETHBTC": 21,
+	}))
+	defer f.Close()
+	c := fakeapi.NewChans()
+	btc, eth := mustPair("BTCUSD"), mustPair("ETHBTC")
+	ws := startStream(t, f, c, btc, eth)
+	defer ws.Shutdown()
+
+	// heartbeats are dropped and channel ids are mapped to pairs
+	f.Send <- `[10,"hb"]`
+	f.Send <- `[20,[0.02,10,0.0201,5,0.001,0.05,0.0205,300,0.021,0.019]]`
+	f.Send <- `[10,[7000,1.5,7001,2,100,0.0145,7000.5,1234.5,7100,6800]]`
+	want := cq.UpdateMsg{
+		Quote: cq.Quote{
+			ID:     eth,
+			Bid:    "0.02",
+			Ask:    "0.0201",
+			Low:    "0.019",
+			High:   "0.021",
+			Open:   "0.0195",
+			Volume: "300",
+		},
+		Type: cq.TickerUpd,
+	}
+	if upd := c.Quote(t); upd != want {
+		t.Errorf("got %+v, want %+v", upd, want)
+	}
+	if upd := c.Quote(t); upd.Quote.ID != btc || upd.Quote.Open != "6900.5" {
+		t.Errorf("got %+v, want BTC/USD ticker opening at 6900.5", upd)
+	}
+
+	// snapshots are sent to history only
+	f.Send <- `[11,[[400,1577934244000,1,6999],[399,1577934243000,-2,6998]]]`
+	if trade := c.Trade(t); trade.ID != 400 || trade.Pair != btc || trade.Side != cq.Buy {
+		t.Errorf("got %+v, want bought trade 400", trade)
+	}
+	if trade := c.Trade(t); trade.ID != 399 || trade.Side != cq.Sell || trade.Size != "2" {
+		t.Errorf("got %+v, want sold trade 399 of 2", trade)
+	}
+
+	// "tu" repeats "te" and unknown channels are ignored
+	f.Send <- `[11,"te",[401,1577934245123,-0.25,7000.5]]`
+	f.Send <- `[11,"tu",[401,1577934245123,-0.25,7000.5]]`
+	f.Send <- `[99,"te",[1,1577934245123,1,1]]`
+	f.Send <- `[21,"te",[402,1577934246000,3,0.0205]]`
+	if upd := c.Quote(t); upd.Type != cq.TradeUpd || upd.Quote.ID != btc || upd.Quote.Price != "7000.5" || upd.Quote.Size != "0.25" {
+		t.Errorf("got %+v, want BTC/USD trade update at 7000.5", upd)
+	}
+	if trade := c.Trade(t); trade.ID != 401 || trade.Side != cq.Sell {
+		t.Errorf("got %+v, want sold trade 401", trade)
+	}
+	if upd := c.Quote(t); upd.Quote.ID != eth || upd.Quote.Price != "0.0205" {
+		t.Errorf("got %+v, want ETH/BTC trade update at 0.0205", upd)
+	}
+	if trade := c.Trade(t); trade.ID != 402 || trade.Pair != eth {
+		t.Errorf("got %+v, want ETH/BTC trade 402", trade)
+	}
+}
+
+func TestStreamCandles(t *testing.T) {
+	f := newFakeFeed(subscribed(map[string]int64{
+		"candles:trade:1m:tBTCUSD": 30,
+	}))
+	defer f.Close()
+	c := fakeapi.NewChans()
+	pair := mustPair("BTCUSD")
+	ws := startStream(t, f, c)
+	defer ws.Shutdown()
+
+	err := ws.SubCandles(pair, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := next(t, f); msg.Channel != "candles" || msg.Key != "trade:1m:tBTCUSD" {
+		t.Errorf("got %+v, want candles subscription", msg)
+	}
+
+	f.Send <- `[30,[[1577934300000,7001,7002,7003,7000,1.5],[1577934240000,7000,7001,7002,6999,2.25]]]`
+	upd := c.CandleUpd(t)
+	if upd.Type != cq.CandleSnapshot || upd.Pair != pair || len(upd.Candles) != 2 {
+		t.Fatalf("got %+v, want snapshot of 2 candles", upd)
+	}
+	if !upd.Candles[0].Timestamp.Before(upd.Candles[1].Timestamp) {
+		t.Errorf("snapshot is not in ascending order: %+v", upd.Candles)
+	}
+
+	f.Send <- `[30,[1577934300000,7001,7004,7005,7000,1.75]]`
+	upd = c.CandleUpd(t)
+	want := cq.CandleData{
+		Timestamp: time.Date(2020, 1, 2, 3, 5, 0, 0, time.UTC),
+		Open:      "7001",
+		Close:     "7004",
+		Max:       "7005",
+		Min:       "7000",
+		Volume:    "1.75",
+	}
+	if upd.Type != cq.CandleUpd || len(upd.Candles) != 1 || upd.Candles[0] != want {
+		t.Errorf("got %+v, want update of %+v", upd, want)
+	}
+
+	err = ws.UnsubCandles(pair, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := next(t, f); msg.Event != "unsubscribe" || msg.ChanID != 30 {
+		t.Errorf("got %+v, want unsubscribe from channel 30", msg)
+	}
+}
+
+func TestSubscribeError(t *testing.T) {
+	f := newFakeFeed(subscribed(map[string]int64{
+		"ticker:tBTCUSD": 10,
+		"trades:tBTCUSD": 11,
+	}))
+	defer f.Close()
+	c := fakeapi.NewChans()
+	ws := startStream(t, f, c)
+	defer ws.Shutdown()
+
+	err := ws.SubQuotes(mustPair("BADUSD"))
+	if err == nil || !strings.Contains(err.Error(), "symbol: invalid (code 10300)") {
+		t.Errorf("got error %v, want server's error", err)
+	}
+
+	err = ws.SubQuotes(mustPair("BTCUSD"))
+	if err != nil {
+		t.Errorf("unexpected error after rejected request: %v", err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	f := newFakeFeed(subscribed(map[string]int64{
+		"ticker:tBTCUSD": 10,
+		"trades:tBTCUSD": 11,
+	}))
+	defer f.Close()
+	c := fakeapi.NewChans()
+	ws := startStream(t, f, c, mustPair("BTCUSD"))
+	defer ws.Shutdown()
+	next(t, f)
+	next(t, f)
+
+	f.Send <- map[string]interface{}{"event": "info", "code": infoReconnect}
+	for msg := range ws.Status() {
+		if msg.State == cq.Reconnecting {
+			break
+		}
+	}
+
+	// request is sent after subscriptions are replayed and answered by server
+	err := ws.SubTrades(mustPair("BADUSD"))
+	if err == nil || !strings.Contains(err.Error(), "symbol: invalid") {
+		t.Errorf("got error %v, want server's error", err)
+	}
+	replayed := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		msg := next(t, f)
+		replayed[msg.Channel+":"+msg.Symbol] = true
+	}
+	if !replayed["ticker:tBTCUSD"] || !replayed["trades:tBTCUSD"] {
+		t.Errorf("replayed %v, want ticker and trades of tBTCUSD", replayed)
+	}
+
+	f.Send <- `[11,"te",[401,1577934245123,-0.25,7000.5]]`
+	if trade := c.Trade(t); trade.ID != 401 {
+		t.Errorf("got %+v, want trade 401 on replayed channel", trade)
+	}
+}
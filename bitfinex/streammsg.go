@@ -0,0 +1,97 @@
+package bitfinex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// infoReconnect asks clients to reconnect
+	// https://docs.bitfinex.com/docs/ws-general#info-messages
+	infoReconnect = 20051
+
+	whitespace = " \t\r\n"
+)
+
+// EventMsg contains data from websocket event messages which are JSON objects
+// https://docs.bitfinex.com/docs/ws-general
+type EventMsg struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	ChanID  int64  `json:"chanId"`
+	Symbol  string `json:"symbol"`
+	Key     string `json:"key"`
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+	Status  string `json:"status"`
+}
+
+// WSMsg contains a websocket message
+// Event messages are objects and channel data messages are arrays which
+// start with channel id: [CHAN_ID, ...]
+type WSMsg struct {
+	Event *EventMsg
+	Data  []json.RawMessage
+}
+
+// decodeWSMsg decodes event or channel data message
+func decodeWSMsg(b []byte) (WSMsg, error) {
+	trimmed := bytes.TrimLeft(b, whitespace)
+	if len(trimmed) == 0 {
+		return WSMsg{}, errors.New("empty websocket message")
+	}
+
+	switch trimmed[0] {
+	case '{':
+		e := EventMsg{}
+		err := json.Unmarshal(b, &e)
+		if err != nil {
+			return WSMsg{}, fmt.Errorf("malformed event message: %v", err)
+		}
+		return WSMsg{Event: &e}, nil
+	case '[':
+		data := []json.RawMessage{}
+		err := json.Unmarshal(b, &data)
+		if err != nil {
+			return WSMsg{}, fmt.Errorf("malformed channel message: %v", err)
+		}
+		if len(data) < 2 {
+			return WSMsg{}, fmt.Errorf("malformed channel message: %v", string(b))
+		}
+		return WSMsg{Data: data}, nil
+	}
+	return WSMsg{}, fmt.Errorf("malformed websocket message: %.40v", string(b))
+}
+
+// chanID returns channel id of channel data message
+func (m WSMsg) chanID() (int64, error) {
+	var id int64
+	err := json.Unmarshal(m.Data[0], &id)
+	if err != nil {
+		return 0, fmt.Errorf("malformed channel message: invalid channel id %v", string(m.Data[0]))
+	}
+	return id, nil
+}
+
+// msgType returns "hb", "te", "tu" etc. for channel messages with a type
+// string in second position or "" for snapshots and updates
+func (m WSMsg) msgType() string {
+	var t string
+	if json.Unmarshal(m.Data[1], &t) != nil {
+		return ""
+	}
+	return t
+}
+
+// isSnapshot returns true if payload is an array of arrays
+// Empty arrays are empty snapshots
+func isSnapshot(payload json.RawMessage) bool {
+	p := bytes.TrimLeft(payload, whitespace)
+	if len(p) == 0 || p[0] != '[' {
+		return false
+	}
+	p = bytes.TrimLeft(p[1:], whitespace)
+	return len(p) > 0 && (p[0] == '[' || p[0] == ']')
+}
@@ -0,0 +1,64 @@
+package coinbase
+
+import (
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// candleBuilder builds candles from trades since Coinbase Pro websocket api
+// does not stream candles
+type candleBuilder struct {
+	interval time.Duration
+	current  cq.CandleData
+}
+
+// newCandleBuilder returns builder with current candle set to last candle
+// of snapshot
+func newCandleBuilder(interval int, snapshot []cq.CandleData) *candleBuilder {
+	b := &candleBuilder{
+		interval: time.Duration(interval) * time.Minute,
+	}
+	if len(snapshot) > 0 {
+		b.current = snapshot[len(snapshot)-1]
+	}
+	return b
+}
+
+// add updates current candle with trade and returns it
+// Returns false if trade is older than current candle
+func (b *candleBuilder) add(price string, size string, t time.Time) (cq.CandleData, bool) {
+	start := t.Truncate(b.interval)
+
+	switch true {
+	case start.Before(b.current.Timestamp):
+		return cq.CandleData{}, false
+	case start.After(b.current.Timestamp):
+		b.current = cq.CandleData{
+			Timestamp: start,
+			Open:      price,
+			Close:     price,
+			Min:       price,
+			Max:       price,
+			Volume:    size,
+		}
+		return b.current, true
+	}
+
+	p, _ := cq.ParseDecimal(price)
+	min, _ := cq.ParseDecimal(b.current.Min)
+	max, _ := cq.ParseDecimal(b.current.Max)
+	vol, _ := cq.ParseDecimal(b.current.Volume)
+	s, _ := cq.ParseDecimal(size)
+
+	b.current.Close = price
+	if p.Cmp(min) < 0 {
+		b.current.Min = price
+	}
+	if p.Cmp(max) > 0 {
+		b.current.Max = price
+	}
+	b.current.Volume = vol.Add(s).String()
+
+	return b.current, true
+}
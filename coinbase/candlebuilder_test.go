@@ -0,0 +1,71 @@
+package coinbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+func TestCandleBuilder(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	b := newCandleBuilder(1, []cq.CandleData{{
+		Timestamp: start,
+		Open:      "10",
+		Close:     "11",
+		Min:       "9",
+		Max:       "12",
+		Volume:    "1.5",
+	}})
+
+	tests := []struct {
+		name  string
+		price string
+		size  string
+		t     time.Time
+		ok    bool
+		want  cq.CandleData
+	}{
+		{
+			name:  "older trade is dropped",
+			price: "20",
+			size:  "1",
+			t:     start.Add(-time.Second),
+		},
+		{
+			name:  "new high",
+			price: "13",
+			size:  "0.25",
+			t:     start.Add(10 * time.Second),
+			ok:    true,
+			want:  cq.CandleData{Timestamp: start, Open: "10", Close: "13", Min: "9", Max: "13", Volume: "1.75"},
+		},
+		{
+			name:  "new low",
+			price: "8.5",
+			size:  "0.05",
+			t:     start.Add(20 * time.Second),
+			ok:    true,
+			want:  cq.CandleData{Timestamp: start, Open: "10", Close: "8.5", Min: "8.5", Max: "13", Volume: "1.80"},
+		},
+		{
+			name:  "next candle",
+			price: "11",
+			size:  "2",
+			t:     start.Add(time.Minute + time.Second),
+			ok:    true,
+			want:  cq.CandleData{Timestamp: start.Add(time.Minute), Open: "11", Close: "11", Min: "11", Max: "11", Volume: "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		c, ok := b.add(tt.price, tt.size, tt.t)
+		if ok != tt.ok {
+			t.Errorf("%v: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && c != tt.want {
+			t.Errorf("%v: got %+v, want %+v", tt.name, c, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package coinbase
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// Exchange implements the cq.Exchange interface
+type Exchange struct {
+	cq.BaseExchange
+}
+
+// New returns new instance which implements cq.Exchange interface
+// Sets id, available Pair(s) loaded with rest, and default watchlist
+func New(rest REST) (*Exchange, error) {
+	e := &Exchange{
+		cq.BaseExchange{},
+	}
+	instruments, err := rest.GetInstruments()
+	if err != nil {
+		return nil, errors.New("unable to get available pairs")
+	}
+	e.SetID(cq.Coinbase)
+	e.AddInstrument(instruments...)
+	e.SetWatchlist(e.GetDefaultPairs()...)
+
+	return e, nil
+}
+
+// GetDefaultPairs returns a slice of cq.Pair(s) for Coinbase Pro exchange
+func (e *Exchange) GetDefaultPairs() []cq.Pair {
+	return []cq.Pair{
+		NewPair("BTC-USD"),
+		NewPair("BCH-USD"),
+		NewPair("ETH-USD"),
+		NewPair("ETH-BTC"),
+		NewPair("LTC-USD"),
+		NewPair("LTC-BTC"),
+		NewPair("ZRX-USD"),
+	}
+}
+
+// NewPair takes a product id in the format used by Coinbase Pro APIs
+// (ie, "BTC-USD") and returns an instance of cq.Pair.
+func NewPair(s string) cq.Pair {
+	t := strings.SplitN(s, "-", 2)
+	if len(t) < 2 {
+		return cq.NewMarketPair(cq.Coinbase, s, "", cq.Spot)
+	}
+	return cq.NewMarketPair(cq.Coinbase, t[0], t[1], cq.Spot)
+}
+
+// NewSymbol takes a cq.Pair and returns a product id formatted
+// for use by API
+func NewSymbol(p cq.Pair) string {
+	return p.BaseCurrency() + "-" + p.QuoteCurrency()
+}
@@ -0,0 +1,208 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// restAPI is the base url of Coinbase Pro's REST API
+const restAPI = "https://api.pro.coinbase.com"
+
+// requestInterval keeps requests below public rate limit of 3 requests per
+// second
+const requestInterval = time.Second / 3
+
+// REST implements cq.MarketData with Coinbase Pro's REST API
+// API is the base url of REST API.  Empty API uses Coinbase Pro's api.
+// Requests of REST values that share a limiter are spaced by
+// requestInterval.  REST without limiter is not rate limited.
+type REST struct {
+	API     string
+	limiter *limiter
+}
+
+// NewREST returns REST for Coinbase Pro's api with its own rate limiter
+func NewREST() REST {
+	return REST{limiter: &limiter{}}
+}
+
+// limiter spaces requests so they start at least requestInterval apart
+type limiter struct {
+	sync.Mutex
+	next time.Time
+}
+
+// wait blocks until request may be sent
+// Nil limiter doesn't wait.
+func (l *limiter) wait() {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(requestInterval)
+	l.Unlock()
+
+	time.Sleep(start.Sub(now))
+}
+
+// url returns url of path on REST API
+func (r REST) url(path string) string {
+	if len(r.API) == 0 {
+		return restAPI + path
+	}
+	return r.API + path
+}
+
+// ErrorResp contains error data returned by REST API
+// https://docs.pro.coinbase.com/#errors
+type ErrorResp struct {
+	Message string `json:"message"`
+}
+
+// getJSON performs http GET request and decodes response body into v once
+// limiter allows it
+// Non-200 responses are returned as errors with message from API if present
+func (r REST) getJSON(api string, v interface{}) error {
+	r.limiter.wait()
+
+	resp, err := http.Get(api)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e := ErrorResp{}
+		if json.Unmarshal(body, &e) == nil && len(e.Message) > 0 {
+			return fmt.Errorf("coinbase api error: %v", e.Message)
+		}
+		return fmt.Errorf("coinbase api error: %v", resp.Status)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// ProductsResp contains data for http response for products query
+// https://docs.pro.coinbase.com/#get-products
+type ProductsResp struct {
+	ID             string `json:"id"`
+	BaseCurrency   string `json:"base_currency"`
+	QuoteCurrency  string `json:"quote_currency"`
+	BaseMinSize    string `json:"base_min_size"`
+	BaseMaxSize    string `json:"base_max_size"`
+	QuoteIncrement string `json:"quote_increment"`
+	BaseIncrement  string `json:"base_increment"`
+	Status         string `json:"status"`
+}
+
+// GetPairs queries REST API to get all available crypto pairs.
+// Returns a slice of cq.Pair
+func (r REST) GetPairs() ([]cq.Pair, error) {
+	instruments, err := r.GetInstruments()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []cq.Pair{}
+	for _, i := range instruments {
+		pairs = append(pairs, i.Pair)
+	}
+	return pairs, nil
+}
+
+// GetInstruments queries REST API to get price and size increments of all
+// available crypto pairs
+// Products which aren't online (delisted or not yet trading) are skipped.
+// Fee rates depend on account volume and are not in public api so they are
+// left unknown.  Fees are charged in quote currency.
+func (r REST) GetInstruments() ([]cq.Instrument, error) {
+	products := []ProductsResp{}
+	err := r.getJSON(r.url("/products"), &products)
+	if err != nil {
+		return nil, err
+	}
+
+	instruments := []cq.Instrument{}
+	for _, p := range products {
+		if p.Status != "online" {
+			continue
+		}
+		i := cq.NewInstrument(cq.NewMarketPair(cq.Coinbase, p.BaseCurrency, p.QuoteCurrency, cq.Spot))
+		i.TickSize, _ = cq.ParseDecimal(p.QuoteIncrement)
+		i.LotSize, _ = cq.ParseDecimal(p.BaseIncrement)
+		i.FeeCurrency = i.Pair.QuoteCurrency()
+		instruments = append(instruments, i)
+	}
+	return instruments, nil
+}
+
+// TickerResp holds data for product ticker response
+// https://docs.pro.coinbase.com/#get-product-ticker
+type TickerResp struct {
+	TradeID float64 `json:"trade_id"`
+	Price   string  `json:"price"`
+	Size    string  `json:"size"`
+	Bid     string  `json:"bid"`
+	Ask     string  `json:"ask"`
+	Volume  string  `json:"volume"`
+	Time    string  `json:"time"`
+}
+
+// StatsResp holds data for 24 hour product stats response
+// https://docs.pro.coinbase.com/#get-24hr-stats
+type StatsResp struct {
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Last   string `json:"last"`
+	Volume string `json:"volume"`
+}
+
+// GetQuotes queries ticker and 24 hour stats for each pair
+func (r REST) GetQuotes(pairs ...cq.Pair) ([]cq.Quote, error) {
+	quotes := []cq.Quote{}
+
+	for _, pair := range pairs {
+		ticker := TickerResp{}
+		err := r.getJSON(r.url(fmt.Sprintf("/products/%v/ticker", NewSymbol(pair))), &ticker)
+		if err != nil {
+			return nil, err
+		}
+		stats := StatsResp{}
+		err = r.getJSON(r.url(fmt.Sprintf("/products/%v/stats", NewSymbol(pair))), &stats)
+		if err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, cq.Quote{
+			ExchangeID: cq.Coinbase,
+			ID:         pair,
+			Price:      ticker.Price,
+			Size:       ticker.Size,
+			Bid:        ticker.Bid,
+			Ask:        ticker.Ask,
+			Low:        stats.Low,
+			High:       stats.High,
+			Open:       stats.Open,
+			Volume:     stats.Volume,
+		})
+	}
+
+	return quotes, nil
+}
@@ -0,0 +1,222 @@
+package coinbase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// newFakeREST returns REST client for fake server which serves routes by
+// path
+func newFakeREST(routes map[string]http.HandlerFunc) (REST, *httptest.Server) {
+	srv := fakeapi.NewREST(routes, `{"message":"NotFound"}`)
+	return REST{API: srv.URL}, srv
+}
+
+func TestGetInstruments(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products": fakeapi.Respond(`[{"id":"BTC-USD","base_currency":"BTC","quote_currency":"USD","quote_increment":"0.01","base_increment":"0.00000001","status":"online"},{"id":"BAT-ETH","base_currency":"BAT","quote_currency":"ETH","quote_increment":"0.00000001","base_increment":"1","status":"delisted"}]`),
+	})
+	defer srv.Close()
+
+	instruments, err := rest.GetInstruments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instruments) != 1 {
+		t.Fatalf("got %v instruments, want 1", len(instruments))
+	}
+	i := instruments[0]
+	if i.Pair != NewPair("BTC-USD") {
+		t.Errorf("pair = %v, want BTC/USD", i.Pair.Key())
+	}
+	if places, _ := i.PricePlaces(); places != 2 {
+		t.Errorf("price places = %v, want 2", places)
+	}
+	if places, _ := i.SizePlaces(); places != 8 {
+		t.Errorf("size places = %v, want 8", places)
+	}
+	if i.FeeCurrency != "USD" {
+		t.Errorf("fee currency = %v, want USD", i.FeeCurrency)
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products/BTC-USD/ticker": fakeapi.Respond(`{"trade_id":4,"price":"7000.01","size":"0.5","bid":"7000.00","ask":"7000.02","volume":"1000"}`),
+		"/products/BTC-USD/stats":  fakeapi.Respond(`{"open":"6900.00","high":"7100.00","low":"6800.00","last":"7000.01","volume":"1000"}`),
+	})
+	defer srv.Close()
+
+	quotes, err := rest.GetQuotes(NewPair("BTC-USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cq.Quote{
+		ExchangeID: cq.Coinbase,
+		ID:         NewPair("BTC-USD"),
+		Price:      "7000.01",
+		Size:       "0.5",
+		Bid:        "7000.00",
+		Ask:        "7000.02",
+		Low:        "6800.00",
+		High:       "7100.00",
+		Open:       "6900.00",
+		Volume:     "1000",
+	}
+	if len(quotes) != 1 || quotes[0] != want {
+		t.Errorf("got %+v, want %+v", quotes, want)
+	}
+}
+
+func TestGetQuotesError(t *testing.T) {
+	rest, srv := newFakeREST(nil)
+	defer srv.Close()
+
+	_, err := rest.GetQuotes(NewPair("BTC-USD"))
+	if err == nil || err.Error() != "coinbase api error: NotFound" {
+		t.Errorf("got error %v, want api error", err)
+	}
+}
+
+func TestGetTrades(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products/BTC-USD/trades": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("after") == "11" {
+				w.Write([]byte(`[{"trade_id":10,"price":"6999.99","size":"1","side":"buy","time":"2020-01-02T03:04:04.5Z"}]`))
+				return
+			}
+			w.Write([]byte(`[{"trade_id":11,"price":"7000.01","size":"0.25","side":"sell","time":"2020-01-02T03:04:05.123456Z"}]`))
+		},
+	})
+	defer srv.Close()
+	pair := NewPair("BTC-USD")
+
+	trades, err := rest.GetTrades(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cq.Trade{
+		Pair:      pair,
+		ID:        11,
+		Price:     "7000.01",
+		Size:      "0.25",
+		Side:      cq.Buy,
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 123456000, time.UTC),
+	}
+	if len(trades) != 1 || trades[0] != want {
+		t.Fatalf("got %+v, want %+v", trades, want)
+	}
+
+	older, err := rest.GetOlderTrades(pair, trades[0], 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(older) != 1 || older[0].ID != 10 || older[0].Side != cq.Sell {
+		t.Errorf("got %+v, want trade 10 sold", older)
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		// candles are served newest first for every minute in range
+		"/products/BTC-USD/candles": func(w http.ResponseWriter, r *http.Request) {
+			start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+			end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+			body := "["
+			for ts := end.Truncate(time.Minute); ts.After(start); ts = ts.Add(-time.Minute) {
+				if len(body) > 1 {
+					body += ","
+				}
+				body += "[" + strconv.FormatInt(ts.Unix(), 10) + `,1.5,3,2,2.5,10.25]`
+			}
+			w.Write([]byte(body + "]"))
+		},
+	})
+	defer srv.Close()
+
+	candles, err := rest.GetCandles(NewPair("BTC-USD"), 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 3 {
+		t.Fatalf("got %v candles, want 3", len(candles))
+	}
+	for i := 1; i < len(candles); i++ {
+		if !candles[i].Timestamp.After(candles[i-1].Timestamp) {
+			t.Errorf("candles are not in ascending order: %v", candles)
+		}
+	}
+	c := candles[0]
+	if c.Min != "1.5" || c.Max != "3" || c.Open != "2" || c.Close != "2.5" || c.Volume != "10.25" {
+		t.Errorf("got %+v, want low 1.5, high 3, open 2, close 2.5, volume 10.25", c)
+	}
+}
+
+func TestGetCandlesInterval(t *testing.T) {
+	_, err := REST{}.GetCandles(NewPair("BTC-USD"), 3, 10)
+	if err == nil {
+		t.Error("expected error for unsupported interval")
+	}
+}
+
+func TestNew(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products": fakeapi.Respond(`[{"id":"BTC-USD","base_currency":"BTC","quote_currency":"USD","quote_increment":"0.01","base_increment":"0.00000001","status":"online"},{"id":"ETH-USD","base_currency":"ETH","quote_currency":"USD","quote_increment":"0.01","base_increment":"0.00000001","status":"online"},{"id":"BAT-ETH","base_currency":"BAT","quote_currency":"ETH","quote_increment":"0.00000001","base_increment":"1","status":"delisted"}]`),
+	})
+	defer srv.Close()
+
+	e, err := New(rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.GetAvailablePairs(); len(got) != 2 {
+		t.Errorf("got available pairs %v, want pairs served by rest", got)
+	}
+	if places, _ := e.GetInstrument(NewPair("BTC-USD")).PricePlaces(); places != 2 {
+		t.Errorf("price places = %v, want 2", places)
+	}
+
+	srv.Close()
+	if _, err := New(rest); err == nil {
+		t.Error("expected error when rest api is unavailable")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products": fakeapi.Respond(`[]`),
+	})
+	defer srv.Close()
+
+	// requests of copies sharing limiter are spaced
+	limited := NewREST()
+	limited.API = rest.API
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := limited.GetPairs()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 3*requestInterval {
+		t.Errorf("4 limited requests took %v, want at least %v", d, 3*requestInterval)
+	}
+
+	// REST without limiter doesn't wait
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := rest.GetPairs()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d >= 3*requestInterval {
+		t.Errorf("4 unlimited requests took %v", d)
+	}
+}
@@ -0,0 +1,124 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// maxCandlesPage is the largest number of candles returned by a single request
+const maxCandlesPage = 300
+
+// granularities lists candle intervals in minutes supported by API
+var granularities = map[int]struct{}{
+	1:    {},
+	5:    {},
+	15:   {},
+	60:   {},
+	360:  {},
+	1440: {},
+}
+
+// CandleEntry holds data for element of candles response array
+// Values are ordered: time, low, high, open, close, volume
+// https://docs.pro.coinbase.com/#get-historic-rates
+type CandleEntry [6]json.Number
+
+// GetCandles performs http request/s to retrieve the most recent candles
+// for pair with interval given in minutes
+// Requests for more than one page of candles are paged automatically
+func (r REST) GetCandles(pair cq.Pair, interval int, limit int) ([]cq.CandleData, error) {
+	if _, ok := granularities[interval]; !ok {
+		return nil, fmt.Errorf("unsupported candle interval: %v minutes", interval)
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	granularity := time.Duration(interval) * time.Minute
+
+	candles := []cq.CandleData{}
+	seen := map[time.Time]struct{}{}
+	end := time.Now().UTC()
+	for len(candles) < limit {
+		n := limit - len(candles)
+		if n > maxCandlesPage {
+			n = maxCandlesPage
+		}
+		start := end.Add(-time.Duration(n) * granularity)
+
+		page, err := r.getCandlesPage(pair, granularity, start, end)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, c := range page {
+			if _, ok := seen[c.Timestamp]; ok {
+				continue
+			}
+			seen[c.Timestamp] = struct{}{}
+			candles = append(candles, c)
+			added++
+		}
+		if added == 0 {
+			break
+		}
+		end = start
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp.Before(candles[j].Timestamp)
+	})
+	if len(candles) > limit {
+		candles = candles[len(candles)-limit:]
+	}
+
+	return candles, nil
+}
+
+// getCandlesPage retrieves candles between start and end
+func (r REST) getCandlesPage(pair cq.Pair, granularity time.Duration, start time.Time, end time.Time) ([]cq.CandleData, error) {
+	params := url.Values{}
+	params.Set("granularity", strconv.Itoa(int(granularity.Seconds())))
+	params.Set("start", start.Format(time.RFC3339))
+	params.Set("end", end.Format(time.RFC3339))
+
+	api := r.url(fmt.Sprintf("/products/%v/candles?%v", NewSymbol(pair), params.Encode()))
+	entries := []CandleEntry{}
+	err := r.getJSON(api, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := []cq.CandleData{}
+	for _, e := range entries {
+		c, err := newCandle(e)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+
+	return candles, nil
+}
+
+// newCandle converts CandleEntry instance to cq.CandleData instance
+func newCandle(e CandleEntry) (cq.CandleData, error) {
+	sec, err := e[0].Int64()
+	if err != nil {
+		return cq.CandleData{}, fmt.Errorf("invalid candle timestamp: %v", e[0])
+	}
+
+	return cq.CandleData{
+		Timestamp: time.Unix(sec, 0).UTC(),
+		Min:       e[1].String(),
+		Max:       e[2].String(),
+		Open:      e[3].String(),
+		Close:     e[4].String(),
+		Volume:    e[5].String(),
+	}, nil
+}
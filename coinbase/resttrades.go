@@ -0,0 +1,81 @@
+package coinbase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// TradeEntry holds data for element of trades response array
+// https://docs.pro.coinbase.com/#get-trades
+type TradeEntry struct {
+	ID    float64 `json:"trade_id"`
+	Price string  `json:"price"`
+	Size  string  `json:"size"`
+	Side  string  `json:"side"`
+	Time  string  `json:"time"`
+}
+
+// GetTrades performs http request to retrieve 100 most recent trades
+func (r REST) GetTrades(pair cq.Pair) ([]cq.Trade, error) {
+	trades := []TradeEntry{}
+	t := []cq.Trade{}
+
+	api := r.url(fmt.Sprintf("/products/%v/trades?limit=100", NewSymbol(pair)))
+	err := r.getJSON(api, &trades)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trade := range trades {
+		tr := newTrade(trade)
+		tr.Pair = pair
+		t = append(t, tr)
+	}
+
+	return t, nil
+}
+
+// GetOlderTrades performs http request to retrieve up to limit trades
+// older than trade, newest first
+// Pagination cursor "after" returns trades with lower trade ids
+func (r REST) GetOlderTrades(pair cq.Pair, before cq.Trade, limit int) ([]cq.Trade, error) {
+	entries := []TradeEntry{}
+	api := r.url(fmt.Sprintf("/products/%v/trades?limit=%v&after=%v", NewSymbol(pair), limit, int64(before.ID)))
+	err := r.getJSON(api, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	t := []cq.Trade{}
+	for _, e := range entries {
+		trade := newTrade(e)
+		trade.Pair = pair
+		t = append(t, trade)
+	}
+
+	return t, nil
+}
+
+// newTrade converts TradeEntry instance to cq.Trade instance
+// Side is the maker's side so it is flipped to the aggressor's side
+func newTrade(t TradeEntry) cq.Trade {
+	return cq.Trade{
+		ID:        t.ID,
+		Price:     t.Price,
+		Size:      t.Size,
+		Side:      cq.NewSide(t.Side).Opposite(),
+		Timestamp: parseTime(t.Time),
+	}
+}
+
+// parseTime parses RFC3339 timestamp
+// Returns zero time if timestamp is invalid
+func parseTime(t string) time.Time {
+	t2, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return time.Time{}
+	}
+	return t2
+}
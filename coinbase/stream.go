@@ -0,0 +1,546 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// wsAPI is the url of Coinbase Pro's websocket feed
+const wsAPI = "wss://ws-feed.pro.coinbase.com"
+
+type WSCtlr struct {
+	// client owns connection and matches responses to requests
+	client *cq.WSClient
+	// rest is used to request candle snapshots
+	rest REST
+
+	// fields below are only accessed by event loop
+	chans cq.StreamChans
+	// quoted holds product ids subscribed to ticker and matches channels
+	quoted map[string]struct{}
+	// traded holds product ids subscribed to matches channel for trades only
+	traded map[string]struct{}
+	// candles holds candle builders for product ids subscribed to candles
+	candles map[string]*candleBuilder
+}
+
+// Channel is a websocket channel with product ids
+type Channel struct {
+	Name       string   `json:"name"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// SubscribeMsg contains info to subscribe to websocket data
+// Type is "subscribe" or "unsubscribe"
+// https://docs.pro.coinbase.com/#subscribe
+type SubscribeMsg struct {
+	Type     string    `json:"type"`
+	Channels []Channel `json:"channels"`
+}
+
+// SubRequest contains a subscribe message and the subscription it changes
+// candles is set for candle subscriptions which are built from matches
+// trades is set for subscriptions to matches without tickers
+type SubRequest struct {
+	Msg     SubscribeMsg
+	candles *candleReq
+	trades  bool
+}
+
+// candleReq holds candle subscription details
+type candleReq struct {
+	product  string
+	interval int
+	snapshot []cq.CandleData
+}
+
+// NewWSCtlr returns an instance that is connected to websocket at
+// "wss://ws-feed.pro.coinbase.com"
+// Candle snapshots are requested from rest
+func NewWSCtlr(rest REST) (*WSCtlr, error) {
+	return Connect(wsAPI, rest)
+}
+
+// Connect returns an instance that is connected to websocket at api
+// Candle snapshots are requested from rest
+func Connect(api string, rest REST) (*WSCtlr, error) {
+	ws := &WSCtlr{
+		rest:    rest,
+		quoted:  make(map[string]struct{}),
+		traded:  make(map[string]struct{}),
+		candles: make(map[string]*candleBuilder),
+	}
+	client, err := cq.ConnectWS(cq.WSConfig{
+		Exchange: cq.Coinbase,
+		API:      api,
+	}, ws)
+	if err != nil {
+		return nil, err
+	}
+	ws.client = client
+
+	return ws, nil
+}
+
+// Status returns channel that receives connection state changes
+func (ws *WSCtlr) Status() <-chan cq.ConnStatusMsg {
+	return ws.client.Status()
+}
+
+// Errors returns channel that receives errors from malformed or unexpected
+// websocket messages
+// These errors do not interrupt streaming
+func (ws *WSCtlr) Errors() <-chan error {
+	return ws.client.Errors()
+}
+
+// SubQuotes subscribes to ticker and matches channels via websocket api
+func (ws *WSCtlr) SubQuotes(pairs ...cq.Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	products := newSymbols(pairs...)
+	err := ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "subscribe",
+			Channels: quoteChannels(products),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the following symbols: %v (%v)", strings.Join(products, ", "), err)
+	}
+
+	return nil
+}
+
+// UnsubQuotes unsubscribes from ticker and matches channels
+// Matches are kept for pairs with candle subscriptions
+func (ws *WSCtlr) UnsubQuotes(pairs ...cq.Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	products := newSymbols(pairs...)
+	err := ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "unsubscribe",
+			Channels: quoteChannels(products),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe from the following symbols: %v (%v)", strings.Join(products, ", "), err)
+	}
+
+	return nil
+}
+
+// SubTrades subscribes to matches channel via websocket api
+func (ws *WSCtlr) SubTrades(pairs ...cq.Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	products := newSymbols(pairs...)
+	err := ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "subscribe",
+			Channels: []Channel{{Name: "matches", ProductIDs: products}},
+		},
+		trades: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the following symbols: %v (%v)", strings.Join(products, ", "), err)
+	}
+
+	return nil
+}
+
+// UnsubTrades unsubscribes from matches channel
+// Matches are kept for pairs with quote or candle subscriptions
+func (ws *WSCtlr) UnsubTrades(pairs ...cq.Pair) error {
+	if len(pairs) == 0 {
+		return errors.New("no symbols given")
+	}
+
+	products := newSymbols(pairs...)
+	err := ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "unsubscribe",
+			Channels: []Channel{{Name: "matches", ProductIDs: products}},
+		},
+		trades: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe from the following symbols: %v (%v)", strings.Join(products, ", "), err)
+	}
+
+	return nil
+}
+
+// SubOrderBook is not yet supported
+func (ws *WSCtlr) SubOrderBook(pair cq.Pair) error {
+	return cq.ErrNotSupported
+}
+
+// UnsubOrderBook is not yet supported
+func (ws *WSCtlr) UnsubOrderBook(pair cq.Pair) error {
+	return cq.ErrNotSupported
+}
+
+// SubCandles retrieves candle snapshot from REST API and builds live
+// candles from matches channel
+func (ws *WSCtlr) SubCandles(pair cq.Pair, interval int, maxBars int) error {
+	snapshot, err := ws.rest.GetCandles(pair, interval, maxBars)
+	if err != nil {
+		return err
+	}
+
+	product := NewSymbol(pair)
+	return ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "subscribe",
+			Channels: []Channel{{Name: "matches", ProductIDs: []string{product}}},
+		},
+		candles: &candleReq{
+			product:  product,
+			interval: interval,
+			snapshot: snapshot,
+		},
+	})
+}
+
+// UnsubCandles stops building candles for pair
+func (ws *WSCtlr) UnsubCandles(pair cq.Pair, interval int, maxBars int) error {
+	product := NewSymbol(pair)
+	return ws.request(SubRequest{
+		Msg: SubscribeMsg{
+			Type:     "unsubscribe",
+			Channels: []Channel{{Name: "matches", ProductIDs: []string{product}}},
+		},
+		candles: &candleReq{
+			product:  product,
+			interval: interval,
+		},
+	})
+}
+
+// newSymbols converts pairs to product ids
+func newSymbols(pairs ...cq.Pair) []string {
+	products := []string{}
+	for _, p := range pairs {
+		products = append(products, NewSymbol(p))
+	}
+	return products
+}
+
+// quoteChannels returns channels needed to stream quotes for products
+// Heartbeats let event loop detect stale connections for quiet products
+func quoteChannels(products []string) []Channel {
+	return []Channel{
+		{Name: "ticker", ProductIDs: products},
+		{Name: "matches", ProductIDs: products},
+		{Name: "heartbeat", ProductIDs: products},
+	}
+}
+
+// request sends request to event loop to be written to websocket and waits
+// for server's response
+// Returns error from server if request was rejected
+func (ws *WSCtlr) request(req SubRequest) error {
+	return ws.client.Request(req)
+}
+
+// Shutdown stops event loop and closes websocket connection
+func (ws *WSCtlr) Shutdown() error {
+	return ws.client.Shutdown()
+}
+
+// Stream connects to Coinbase Pro websocket API to get streaming data
+// Dropped connections are reconnected and active subscriptions are replayed
+func (ws *WSCtlr) Stream(chans cq.StreamChans, pairs ...cq.Pair) error {
+	ws.chans = chans
+	err := ws.client.Stream()
+	if err != nil {
+		return err
+	}
+
+	if len(pairs) > 0 {
+		return ws.SubQuotes(pairs...)
+	}
+	return nil
+}
+
+// prepare returns message to write for request
+// Unsubscribing from matches is skipped for products that still need them
+// for quotes, trades or candles
+func (ws *WSCtlr) prepare(req SubRequest) SubscribeMsg {
+	if req.Msg.Type != "unsubscribe" {
+		return req.Msg
+	}
+
+	msg := SubscribeMsg{Type: req.Msg.Type}
+	for _, ch := range req.Msg.Channels {
+		if ch.Name != "matches" {
+			msg.Channels = append(msg.Channels, ch)
+			continue
+		}
+
+		products := []string{}
+		for _, p := range ch.ProductIDs {
+			_, quoted := ws.quoted[p]
+			_, traded := ws.traded[p]
+			_, candles := ws.candles[p]
+			var keep bool
+			switch true {
+			case req.candles != nil:
+				keep = quoted || traded
+			case req.trades:
+				keep = quoted || candles
+			default:
+				keep = traded || candles
+			}
+			if keep {
+				continue
+			}
+			products = append(products, p)
+		}
+		if len(products) > 0 {
+			msg.Channels = append(msg.Channels, Channel{Name: ch.Name, ProductIDs: products})
+		}
+	}
+	return msg
+}
+
+// commit updates subscription state after request succeeds so it can be
+// replayed after reconnect
+func (ws *WSCtlr) commit(req SubRequest) {
+	if req.candles != nil {
+		if req.Msg.Type == "unsubscribe" {
+			delete(ws.candles, req.candles.product)
+			return
+		}
+		ws.candles[req.candles.product] = newCandleBuilder(req.candles.interval, req.candles.snapshot)
+		ws.chans.Candles <- cq.CandleUpdMsg{
+			Type:    cq.CandleSnapshot,
+			Pair:    NewPair(req.candles.product),
+			Candles: req.candles.snapshot,
+		}
+		return
+	}
+
+	products, name := ws.quoted, "ticker"
+	if req.trades {
+		products, name = ws.traded, "matches"
+	}
+	for _, ch := range req.Msg.Channels {
+		if ch.Name != name {
+			continue
+		}
+		for _, p := range ch.ProductIDs {
+			if req.Msg.Type == "unsubscribe" {
+				delete(products, p)
+				continue
+			}
+			products[p] = struct{}{}
+		}
+	}
+}
+
+// Send writes message for request to websocket and adds it to pending
+// requests
+// Requests that change nothing on server are answered immediately
+func (ws *WSCtlr) Send(req cq.WSRequest) error {
+	subReq := req.Msg.(SubRequest)
+	msg := ws.prepare(subReq)
+	if len(msg.Channels) == 0 {
+		ws.commit(subReq)
+		req.Reply(nil)
+		return nil
+	}
+
+	err := ws.client.Write(msg)
+	if err != nil {
+		return err
+	}
+	// API does not echo request ids so responses are matched in order
+	ws.client.Pend(nil, msg.Type, req)
+	return nil
+}
+
+// resolve answers oldest pending request
+func (ws *WSCtlr) resolve(err error) {
+	req, ok := ws.client.Resolve(nil)
+	if !ok {
+		if err != nil {
+			ws.client.ReportErr(err)
+		}
+		return
+	}
+
+	switch true {
+	case err == nil:
+		ws.commit(req.Msg.(SubRequest))
+	case req.Replayed():
+		ws.client.ReportErr(fmt.Errorf("resubscribe: %v", err))
+	}
+
+	req.Reply(err)
+}
+
+// Resubscribe replays all active subscriptions on new connection followed by
+// requests made while disconnected
+// Returns requests that were not sent
+func (ws *WSCtlr) Resubscribe(queued []cq.WSRequest) ([]cq.WSRequest, error) {
+	err := ws.resubscribe()
+	if err != nil {
+		return queued, err
+	}
+	for i, req := range queued {
+		err := ws.Send(req)
+		if err != nil {
+			return queued[i:], err
+		}
+	}
+	return nil, nil
+}
+
+// resubscribe replays all active subscriptions with a single message
+func (ws *WSCtlr) resubscribe() error {
+	quoted := []string{}
+	for p := range ws.quoted {
+		quoted = append(quoted, p)
+	}
+	// products that need matches without tickers
+	matches := []string{}
+	for p := range ws.candles {
+		matches = append(matches, p)
+	}
+	for p := range ws.traded {
+		if _, ok := ws.candles[p]; !ok {
+			matches = append(matches, p)
+		}
+	}
+
+	msg := SubscribeMsg{Type: "subscribe"}
+	if len(quoted) > 0 {
+		msg.Channels = append(msg.Channels, quoteChannels(quoted)...)
+	}
+	if len(matches) > 0 {
+		msg.Channels = append(msg.Channels, Channel{Name: "matches", ProductIDs: matches})
+	}
+	if len(msg.Channels) == 0 {
+		return nil
+	}
+
+	err := ws.client.Write(msg)
+	if err != nil {
+		return err
+	}
+	// request without products so nothing is committed on response
+	ws.client.Pend(nil, "resubscribe", cq.WSRequest{Msg: SubRequest{}})
+	return nil
+}
+
+// Disconnected keeps candle builders so candles continue after reconnect
+func (ws *WSCtlr) Disconnected() {}
+
+// Decode parses websocket message and keeps raw bytes for decodeMsg
+func (ws *WSCtlr) Decode(b []byte) (interface{}, error) {
+	var msg WSMsg
+	err := json.Unmarshal(b, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("malformed websocket message: %v", err)
+	}
+	msg.Raw = b
+	return msg, nil
+}
+
+// Handle routes data from websocket message to main event loop
+func (ws *WSCtlr) Handle(msg interface{}) error {
+	ws.handle(msg.(WSMsg))
+	return nil
+}
+
+// handle routes data from websocket message to main event loop
+// Malformed messages are reported on error channel and dropped
+func (ws *WSCtlr) handle(msg WSMsg) {
+	switch msg.Type {
+	case "subscriptions":
+		ws.resolve(nil)
+		return
+	case "error":
+		ws.resolve(errorMsg(msg))
+		return
+	}
+
+	decoded, err := decodeMsg(msg)
+	if err == errUnknownType {
+		return
+	}
+	if err != nil {
+		ws.client.ReportErr(err)
+		return
+	}
+
+	switch m := decoded.(type) {
+	case TickerMsg:
+		if _, ok := ws.quoted[m.ProductID]; !ok {
+			return
+		}
+		ws.chans.Quotes <- cq.UpdateMsg{
+			Quote: cq.Quote{
+				ID:     NewPair(m.ProductID),
+				Ask:    m.BestAsk,
+				Bid:    m.BestBid,
+				Low:    m.Low24h,
+				High:   m.High24h,
+				Open:   m.Open24h,
+				Volume: m.Volume24h,
+			},
+			Type: cq.TickerUpd,
+		}
+	case MatchMsg:
+		pair := NewPair(m.ProductID)
+		trade := newTrade(TradeEntry{
+			ID:    m.TradeID,
+			Price: m.Price,
+			Size:  m.Size,
+			Side:  m.Side,
+			Time:  m.Time,
+		})
+		trade.Pair = pair
+		ws.chans.Trades <- trade
+
+		if _, ok := ws.quoted[m.ProductID]; ok {
+			ws.chans.Quotes <- cq.UpdateMsg{
+				Quote: cq.Quote{
+					ID:    pair,
+					Price: m.Price,
+					Size:  m.Size,
+				},
+				Type: cq.TradeUpd,
+			}
+		}
+
+		if b, ok := ws.candles[m.ProductID]; ok {
+			t, err := time.Parse(time.RFC3339Nano, m.Time)
+			if err != nil {
+				ws.client.ReportErr(fmt.Errorf("malformed match message: invalid time %v", m.Time))
+				return
+			}
+			if c, ok := b.add(m.Price, m.Size, t); ok {
+				ws.chans.Candles <- cq.CandleUpdMsg{
+					Type:    cq.CandleUpd,
+					Pair:    pair,
+					Candles: []cq.CandleData{c},
+				}
+			}
+		}
+	}
+}
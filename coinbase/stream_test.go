@@ -0,0 +1,265 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// ack answers every request with a subscriptions message
+func ack(SubscribeMsg) interface{} {
+	return map[string]interface{}{"type": "subscriptions", "channels": []Channel{}}
+}
+
+// newFakeFeed returns feed standing in for Coinbase Pro's feed which answers
+// subscribe messages with reply
+func newFakeFeed(reply func(SubscribeMsg) interface{}) *fakeapi.Feed {
+	return fakeapi.NewFeed(func(b []byte) interface{} {
+		msg := SubscribeMsg{}
+		json.Unmarshal(b, &msg)
+		return reply(msg)
+	})
+}
+
+// next returns next subscribe message received by feed
+func next(t *testing.T, f *fakeapi.Feed) SubscribeMsg {
+	t.Helper()
+	msg := SubscribeMsg{}
+	f.Next(t, &msg)
+	return msg
+}
+
+// startStream connects to feed and starts streaming quotes for pairs
+func startStream(t *testing.T, f *fakeapi.Feed, rest REST, c fakeapi.Chans, pairs ...cq.Pair) *WSCtlr {
+	t.Helper()
+	ws, err := Connect(f.WSURL(), rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Stream(c.Stream(), pairs...)
+	if err != nil {
+		ws.Shutdown()
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestStreamQuotes(t *testing.T) {
+	f := newFakeFeed(ack)
+	defer f.Close()
+	c := fakeapi.NewChans()
+	pair := NewPair("BTC-USD")
+	ws := startStream(t, f, REST{}, c, pair)
+	defer ws.Shutdown()
+
+	msg := next(t, f)
+	if msg.Type != "subscribe" || len(msg.Channels) != 3 {
+		t.Fatalf("got %+v, want subscribe to ticker, matches and heartbeat", msg)
+	}
+	for _, ch := range msg.Channels {
+		if len(ch.ProductIDs) != 1 || ch.ProductIDs[0] != "BTC-USD" {
+			t.Errorf("%v channel products = %v, want BTC-USD", ch.Name, ch.ProductIDs)
+		}
+	}
+
+	f.Send <- map[string]interface{}{"type": "heartbeat", "product_id": "BTC-USD"}
+	f.Send <- map[string]interface{}{
+		"type":       "ticker",
+		"product_id": "BTC-USD",
+		"price":      "7000.01",
+		"best_bid":   "7000.00",
+		"best_ask":   "7000.02",
+		"open_24h":   "6900",
+		"volume_24h": "1000",
+		"low_24h":    "6800",
+		"high_24h":   "7100",
+	}
+	want := cq.UpdateMsg{
+		Quote: cq.Quote{
+			ID:     pair,
+			Bid:    "7000.00",
+			Ask:    "7000.02",
+			Low:    "6800",
+			High:   "7100",
+			Open:   "6900",
+			Volume: "1000",
+		},
+		Type: cq.TickerUpd,
+	}
+	select {
+	case upd := <-c.Quotes:
+		if upd != want {
+			t.Errorf("got %+v, want %+v", upd, want)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no ticker update")
+	}
+
+	f.Send <- map[string]interface{}{
+		"type":       "match",
+		"trade_id":   12,
+		"product_id": "BTC-USD",
+		"price":      "7000.02",
+		"size":       "0.5",
+		"side":       "sell",
+		"time":       "2020-01-02T03:04:05.5Z",
+	}
+	select {
+	case trade := <-c.Trades:
+		if trade.ID != 12 || trade.Pair != pair || trade.Price != "7000.02" || trade.Size != "0.5" {
+			t.Errorf("got %+v, want trade 12 of 0.5 at 7000.02", trade)
+		}
+		// maker sold so aggressor bought
+		if trade.Side != cq.Buy {
+			t.Errorf("side = %v, want buy", trade.Side)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no trade")
+	}
+	select {
+	case upd := <-c.Quotes:
+		if upd.Type != cq.TradeUpd || upd.Quote.Price != "7000.02" || upd.Quote.Size != "0.5" {
+			t.Errorf("got %+v, want trade update at 7000.02", upd)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no trade update")
+	}
+}
+
+func TestSubscribeError(t *testing.T) {
+	f := newFakeFeed(func(msg SubscribeMsg) interface{} {
+		for _, ch := range msg.Channels {
+			for _, p := range ch.ProductIDs {
+				if p == "BAD-USD" {
+					return map[string]interface{}{
+						"type":    "error",
+						"message": "Failed to subscribe",
+						"reason":  "BAD-USD is not a valid product",
+					}
+				}
+			}
+		}
+		return ack(msg)
+	})
+	defer f.Close()
+	c := fakeapi.NewChans()
+	ws := startStream(t, f, REST{}, c)
+	defer ws.Shutdown()
+
+	err := ws.SubQuotes(NewPair("BAD-USD"))
+	if err == nil || !strings.Contains(err.Error(), "BAD-USD is not a valid product") {
+		t.Errorf("got error %v, want server's reason", err)
+	}
+
+	err = ws.SubQuotes(NewPair("BTC-USD"))
+	if err != nil {
+		t.Errorf("unexpected error after rejected request: %v", err)
+	}
+}
+
+func TestSubCandles(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Minute)
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/products/BTC-USD/candles": fakeapi.Respond(`[[` + strings.Join([]string{
+			itoa(now.Unix()), "1", "3", "2", "2.5", "10",
+		}, ",") + `],[` + strings.Join([]string{
+			itoa(now.Add(-time.Minute).Unix()), "1", "3", "2", "2.5", "10",
+		}, ",") + `]]`),
+	})
+	defer srv.Close()
+	f := newFakeFeed(ack)
+	defer f.Close()
+	c := fakeapi.NewChans()
+	pair := NewPair("BTC-USD")
+	ws := startStream(t, f, rest, c, pair)
+	defer ws.Shutdown()
+	next(t, f)
+
+	err := ws.SubCandles(pair, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := next(t, f)
+	if len(msg.Channels) != 1 || msg.Channels[0].Name != "matches" {
+		t.Errorf("got %+v, want subscribe to matches", msg)
+	}
+	select {
+	case upd := <-c.Candles:
+		if upd.Type != cq.CandleSnapshot || upd.Pair != pair || len(upd.Candles) != 2 {
+			t.Errorf("got %+v, want snapshot of 2 candles", upd)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no candle snapshot")
+	}
+
+	f.Send <- map[string]interface{}{
+		"type":       "match",
+		"trade_id":   13,
+		"product_id": "BTC-USD",
+		"price":      "3.5",
+		"size":       "1",
+		"side":       "buy",
+		"time":       now.Add(10 * time.Second).Format(time.RFC3339Nano),
+	}
+	select {
+	case upd := <-c.Candles:
+		want := cq.CandleData{Timestamp: now, Open: "2", Close: "3.5", Min: "1", Max: "3.5", Volume: "11"}
+		if upd.Type != cq.CandleUpd || len(upd.Candles) != 1 || upd.Candles[0] != want {
+			t.Errorf("got %+v, want update of %+v", upd, want)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no candle update")
+	}
+
+	// matches are still needed for quotes so nothing is sent to server
+	err = ws.UnsubCandles(pair, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-f.Received:
+		t.Errorf("unexpected request %s", msg)
+	default:
+	}
+}
+
+func TestRequestWhileReconnecting(t *testing.T) {
+	f := newFakeFeed(func(msg SubscribeMsg) interface{} {
+		if msg.Channels[0].ProductIDs[0] == "BAD-USD" {
+			return map[string]interface{}{"type": "error", "message": "Failed to subscribe"}
+		}
+		return ack(msg)
+	})
+	defer f.Close()
+	c := fakeapi.NewChans()
+	ws := startStream(t, f, REST{}, c, NewPair("BTC-USD"))
+	defer ws.Shutdown()
+	next(t, f)
+
+	f.Drop <- struct{}{}
+	for msg := range ws.Status() {
+		if msg.State == cq.Reconnecting {
+			break
+		}
+	}
+
+	// request is sent after subscriptions are replayed and answered by server
+	err := ws.SubQuotes(NewPair("BAD-USD"))
+	if err == nil || !strings.Contains(err.Error(), "Failed to subscribe") {
+		t.Errorf("got error %v, want server's rejection", err)
+	}
+	replayed := next(t, f)
+	if replayed.Channels[0].ProductIDs[0] != "BTC-USD" {
+		t.Errorf("got %+v, want BTC-USD replayed first", replayed)
+	}
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
@@ -0,0 +1,87 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WSMsg contains type of websocket message
+// Raw holds the whole message which is decoded into a typed struct
+// by decodeMsg
+type WSMsg struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+
+	Raw json.RawMessage `json:"-"`
+}
+
+// TickerMsg holds data from "ticker" channel
+// https://docs.pro.coinbase.com/#the-ticker-channel
+type TickerMsg struct {
+	ProductID string  `json:"product_id"`
+	TradeID   float64 `json:"trade_id"`
+	Sequence  int64   `json:"sequence"`
+	Time      string  `json:"time"`
+	Price     string  `json:"price"`
+	Side      string  `json:"side"`
+	LastSize  string  `json:"last_size"`
+	BestBid   string  `json:"best_bid"`
+	BestAsk   string  `json:"best_ask"`
+	Open24h   string  `json:"open_24h"`
+	Volume24h string  `json:"volume_24h"`
+	Low24h    string  `json:"low_24h"`
+	High24h   string  `json:"high_24h"`
+}
+
+// MatchMsg holds data from "matches" channel
+// https://docs.pro.coinbase.com/#the-matches-channel
+type MatchMsg struct {
+	ProductID string  `json:"product_id"`
+	TradeID   float64 `json:"trade_id"`
+	Sequence  int64   `json:"sequence"`
+	Time      string  `json:"time"`
+	Price     string  `json:"price"`
+	Size      string  `json:"size"`
+	Side      string  `json:"side"`
+}
+
+// errUnknownType is returned by decodeMsg for messages that are not handled
+var errUnknownType = errors.New("unknown message type")
+
+// decodeMsg decodes message into typed struct for its Type
+// Returned value is TickerMsg or MatchMsg
+func decodeMsg(msg WSMsg) (interface{}, error) {
+	switch msg.Type {
+	case "ticker":
+		m := TickerMsg{}
+		err := unmarshalMsg(msg, &m, &m.ProductID)
+		return m, err
+	case "match", "last_match":
+		m := MatchMsg{}
+		err := unmarshalMsg(msg, &m, &m.ProductID)
+		return m, err
+	}
+	return nil, errUnknownType
+}
+
+// unmarshalMsg decodes message into v and checks that product id was set
+func unmarshalMsg(msg WSMsg, v interface{}, productID *string) error {
+	err := json.Unmarshal(msg.Raw, v)
+	if err != nil {
+		return fmt.Errorf("malformed %v message: %v", msg.Type, err)
+	}
+	if len(*productID) == 0 {
+		return fmt.Errorf("malformed %v message: missing product_id", msg.Type)
+	}
+	return nil
+}
+
+// errorMsg returns error from "error" message
+func errorMsg(msg WSMsg) error {
+	if len(msg.Reason) > 0 {
+		return fmt.Errorf("%v: %v", msg.Message, msg.Reason)
+	}
+	return errors.New(msg.Message)
+}
@@ -0,0 +1,259 @@
+package cq
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// CrossAbove fires when price rises to or above value
+	CrossAbove AlertCond = iota + 1
+	// CrossBelow fires when price falls to or below value
+	CrossBelow
+	// ChangeBeyond fires when percent change from open reaches value in
+	// either direction
+	ChangeBeyond
+	// SpreadAbove fires when spread between ask and bid is wider than value
+	// in quote currency
+	SpreadAbove
+	// VolumeAbove fires when volume in base currency is above value
+	VolumeAbove
+)
+
+// AlertConds lists alert conditions in the order shown in alerts panel
+var AlertConds = []AlertCond{CrossAbove, CrossBelow, ChangeBeyond, SpreadAbove, VolumeAbove}
+
+// AlertCond is the condition that fires an Alert
+type AlertCond int
+
+// String returns name of condition
+func (c AlertCond) String() string {
+	switch c {
+	case CrossAbove:
+		return "above"
+	case CrossBelow:
+		return "below"
+	case ChangeBeyond:
+		return "change"
+	case SpreadAbove:
+		return "spread"
+	case VolumeAbove:
+		return "volume"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes condition by name
+func (c AlertCond) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText decodes condition from name
+func (c *AlertCond) UnmarshalText(b []byte) error {
+	for _, cond := range AlertConds {
+		if cond.String() == string(b) {
+			*c = cond
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid alert condition: %v", string(b))
+}
+
+// isCross reports whether condition fires on price crossing a level
+// Crossings need a previous price so first quote is only used as reference
+func (c AlertCond) isCross() bool {
+	return c == CrossAbove || c == CrossBelow
+}
+
+// Alert fires once when its condition becomes true for pair
+// Re-armed alerts fire again each time condition becomes true after
+// cooldown has passed.  Other alerts are disabled once fired.
+type Alert struct {
+	ID      int       `json:"id"`
+	Pair    Pair      `json:"pair"`
+	Cond    AlertCond `json:"cond"`
+	Value   Decimal   `json:"value"`
+	Enabled bool      `json:"enabled"`
+	Rearm   bool      `json:"rearm"`
+	// Cooldown is number of seconds before re-armed alert can fire again
+	Cooldown int       `json:"cooldown"`
+	Fired    time.Time `json:"fired"`
+}
+
+// String describes alert condition
+func (a Alert) String() string {
+	switch a.Cond {
+	case CrossAbove:
+		return fmt.Sprintf("%v crosses above %v", a.Pair, a.Value)
+	case CrossBelow:
+		return fmt.Sprintf("%v crosses below %v", a.Pair, a.Value)
+	case ChangeBeyond:
+		return fmt.Sprintf("%v changes %v%% from open", a.Pair, a.Value)
+	case SpreadAbove:
+		return fmt.Sprintf("%v spread wider than %v %v", a.Pair, a.Value, a.Pair.QuoteCurrency())
+	case VolumeAbove:
+		return fmt.Sprintf("%v volume above %v %v", a.Pair, a.Value, a.Pair.BaseCurrency())
+	}
+	return fmt.Sprintf("%v %v %v", a.Pair, a.Cond, a.Value)
+}
+
+// met reports whether condition is true for quote
+// Returns false for ok if quote is missing data used by condition
+// Quote values are compared exactly with alert value.
+func (a Alert) met(q Quote) (met bool, ok bool) {
+	v := a.Value
+	switch a.Cond {
+	case CrossAbove, CrossBelow:
+		p, err := ParseDecimal(q.Price)
+		if err != nil {
+			return false, false
+		}
+		if a.Cond == CrossAbove {
+			return p.Cmp(v) >= 0, true
+		}
+		return p.Cmp(v) <= 0, true
+	case ChangeBeyond:
+		p, err1 := ParseDecimal(q.Price)
+		o, err2 := ParseDecimal(q.Open)
+		if err1 != nil || err2 != nil || o.IsZero() {
+			return false, false
+		}
+		// |p-o| / |o| * 100 >= v without dividing
+		change := p.Sub(o).Abs().Mul(NewDecimal(100, 0))
+		return change.Cmp(v.Mul(o.Abs())) >= 0, true
+	case SpreadAbove:
+		bid, err1 := ParseDecimal(q.Bid)
+		ask, err2 := ParseDecimal(q.Ask)
+		if err1 != nil || err2 != nil {
+			return false, false
+		}
+		return ask.Sub(bid).Cmp(v) > 0, true
+	case VolumeAbove:
+		vol, err := ParseDecimal(q.Volume)
+		if err != nil {
+			return false, false
+		}
+		return vol.Cmp(v) > 0, true
+	}
+	return false, false
+}
+
+// AlertEngine evaluates alerts against quotes routed to main event loop
+type AlertEngine struct {
+	sync.Mutex
+
+	alerts []Alert
+	nextID int
+	// quotes are merged from update messages since they carry partial quotes
+	quotes map[Pair]Quote
+	// state holds whether condition was met by previous quote
+	state map[int]bool
+}
+
+// NewAlertEngine returns engine that evaluates alerts
+func NewAlertEngine(alerts []Alert) *AlertEngine {
+	e := &AlertEngine{
+		quotes: map[Pair]Quote{},
+		state:  map[int]bool{},
+	}
+	for _, a := range alerts {
+		e.alerts = append(e.alerts, a)
+		if a.ID >= e.nextID {
+			e.nextID = a.ID + 1
+		}
+	}
+	return e
+}
+
+// Alerts returns copy of all alerts
+func (e *AlertEngine) Alerts() []Alert {
+	e.Lock()
+	defer e.Unlock()
+
+	return append([]Alert{}, e.alerts...)
+}
+
+// Add adds enabled alert and returns it with its ID set
+func (e *AlertEngine) Add(a Alert) Alert {
+	e.Lock()
+	defer e.Unlock()
+
+	a.ID = e.nextID
+	a.Enabled = true
+	a.Fired = time.Time{}
+	e.nextID++
+	e.alerts = append(e.alerts, a)
+	return a
+}
+
+// Update changes alert with id in place so fields changed since it was
+// read, such as Fired, are kept
+// Changing an alert resets its state so it can fire on next quote
+func (e *AlertEngine) Update(id int, change func(*Alert)) error {
+	e.Lock()
+	defer e.Unlock()
+
+	for i := range e.alerts {
+		if e.alerts[i].ID == id {
+			change(&e.alerts[i])
+			delete(e.state, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("alert %v not found", id)
+}
+
+// Remove deletes alert with id
+func (e *AlertEngine) Remove(id int) {
+	e.Lock()
+	defer e.Unlock()
+
+	for i := range e.alerts {
+		if e.alerts[i].ID == id {
+			e.alerts = append(e.alerts[:i], e.alerts[i+1:]...)
+			delete(e.state, id)
+			return
+		}
+	}
+}
+
+// Check evaluates alerts for pair of update message and returns alerts that
+// fired
+func (e *AlertEngine) Check(upd UpdateMsg) []Alert {
+	e.Lock()
+	defer e.Unlock()
+
+	pair := upd.Quote.ID
+	q := mergeQuote(e.quotes[pair], upd)
+	e.quotes[pair] = q
+
+	now := time.Now()
+	fired := []Alert{}
+	for i := range e.alerts {
+		a := &e.alerts[i]
+		if a.Pair != pair {
+			continue
+		}
+		met, ok := a.met(q)
+		if !ok {
+			continue
+		}
+		prev, known := e.state[a.ID]
+		e.state[a.ID] = met
+
+		// only fire when condition becomes true
+		if !a.Enabled || !met || prev || (!known && a.Cond.isCross()) {
+			continue
+		}
+		if a.Rearm && now.Sub(a.Fired) < time.Duration(a.Cooldown)*time.Second {
+			continue
+		}
+		a.Fired = now
+		if !a.Rearm {
+			a.Enabled = false
+		}
+		fired = append(fired, *a)
+	}
+	return fired
+}
@@ -0,0 +1,184 @@
+package cq
+
+import (
+	"testing"
+	"time"
+)
+
+// tradeUpd returns update setting price of pair
+func tradeUpd(pair Pair, price string) UpdateMsg {
+	return UpdateMsg{Type: TradeUpd, Quote: Quote{ID: pair, Price: price}}
+}
+
+// tickerUpd returns update setting bid, ask, open and volume of pair
+func tickerUpd(pair Pair, bid, ask, open, volume string) UpdateMsg {
+	return UpdateMsg{Type: TickerUpd, Quote: Quote{ID: pair, Bid: bid, Ask: ask, Open: open, Volume: volume}}
+}
+
+func TestAlertEngineCheck(t *testing.T) {
+	btc := NewMarketPair(HitBTC, "BTC", "USD", Spot)
+	eth := NewMarketPair(HitBTC, "ETH", "USD", Spot)
+
+	// step is an update and whether alert fires on it
+	type step struct {
+		upd   UpdateMsg
+		fires bool
+	}
+	tests := []struct {
+		name     string
+		alert    Alert
+		disabled bool
+		steps    []step
+	}{
+		{
+			name:  "cross above",
+			alert: Alert{Pair: btc, Cond: CrossAbove, Value: NewDecimal(7000, 0)},
+			steps: []step{
+				// first price is only a reference even if already above
+				{tradeUpd(btc, "7100"), false},
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7000.00"), true},
+				// disabled once fired
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7100"), false},
+			},
+		},
+		{
+			name:  "cross below",
+			alert: Alert{Pair: btc, Cond: CrossBelow, Value: NewDecimal(69995, 1)},
+			steps: []step{
+				{tradeUpd(btc, "7000"), false},
+				{tradeUpd(btc, "6999.51"), false},
+				{tradeUpd(eth, "100"), false},
+				{tradeUpd(btc, "6999.49"), true},
+			},
+		},
+		{
+			name:  "change up from open",
+			alert: Alert{Pair: btc, Cond: ChangeBeyond, Value: NewDecimal(5, 0)},
+			steps: []step{
+				// no open yet
+				{tradeUpd(btc, "7000"), false},
+				{tickerUpd(btc, "", "", "7000", ""), false},
+				{tradeUpd(btc, "7349.99"), false},
+				{tradeUpd(btc, "7350"), true},
+			},
+		},
+		{
+			name:  "change down from open",
+			alert: Alert{Pair: btc, Cond: ChangeBeyond, Value: NewDecimal(25, 1)},
+			steps: []step{
+				{tickerUpd(btc, "", "", "100", ""), false},
+				// condition already met on first quote fires
+				{tradeUpd(btc, "97.5"), true},
+			},
+		},
+		{
+			name:  "spread",
+			alert: Alert{Pair: btc, Cond: SpreadAbove, Value: NewDecimal(5, 1)},
+			steps: []step{
+				{tickerUpd(btc, "7000", "7000.5", "", ""), false},
+				{tickerUpd(btc, "7000", "7000.51", "", ""), true},
+			},
+		},
+		{
+			name:  "volume",
+			alert: Alert{Pair: eth, Cond: VolumeAbove, Value: NewDecimal(1000, 0)},
+			steps: []step{
+				{tickerUpd(btc, "", "", "", "5000"), false},
+				{tickerUpd(eth, "", "", "", "1000"), false},
+				{tickerUpd(eth, "", "", "", "1000.00000001"), true},
+			},
+		},
+		{
+			name:  "fires once while met",
+			alert: Alert{Pair: btc, Cond: SpreadAbove, Value: NewDecimal(1, 0), Rearm: true},
+			steps: []step{
+				{tickerUpd(btc, "7000", "7002", "", ""), true},
+				{tickerUpd(btc, "7000", "7003", "", ""), false},
+				{tickerUpd(btc, "7000", "7001", "", ""), false},
+				{tickerUpd(btc, "7000", "7002", "", ""), true},
+			},
+		},
+		{
+			name:  "re-arm",
+			alert: Alert{Pair: btc, Cond: CrossAbove, Value: NewDecimal(7000, 0), Rearm: true},
+			steps: []step{
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7000"), true},
+				{tradeUpd(btc, "7100"), false},
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7100"), true},
+			},
+		},
+		{
+			name:  "cooldown",
+			alert: Alert{Pair: btc, Cond: CrossAbove, Value: NewDecimal(7000, 0), Rearm: true, Cooldown: 3600},
+			steps: []step{
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7000"), true},
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7100"), false},
+			},
+		},
+		{
+			name:     "disabled",
+			alert:    Alert{Pair: btc, Cond: CrossAbove, Value: NewDecimal(7000, 0), Rearm: true},
+			disabled: true,
+			steps: []step{
+				{tradeUpd(btc, "6900"), false},
+				{tradeUpd(btc, "7100"), false},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewAlertEngine(nil)
+			a := e.Add(test.alert)
+			if test.disabled {
+				err := e.Update(a.ID, func(a *Alert) { a.Enabled = false })
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i, s := range test.steps {
+				fired := e.Check(s.upd)
+				if s.fires != (len(fired) == 1) || len(fired) > 1 {
+					t.Fatalf("step %v (%+v): fired %v, want fired %v", i, s.upd.Quote, fired, s.fires)
+				}
+				if s.fires && (fired[0].ID != a.ID || fired[0].Fired.IsZero()) {
+					t.Errorf("step %v: got %+v, want alert %v with time fired", i, fired[0], a.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertEngineCooldownPassed(t *testing.T) {
+	btc := NewMarketPair(HitBTC, "BTC", "USD", Spot)
+	e := NewAlertEngine(nil)
+	a := e.Add(Alert{Pair: btc, Cond: VolumeAbove, Value: NewDecimal(10, 0), Rearm: true, Cooldown: 60})
+
+	if fired := e.Check(tickerUpd(btc, "", "", "", "11")); len(fired) != 1 {
+		t.Fatalf("fired %v, want alert", fired)
+	}
+	e.Check(tickerUpd(btc, "", "", "", "9"))
+	if fired := e.Check(tickerUpd(btc, "", "", "", "11")); len(fired) != 0 {
+		t.Fatalf("fired %v during cooldown", fired)
+	}
+
+	// alert fired longer ago than cooldown fires again once condition
+	// becomes true
+	fired := time.Now().Add(-time.Minute)
+	err := e.Update(a.ID, func(a *Alert) { a.Fired = fired })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Check(tickerUpd(btc, "", "", "", "12")); len(got) != 1 || !got[0].Fired.After(fired) {
+		t.Errorf("fired %v, want alert after cooldown", got)
+	}
+	if err := e.Update(a.ID+1, func(*Alert) {}); err == nil {
+		t.Error("expected error updating unknown alert")
+	}
+}
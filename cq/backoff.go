@@ -0,0 +1,37 @@
+package cq
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff calculates exponentially increasing delays with jitter between
+// reconnect attempts
+type Backoff struct {
+	// Min is the delay before first retry
+	Min time.Duration
+	// Max caps the delay between retries
+	Max time.Duration
+
+	attempt uint
+}
+
+// Next returns delay before next attempt
+// Delay doubles with each call and is randomized between half and full value
+// so clients do not reconnect in lockstep
+func (b *Backoff) Next() time.Duration {
+	d := b.Min << b.attempt
+	if d <= 0 || d >= b.Max {
+		d = b.Max
+	} else {
+		b.attempt++
+	}
+
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// Reset sets delay back to Min after a successful connection
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,20 @@
+package cq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 4 * time.Second}
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if d := b.Next(); d < want/2 || d > want {
+			t.Fatalf("got delay %v, want between %v and %v", d, want/2, want)
+		}
+	}
+
+	b.Reset()
+	if d := b.Next(); d < time.Second/2 || d > time.Second {
+		t.Errorf("got delay %v after reset, want between %v and %v", d, time.Second/2, time.Second)
+	}
+}
@@ -0,0 +1,193 @@
+package cq
+
+import (
+	"strings"
+	"sync"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
+)
+
+// BookGroupings lists price groupings offered by Book as multiples of the
+// pair's tick size or smallest price increment seen in book if it is unknown
+var BookGroupings = []int{1, 10, 100, 1000}
+
+// Book is a widget that displays bids and asks of an order book grouped
+// into price buckets with bars showing cumulative size
+type Book struct {
+	widget.BaseWidget
+	sync.RWMutex
+
+	pair Pair
+	// grouping is the bucket size as a multiple of tick
+	grouping int
+	// decimals is the largest number of decimal places seen in prices and
+	// sets tick to 10^-decimals if instrument's tick size is unknown
+	decimals   int
+	instrument Instrument
+	bids       []BookLevel
+	asks       []BookLevel
+}
+
+// bookRow is a price bucket shown in Book
+type bookRow struct {
+	price string
+	size  Decimal
+	total Decimal
+}
+
+// NewBook returns a new instance of Book widget with no levels
+func NewBook(pair Pair) *Book {
+	b := &Book{
+		pair:     pair,
+		grouping: 1,
+	}
+	b.ExtendBaseWidget(b)
+
+	return b
+}
+
+// SetPair clears book and sets pair
+// Used when selected pair changes
+func (b *Book) SetPair(pair Pair) {
+	b.Lock()
+	b.pair = pair
+	b.decimals = 0
+	b.bids, b.asks = nil, nil
+	b.Unlock()
+
+	b.Refresh()
+}
+
+// Update replaces levels with those in BookUpdMsg
+func (b *Book) Update(upd BookUpdMsg) {
+	b.Lock()
+	b.bids, b.asks = upd.Bids, upd.Asks
+	for _, levels := range [][]BookLevel{upd.Bids, upd.Asks} {
+		for _, l := range levels {
+			if d := decimals(l.Price); d > b.decimals {
+				b.decimals = d
+			}
+		}
+	}
+	b.Unlock()
+
+	b.Refresh()
+}
+
+// SetGrouping sets bucket size as a multiple of smallest price increment
+func (b *Book) SetGrouping(grouping int) {
+	if grouping < 1 {
+		grouping = 1
+	}
+	b.Lock()
+	b.grouping = grouping
+	b.Unlock()
+
+	b.Refresh()
+}
+
+// SetInstrument sets trading rules used for tick size and formatting
+func (b *Book) SetInstrument(i Instrument) {
+	b.Lock()
+	b.instrument = i
+	b.Unlock()
+
+	b.Refresh()
+}
+
+// getInstrument returns trading rules of book's pair
+func (b *Book) getInstrument() Instrument {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.instrument
+}
+
+// GetPair returns pair displayed by book
+func (b *Book) GetPair() Pair {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.pair
+}
+
+// rows returns up to depth grouped bids and asks
+// Bids are rounded down and asks are rounded up to bucket price
+func (b *Book) rows(depth int) ([]bookRow, []bookRow) {
+	b.RLock()
+	defer b.RUnlock()
+
+	step := NewDecimal(int64(b.grouping), b.decimals)
+	if _, ok := b.instrument.PricePlaces(); ok {
+		step = b.instrument.TickSize.Mul(NewDecimal(int64(b.grouping), 0))
+	}
+	places := decimals(step.String())
+	bids := group(b.bids, step, places, depth, false)
+	asks := group(b.asks, step, places, depth, true)
+	return bids, asks
+}
+
+// spread returns difference between best ask and best bid
+// Returns false if either side is empty
+func (b *Book) spread() (Decimal, bool) {
+	b.RLock()
+	defer b.RUnlock()
+
+	if len(b.bids) == 0 || len(b.asks) == 0 {
+		return Decimal{}, false
+	}
+	return b.asks[0].PriceDecimal().Sub(b.bids[0].PriceDecimal()), true
+}
+
+// group merges levels into buckets of step size
+// Levels must be ordered from best price
+func group(levels []BookLevel, step Decimal, places int, depth int, roundUp bool) []bookRow {
+	rows := []bookRow{}
+	total := Decimal{}
+	for _, l := range levels {
+		p := l.PriceDecimal().FloorToTick(step)
+		if roundUp {
+			p = l.PriceDecimal().CeilToTick(step)
+		}
+		price := p.StringFixed(places)
+		size := l.SizeDecimal()
+		total = total.Add(size)
+
+		if n := len(rows); n > 0 && rows[n-1].price == price {
+			rows[n-1].size = rows[n-1].size.Add(size)
+			rows[n-1].total = total
+			continue
+		}
+		if len(rows) == depth {
+			break
+		}
+		rows = append(rows, bookRow{
+			price: price,
+			size:  size,
+			total: total,
+		})
+	}
+	return rows
+}
+
+// decimals returns number of decimal places in price
+func decimals(price string) int {
+	i := strings.Index(price, ".")
+	if i < 0 {
+		return 0
+	}
+	return len(strings.TrimRight(price[i+1:], "0"))
+}
+
+// MinSize returns the size that this widget should not shrink below
+func (b *Book) MinSize() fyne.Size {
+	b.ExtendBaseWidget(b)
+	return fyne.NewSize(300, 300)
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (b *Book) CreateRenderer() fyne.WidgetRenderer {
+	b.ExtendBaseWidget(b)
+	return &bookRenderer{book: b}
+}
@@ -0,0 +1,99 @@
+package cq
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/theme"
+)
+
+type bookRenderer struct {
+	book *Book
+
+	// objects is rebuilt every time book is laid out
+	objects []fyne.CanvasObject
+}
+
+func (r *bookRenderer) MinSize() fyne.Size {
+	return r.book.MinSize()
+}
+
+// Layout draws header, asks above spread and bids below spread
+// Asks are drawn with best price at bottom so both sides meet at spread
+func (r *bookRenderer) Layout(size fyne.Size) {
+	pair, instrument := r.book.GetPair(), r.book.getInstrument()
+	rowHeight := canvas.NewText("0", white).MinSize().Height
+	columnWidth := size.Width / 3
+
+	objects := []fyne.CanvasObject{}
+	text := func(s string, c color.Color, col int, y int) {
+		t := canvas.NewText(s, c)
+		t.Alignment = fyne.TextAlignTrailing
+		t.Move(fyne.NewPos(col*columnWidth, y))
+		t.Resize(fyne.NewSize(columnWidth-theme.Padding(), rowHeight))
+		objects = append(objects, t)
+	}
+
+	text(fmt.Sprintf("Price(%v)", pair.QuoteCurrency()), white, 0, 0)
+	text("Size", white, 1, 0)
+	text("Total", white, 2, 0)
+
+	depth := (size.Height/rowHeight - 2) / 2
+	if depth <= 0 {
+		r.objects = objects
+		return
+	}
+	bids, asks := r.book.rows(depth)
+
+	maxTotal := 0.0
+	if n := len(bids); n > 0 {
+		maxTotal = bids[n-1].total.Float64()
+	}
+	if n := len(asks); n > 0 {
+		maxTotal = math.Max(maxTotal, asks[n-1].total.Float64())
+	}
+	row := func(b bookRow, y int, priceColor color.Color, barColor color.Color) {
+		if maxTotal > 0 {
+			w := int(b.total.Float64() / maxTotal * float64(size.Width))
+			bar := canvas.NewRectangle(barColor)
+			bar.Move(fyne.NewPos(size.Width-w, y))
+			bar.Resize(fyne.NewSize(w, rowHeight))
+			objects = append(objects, bar)
+		}
+		text(b.price, priceColor, 0, y)
+		text(instrument.FmtSize(b.size.String()), white, 1, y)
+		text(instrument.FmtSize(b.total.String()), white, 2, y)
+	}
+
+	spreadY := rowHeight * (depth + 1)
+	for i, a := range asks {
+		row(a, spreadY-rowHeight*(i+1), red, askBar)
+	}
+	if spread, ok := r.book.spread(); ok {
+		text("Spread", white, 1, spreadY)
+		text(instrument.FmtPrice(spread.String()), white, 2, spreadY)
+	}
+	for i, b := range bids {
+		row(b, spreadY+rowHeight*(i+1), green, bidBar)
+	}
+
+	r.objects = objects
+}
+
+func (r *bookRenderer) BackgroundColor() color.Color {
+	return theme.BackgroundColor()
+}
+
+func (r *bookRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *bookRenderer) Refresh() {
+	r.Layout(r.book.Size())
+	canvas.Refresh(r.book)
+}
+
+func (r *bookRenderer) Destroy() {}
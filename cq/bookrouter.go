@@ -0,0 +1,89 @@
+package cq
+
+import (
+	"sync"
+	"time"
+)
+
+// bookInterval is the minimum time between order book updates sent to main
+// event loop
+const bookInterval = 250 * time.Millisecond
+
+// BookRouter routes order book updates from websockets to the main event
+// loop
+// Books arriving faster than bookInterval are coalesced so only the latest
+// book is sent
+type BookRouter struct {
+	sync.RWMutex
+	bookIn   chan BookUpdMsg
+	bookOut  chan BookUpdMsg
+	retarget chan Pair
+	shutdown chan struct{}
+}
+
+// StartBookRouter creates router and launches goroutine to route
+// update messages for pair to main event loop
+func StartBookRouter(pair Pair) *BookRouter {
+	r := &BookRouter{
+		bookIn:   make(chan BookUpdMsg, queueSize),
+		bookOut:  make(chan BookUpdMsg, 1),
+		retarget: make(chan Pair),
+		shutdown: make(chan struct{}, 1),
+	}
+
+	go func() {
+		ticker := time.NewTicker(bookInterval)
+		defer ticker.Stop()
+		var latest *BookUpdMsg
+
+	EventLoop:
+		for {
+			select {
+			case <-r.shutdown:
+				break EventLoop
+			case p := <-r.retarget:
+				pair = p
+				latest = nil
+			case <-ticker.C:
+				if latest == nil {
+					continue
+				}
+				// skip tick if main event loop has not taken previous book
+				select {
+				case r.bookOut <- *latest:
+					latest = nil
+				default:
+				}
+			case b := <-r.bookIn:
+				if b.Pair == pair {
+					latest = &b
+				}
+			}
+		}
+	}()
+
+	return r
+}
+
+// GetChannels returns the router's inbound and outbound channels
+func (r *BookRouter) GetChannels() (chan<- BookUpdMsg, <-chan BookUpdMsg) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.bookIn, r.bookOut
+}
+
+// SetPair changes pair routed to main event loop
+// A book for previous pair may still be queued so receivers should check
+// BookUpdMsg.Pair
+func (r *BookRouter) SetPair(pair Pair) {
+	r.retarget <- pair
+}
+
+// Shutdown sends signal to event loop to shutdown routing goroutine
+func (r *BookRouter) Shutdown() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.shutdown <- struct{}{}
+}
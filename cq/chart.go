@@ -0,0 +1,251 @@
+package cq
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
+)
+
+// MaxChartBars is the largest ChartCfg.MaxBars accepted from config
+const MaxChartBars = 1000
+
+// ChartIntervals lists candle intervals in minutes supported by all exchanges
+var ChartIntervals = []int{1, 5, 15, 60, 1440}
+
+// ChartCfg holds settings for Chart widget
+type ChartCfg struct {
+	// MaxBars is the maximum number of candles kept and displayed
+	MaxBars int `json:"maxBars"`
+	// Interval is the length of each candle in minutes
+	Interval int `json:"interval"`
+}
+
+// valid reports whether chart can be loaded with settings on any exchange
+func (c ChartCfg) valid() bool {
+	if c.MaxBars <= 0 || c.MaxBars > MaxChartBars {
+		return false
+	}
+	for _, i := range ChartIntervals {
+		if c.Interval == i {
+			return true
+		}
+	}
+	return false
+}
+
+// intervalName returns short name of candle interval in minutes shown in
+// chart title, e.g. M5, H4 or D1
+func intervalName(interval int) string {
+	switch {
+	case interval > 0 && interval%1440 == 0:
+		return fmt.Sprintf("D%v", interval/1440)
+	case interval > 0 && interval%60 == 0:
+		return fmt.Sprintf("H%v", interval/60)
+	}
+	return fmt.Sprintf("M%v", interval)
+}
+
+// timeLayout returns layout of time axis labels for candles of interval in
+// minutes
+// Labels of hourly candles span days so they show date as well.
+func timeLayout(interval int) string {
+	switch {
+	case interval >= 1440:
+		return "Jan 2"
+	case interval >= 60:
+		return "Jan 2 15:04"
+	}
+	return "15:04"
+}
+
+// CandleData contains price and volume data for a single candle
+// Values are kept as written by the exchange and are only converted to
+// floats to draw chart.
+type CandleData struct {
+	Timestamp   time.Time
+	Open        string
+	Close       string
+	Min         string
+	Max         string
+	Volume      string
+	VolumeQuote string
+}
+
+// candleFloats holds parsed values of CandleData used to draw chart
+type candleFloats struct {
+	open, close, min, max, volume float64
+}
+
+// floats parses string fields of CandleData for drawing
+// Returns error if any field is not a decimal so candle is not drawn at zero
+func (c CandleData) floats() (candleFloats, error) {
+	fields := []string{c.Open, c.Close, c.Min, c.Max, c.Volume}
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		d, err := ParseDecimal(f)
+		if err != nil {
+			return candleFloats{}, err
+		}
+		values[i] = d.Float64()
+	}
+	return candleFloats{values[0], values[1], values[2], values[3], values[4]}, nil
+}
+
+// direction returns Up if candle closed above open, Down if it closed below
+func (c candleFloats) direction() PriceChange {
+	switch true {
+	case c.close > c.open:
+		return Up
+	case c.close < c.open:
+		return Down
+	}
+	return Even
+}
+
+// Chart is a widget that displays candlestick chart with volume pane,
+// price axis and time axis
+type Chart struct {
+	widget.BaseWidget
+	sync.RWMutex
+
+	cfg     ChartCfg
+	pair    Pair
+	candles []CandleData
+	// instrument sets precision of price axis
+	instrument Instrument
+}
+
+// NewChart returns a new instance of Chart widget with candles set
+func NewChart(cfg ChartCfg, pair Pair, candles []CandleData) *Chart {
+	c := &Chart{
+		cfg:  cfg,
+		pair: pair,
+	}
+	c.ExtendBaseWidget(c)
+	c.setCandles(candles)
+
+	return c
+}
+
+// SetCandles replaces all candles in chart
+// Used when CandleSnapshot message is received
+func (c *Chart) SetCandles(candles []CandleData) {
+	c.Lock()
+	c.setCandles(candles)
+	c.Unlock()
+
+	c.Refresh()
+}
+
+func (c *Chart) setCandles(candles []CandleData) {
+	c.candles = append([]CandleData{}, candles...)
+	sort.Slice(c.candles, func(i, j int) bool {
+		return c.candles[i].Timestamp.Before(c.candles[j].Timestamp)
+	})
+	c.trim()
+}
+
+// Update applies CandleUpd data to chart
+// Candles with timestamp of an existing bar replace that bar and newer
+// candles are appended
+func (c *Chart) Update(candles []CandleData) {
+	c.Lock()
+	for _, candle := range candles {
+		c.update(candle)
+	}
+	c.trim()
+	c.Unlock()
+
+	c.Refresh()
+}
+
+func (c *Chart) update(candle CandleData) {
+	for i := len(c.candles) - 1; i >= 0; i-- {
+		switch true {
+		case c.candles[i].Timestamp.Equal(candle.Timestamp):
+			c.candles[i] = candle
+			return
+		case c.candles[i].Timestamp.Before(candle.Timestamp):
+			c.candles = append(c.candles, CandleData{})
+			copy(c.candles[i+2:], c.candles[i+1:])
+			c.candles[i+1] = candle
+			return
+		}
+	}
+	c.candles = append([]CandleData{candle}, c.candles...)
+}
+
+// trim drops oldest candles beyond ChartCfg.MaxBars
+func (c *Chart) trim() {
+	if c.cfg.MaxBars > 0 && len(c.candles) > c.cfg.MaxBars {
+		c.candles = c.candles[len(c.candles)-c.cfg.MaxBars:]
+	}
+}
+
+// SetPair replaces pair and all candles in chart
+// Used when selected pair changes
+func (c *Chart) SetPair(pair Pair, candles []CandleData) {
+	c.Lock()
+	c.pair = pair
+	c.setCandles(candles)
+	c.Unlock()
+
+	c.Refresh()
+}
+
+// SetInstrument sets trading rules used to format price axis
+func (c *Chart) SetInstrument(i Instrument) {
+	c.Lock()
+	c.instrument = i
+	c.Unlock()
+
+	c.Refresh()
+}
+
+// getInstrument returns trading rules of chart's pair
+func (c *Chart) getInstrument() Instrument {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.instrument
+}
+
+// GetCfg returns chart settings
+func (c *Chart) GetCfg() ChartCfg {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.cfg
+}
+
+// GetPair returns pair displayed by chart
+func (c *Chart) GetPair() Pair {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.pair
+}
+
+// getCandles returns a copy of candles for rendering
+func (c *Chart) getCandles() []CandleData {
+	c.RLock()
+	defer c.RUnlock()
+
+	return append([]CandleData{}, c.candles...)
+}
+
+// MinSize returns the size that this widget should not shrink below
+func (c *Chart) MinSize() fyne.Size {
+	c.ExtendBaseWidget(c)
+	return fyne.NewSize(400, 300)
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (c *Chart) CreateRenderer() fyne.WidgetRenderer {
+	c.ExtendBaseWidget(c)
+	return newChartRenderer(c)
+}
@@ -0,0 +1,49 @@
+package cq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalName(t *testing.T) {
+	tests := []struct {
+		interval int
+		name     string
+		label    string
+	}{
+		{1, "M1", "03:04"},
+		{15, "M15", "03:04"},
+		{60, "H1", "Jan 2 03:04"},
+		{240, "H4", "Jan 2 03:04"},
+		{1440, "D1", "Jan 2"},
+		{10080, "D7", "Jan 2"},
+	}
+	ts := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	for _, test := range tests {
+		if got := intervalName(test.interval); got != test.name {
+			t.Errorf("intervalName(%v) = %v, want %v", test.interval, got, test.name)
+		}
+		if got := ts.Format(timeLayout(test.interval)); got != test.label {
+			t.Errorf("interval %v: got label %q, want %q", test.interval, got, test.label)
+		}
+	}
+}
+
+func TestCandleFloats(t *testing.T) {
+	c := CandleData{Open: "1.5", Close: "2", Min: "1e-8", Max: "3", Volume: "10.25"}
+	got, err := c.floats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (candleFloats{1.5, 2, 1e-8, 3, 10.25}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	c.Max = "abc"
+	if _, err := c.floats(); err == nil {
+		t.Error("expected error for malformed max")
+	}
+	if _, err := (CandleData{}).floats(); err == nil {
+		t.Error("expected error for empty candle")
+	}
+}
@@ -0,0 +1,183 @@
+package cq
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/theme"
+)
+
+const (
+	// number of labels on price axis
+	priceTicks = 5
+	// share of plot height used by volume pane
+	volumeShare = 0.2
+	// share of bar width used by candle body
+	bodyShare = 0.7
+)
+
+var gridColor = color.RGBA{R: 80, G: 80, B: 80, A: 255}
+
+type chartRenderer struct {
+	chart *Chart
+
+	title *canvas.Text
+	// objects is rebuilt every time chart is laid out
+	objects []fyne.CanvasObject
+}
+
+func newChartRenderer(c *Chart) *chartRenderer {
+	title := canvas.NewText("", white)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	return &chartRenderer{chart: c, title: title}
+}
+
+func (r *chartRenderer) MinSize() fyne.Size {
+	return r.chart.MinSize()
+}
+
+// Layout draws candle bodies, wicks, volume bars and axes scaled to size
+func (r *chartRenderer) Layout(size fyne.Size) {
+	cfg, pair, candles := r.chart.GetCfg(), r.chart.GetPair(), r.chart.getCandles()
+	instrument := r.chart.getInstrument()
+
+	r.title.Text = fmt.Sprintf("%v  %v", pair, intervalName(cfg.Interval))
+	r.title.Move(fyne.NewPos(theme.Padding(), 0))
+	r.title.Resize(r.title.MinSize())
+	objects := []fyne.CanvasObject{r.title}
+
+	// reserve space for title, price axis and time axis
+	textSize := canvas.NewText("000000.00000", white).MinSize()
+	top := r.title.MinSize().Height + theme.Padding()
+	axisWidth := textSize.Width + theme.Padding()*2
+	plotWidth := size.Width - axisWidth
+	plotHeight := size.Height - top - textSize.Height - theme.Padding()
+	if plotWidth <= 0 || plotHeight <= 0 || len(candles) == 0 {
+		r.objects = objects
+		return
+	}
+	volHeight := int(float64(plotHeight) * volumeShare)
+	priceHeight := plotHeight - volHeight - theme.Padding()
+
+	bars := cfg.MaxBars
+	if bars < len(candles) {
+		bars = len(candles)
+	}
+	barWidth := float64(plotWidth) / float64(bars)
+	bodyWidth := int(math.Max(1, barWidth*bodyShare))
+
+	// find price and volume ranges
+	// malformed candles leave a gap rather than a bar drawn at zero
+	data := make([]candleFloats, len(candles))
+	valid := make([]bool, len(candles))
+	high, low, maxVol := -math.MaxFloat64, math.MaxFloat64, 0.0
+	for i, c := range candles {
+		d, err := c.floats()
+		if err != nil {
+			continue
+		}
+		data[i], valid[i] = d, true
+		high = math.Max(high, d.max)
+		low = math.Min(low, d.min)
+		maxVol = math.Max(maxVol, d.volume)
+	}
+	if high < low {
+		r.objects = objects
+		return
+	}
+	if high == low {
+		high, low = high+1, low-1
+	}
+	priceY := func(p float64) int {
+		return top + int((high-p)/(high-low)*float64(priceHeight))
+	}
+	volBottom := top + plotHeight
+
+	// price axis with grid lines
+	for i := 0; i <= priceTicks; i++ {
+		p := low + (high-low)*float64(i)/priceTicks
+		y := priceY(p)
+
+		grid := canvas.NewLine(gridColor)
+		grid.StrokeWidth = 1
+		grid.Position1 = fyne.NewPos(0, y)
+		grid.Position2 = fyne.NewPos(plotWidth, y)
+
+		label := canvas.NewText(instrument.fmtValue(decimalFromFloat(p)), white)
+		label.Alignment = fyne.TextAlignTrailing
+		label.Move(fyne.NewPos(plotWidth, y-textSize.Height/2))
+		label.Resize(fyne.NewSize(axisWidth-theme.Padding(), textSize.Height))
+
+		objects = append(objects, grid, label)
+	}
+
+	// separator between price pane and volume pane
+	sep := canvas.NewLine(gridColor)
+	sep.StrokeWidth = 1
+	sep.Position1 = fyne.NewPos(0, volBottom-volHeight)
+	sep.Position2 = fyne.NewPos(plotWidth, volBottom-volHeight)
+	objects = append(objects, sep)
+
+	// candles are right aligned so most recent bar is next to price axis
+	offset := bars - len(candles)
+	labelEvery := int(math.Max(1, math.Ceil(float64(textSize.Width)/barWidth)))
+	layout := timeLayout(cfg.Interval)
+	for i, d := range data {
+		center := int((float64(offset+i) + 0.5) * barWidth)
+
+		// time axis labels are spaced so they do not overlap
+		if (len(data)-1-i)%labelEvery == 0 {
+			label := canvas.NewText(candles[i].Timestamp.Local().Format(layout), white)
+			label.Alignment = fyne.TextAlignCenter
+			label.Move(fyne.NewPos(center-textSize.Width/2, volBottom+theme.Padding()))
+			label.Resize(fyne.NewSize(textSize.Width, textSize.Height))
+			objects = append(objects, label)
+		}
+		if !valid[i] {
+			continue
+		}
+
+		c := setColor(d.direction())
+
+		wick := canvas.NewLine(c)
+		wick.StrokeWidth = 1
+		wick.Position1 = fyne.NewPos(center, priceY(d.max))
+		wick.Position2 = fyne.NewPos(center, priceY(d.min))
+
+		bodyTop := priceY(math.Max(d.open, d.close))
+		bodyHeight := int(math.Max(1, float64(priceY(math.Min(d.open, d.close))-bodyTop)))
+		body := canvas.NewRectangle(c)
+		body.Move(fyne.NewPos(center-bodyWidth/2, bodyTop))
+		body.Resize(fyne.NewSize(bodyWidth, bodyHeight))
+
+		volBarHeight := 0
+		if maxVol > 0 {
+			volBarHeight = int(d.volume / maxVol * float64(volHeight))
+		}
+		vol := canvas.NewRectangle(c)
+		vol.Move(fyne.NewPos(center-bodyWidth/2, volBottom-volBarHeight))
+		vol.Resize(fyne.NewSize(bodyWidth, volBarHeight))
+
+		objects = append(objects, wick, body, vol)
+	}
+
+	r.objects = objects
+}
+
+func (r *chartRenderer) BackgroundColor() color.Color {
+	return theme.BackgroundColor()
+}
+
+func (r *chartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *chartRenderer) Refresh() {
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *chartRenderer) Destroy() {}
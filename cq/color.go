@@ -18,3 +18,9 @@ func setColor(c PriceChange) color.Color {
 	// if Even
 	return white
 }
+
+// bidBar and askBar are backgrounds of cumulative size bars in Book
+var (
+	bidBar = color.RGBA{R: 0, G: 70, B: 20, A: 255}
+	askBar = color.RGBA{R: 80, G: 0, B: 6, A: 255}
+)
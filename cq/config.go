@@ -0,0 +1,333 @@
+package cq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ConfigVersion is the version of config files written by SaveConfig
+	ConfigVersion = 2
+
+	// DarkTheme and LightTheme are theme names used in Config
+	DarkTheme  = "dark"
+	LightTheme = "light"
+)
+
+// Config holds user settings that are kept across runs
+type Config struct {
+	Version int `json:"version"`
+	// Exchange is the exchange shown at startup
+	Exchange ExchangeID `json:"exchange"`
+	// Exchanges holds settings for each exchange keyed by ExchangeID.String()
+	Exchanges map[string]ExchangeCfg `json:"exchanges"`
+	Chart     ChartCfg               `json:"chart"`
+	History   HistoryCfg             `json:"history"`
+	Portfolio PortfolioCfg           `json:"portfolio"`
+	// Reference is the currency of watchlist's reference columns
+	// Empty reference hides them
+	Reference string    `json:"reference,omitempty"`
+	Window    WindowCfg `json:"window"`
+	Theme     string    `json:"theme"`
+}
+
+// ExchangeCfg holds settings for a single exchange
+// Empty Watchlist means exchange's default pairs are used
+type ExchangeCfg struct {
+	Watchlist []Pair `json:"watchlist"`
+	Selected  *Pair  `json:"selected,omitempty"`
+	// Filters holds History trade filters of pairs
+	Filters map[Pair]TradeFilter `json:"filters,omitempty"`
+	Alerts  []Alert              `json:"alerts,omitempty"`
+}
+
+// WindowCfg holds size of main window
+type WindowCfg struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// migrations upgrade raw config from version at index to next version
+// Config files written before versioning are version 0
+var migrations = []func(map[string]json.RawMessage) error{
+	// version 0 files have no version field and may be missing settings
+	// which are filled in from defaults when decoded
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// version 1 files may store pairs as "BASE/QUOTE" without exchange
+	migrateExchangePairs,
+}
+
+// migrateExchangePairs rewrites pairs stored without exchange in each
+// exchange's settings to keys with that exchange
+// Settings of unknown exchanges are left unchanged.
+func migrateExchangePairs(raw map[string]json.RawMessage) error {
+	exchanges := map[string]map[string]json.RawMessage{}
+	if b, ok := raw["exchanges"]; !ok || string(b) == "null" {
+		return nil
+	}
+	err := json.Unmarshal(raw["exchanges"], &exchanges)
+	if err != nil {
+		return err
+	}
+
+	for name, cfg := range exchanges {
+		id, ok := parseExchangeID(name)
+		if !ok || cfg == nil {
+			continue
+		}
+		err := migratePairs(cfg, id)
+		if err != nil {
+			return fmt.Errorf("exchange %v: %v", name, err)
+		}
+	}
+
+	b, err := json.Marshal(exchanges)
+	if err != nil {
+		return err
+	}
+	raw["exchanges"] = b
+	return nil
+}
+
+// migratePairs sets exchange id on pairs of raw exchange settings
+func migratePairs(cfg map[string]json.RawMessage, id ExchangeID) error {
+	key := func(s string) (string, error) {
+		p, err := ParsePair(s)
+		if err != nil {
+			return "", err
+		}
+		if p.Exchange() == 0 {
+			p = p.WithExchange(id)
+		}
+		return p.Key(), nil
+	}
+
+	// rewrite decodes field into v, calls f and encodes v back
+	rewrite := func(field string, v interface{}, f func() error) error {
+		b, ok := cfg[field]
+		if !ok || string(b) == "null" {
+			return nil
+		}
+		err := json.Unmarshal(b, v)
+		if err != nil {
+			return err
+		}
+		err = f()
+		if err != nil {
+			return err
+		}
+		cfg[field], err = json.Marshal(v)
+		return err
+	}
+
+	watchlist := []string{}
+	err := rewrite("watchlist", &watchlist, func() (err error) {
+		for i := range watchlist {
+			if watchlist[i], err = key(watchlist[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var selected string
+	err = rewrite("selected", &selected, func() (err error) {
+		selected, err = key(selected)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	filters := map[string]json.RawMessage{}
+	err = rewrite("filters", &filters, func() error {
+		migrated := map[string]json.RawMessage{}
+		for s, f := range filters {
+			k, err := key(s)
+			if err != nil {
+				return err
+			}
+			migrated[k] = f
+		}
+		filters = migrated
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	alerts := []map[string]json.RawMessage{}
+	return rewrite("alerts", &alerts, func() error {
+		for _, a := range alerts {
+			var pair string
+			if json.Unmarshal(a["pair"], &pair) != nil {
+				continue
+			}
+			k, err := key(pair)
+			if err != nil {
+				return err
+			}
+			a["pair"], err = json.Marshal(k)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DefaultConfig returns settings used when no config file exists
+func DefaultConfig() Config {
+	return Config{
+		Version:   ConfigVersion,
+		Exchange:  HitBTC,
+		Exchanges: map[string]ExchangeCfg{},
+		Chart: ChartCfg{
+			MaxBars:  100,
+			Interval: 5,
+		},
+		History: HistoryCfg{
+			ColorMode: ColorBySide,
+			Depth:     DefaultHistoryDepth,
+			Buffer:    DefaultHistoryBuffer,
+		},
+		Portfolio: PortfolioCfg{
+			Home:     "USD",
+			Holdings: []Holding{},
+		},
+		Window: WindowCfg{
+			Width:  1500,
+			Height: 1000,
+		},
+		Theme: DarkTheme,
+	}
+}
+
+// ConfigPath returns path of config file in user config directory
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cq-gui", "config.json"), nil
+}
+
+// LoadConfig reads config file at path and migrates it to ConfigVersion
+// Returns DefaultConfig if file does not exist
+func LoadConfig(path string) (Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return decodeConfig(b)
+}
+
+// decodeConfig migrates raw config to ConfigVersion and decodes it over
+// default settings
+func decodeConfig(b []byte) (Config, error) {
+	raw := map[string]json.RawMessage{}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid config: %v", err)
+	}
+
+	version := 0
+	if v, ok := raw["version"]; ok {
+		err = json.Unmarshal(v, &version)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid config version: %v", err)
+		}
+	}
+	if version > ConfigVersion {
+		return Config{}, fmt.Errorf("config version %v is newer than supported version %v", version, ConfigVersion)
+	}
+	for ; version < ConfigVersion; version++ {
+		err = migrations[version](raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("unable to migrate config from version %v: %v", version, err)
+		}
+	}
+	raw["version"] = json.RawMessage(fmt.Sprint(ConfigVersion))
+
+	b, err = json.Marshal(raw)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := DefaultConfig()
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid config: %v", err)
+	}
+	if cfg.Exchanges == nil {
+		cfg.Exchanges = map[string]ExchangeCfg{}
+	}
+	cfg.validate()
+
+	return cfg, nil
+}
+
+// validate replaces settings that can't be used with defaults
+// Edited or outdated files shouldn't keep app from starting
+func (c *Config) validate() {
+	def := DefaultConfig()
+	if !c.Chart.valid() {
+		c.Chart = def.Chart
+	}
+	if c.History.Depth <= 0 {
+		c.History.Depth = def.History.Depth
+	}
+	if c.History.Buffer < 0 {
+		c.History.Buffer = def.History.Buffer
+	}
+	if c.History.Aggregate < 0 {
+		c.History.Aggregate = def.History.Aggregate
+	}
+	if c.Window.Width <= 0 || c.Window.Height <= 0 {
+		c.Window = def.Window
+	}
+}
+
+// SaveConfig writes config to path
+// File is replaced atomically so a failed write keeps previous settings
+func SaveConfig(path string, cfg Config) error {
+	cfg.Version = ConfigVersion
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "config-*.json")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
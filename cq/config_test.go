@@ -0,0 +1,235 @@
+package cq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tempConfig returns path of config file in new temporary directory and
+// function that removes directory
+func tempConfig(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cq-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "cq-gui", "config.json"), func() { os.RemoveAll(dir) }
+}
+
+// writeConfig writes raw config file to path
+func writeConfig(t *testing.T, path string, s string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path, []byte(s), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	path, cleanup := tempConfig(t)
+	defer cleanup()
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("got %+v, want defaults", cfg)
+	}
+}
+
+func TestLoadConfigPartial(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want func(*Config)
+	}{
+		{
+			name: "version 0 without version field",
+			file: `{"exchange":1,"theme":"light"}`,
+			want: func(c *Config) {
+				c.Exchange = Coinbase
+				c.Theme = LightTheme
+			},
+		},
+		{
+			name: "nested settings keep defaults of missing fields",
+			file: `{"version":1,"chart":{"interval":15},"history":{"aggregate":500}}`,
+			want: func(c *Config) {
+				c.Chart.Interval = 15
+				c.History.Aggregate = 500
+			},
+		},
+		{
+			name: "unknown fields are ignored",
+			file: `{"version":1,"removed":true}`,
+			want: func(c *Config) {},
+		},
+		{
+			name: "invalid chart falls back to defaults",
+			file: `{"version":1,"chart":{"interval":7,"maxBars":50}}`,
+			want: func(c *Config) {},
+		},
+		{
+			name: "invalid bar count falls back to defaults",
+			file: `{"version":1,"chart":{"interval":15,"maxBars":-1}}`,
+			want: func(c *Config) {},
+		},
+		{
+			name: "version 1 pairs get exchange of their settings",
+			file: `{"version":1,"exchanges":{"HitBTC":{"watchlist":["BTC/USD","COINBASE:ETH/USD"],"selected":"BTC/USD","filters":{"BTC/USD":{"minSize":1}},"alerts":[{"id":1,"pair":"BTC/USD","value":100}]},"Unknown":{"watchlist":["BTC/USD"]}}}`,
+			want: func(c *Config) {
+				btc := NewMarketPair(HitBTC, "BTC", "USD", Spot)
+				c.Exchanges = map[string]ExchangeCfg{
+					"HitBTC": {
+						Watchlist: []Pair{btc, NewMarketPair(Coinbase, "ETH", "USD", Spot)},
+						Selected:  &btc,
+						Filters:   map[Pair]TradeFilter{btc: {MinSize: NewDecimal(1, 0)}},
+						Alerts:    []Alert{{ID: 1, Pair: btc, Value: NewDecimal(100, 0)}},
+					},
+					"Unknown": {Watchlist: []Pair{NewPair("BTC", "USD")}},
+				}
+			},
+		},
+		{
+			name: "version 2 pairs are not migrated",
+			file: `{"version":2,"exchanges":{"Bitfinex":{"watchlist":["BTC/USD:PERP"]}}}`,
+			want: func(c *Config) {
+				c.Exchanges = map[string]ExchangeCfg{
+					"Bitfinex": {Watchlist: []Pair{NewMarketPair(0, "BTC", "USD", Perpetual)}},
+				}
+			},
+		},
+		{
+			name: "invalid history and window fall back to defaults",
+			file: `{"version":1,"history":{"depth":0,"buffer":-5,"aggregate":-1},"window":{"width":0,"height":10}}`,
+			want: func(c *Config) {},
+		},
+	}
+
+	for _, tt := range tests {
+		path, cleanup := tempConfig(t)
+		writeConfig(t, path, tt.file)
+
+		cfg, err := LoadConfig(path)
+		cleanup()
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		want := DefaultConfig()
+		tt.want(&want)
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("%v: got %+v, want %+v", tt.name, cfg, want)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  string
+	}{
+		{"newer version", `{"version":99}`, "config version 99 is newer than supported version"},
+		{"invalid version", `{"version":"1"}`, "invalid config version"},
+		{"invalid json", `{"version":1`, "invalid config"},
+		{"invalid setting", `{"version":1,"chart":{"interval":"5"}}`, "invalid config"},
+		{"invalid version 1 pair", `{"version":1,"exchanges":{"HitBTC":{"watchlist":["BTCUSD"]}}}`, "unable to migrate config from version 1"},
+	}
+
+	for _, tt := range tests {
+		path, cleanup := tempConfig(t)
+		writeConfig(t, path, tt.file)
+
+		_, err := LoadConfig(path)
+		cleanup()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: got error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	if len(migrations) != ConfigVersion {
+		t.Errorf("got %v migrations, want one for each version below %v", len(migrations), ConfigVersion)
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	path, cleanup := tempConfig(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.Exchange = Bitfinex
+	cfg.Chart.Interval = 60
+	cfg.Theme = LightTheme
+	err := SaveConfig(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("got %+v, want %+v", loaded, cfg)
+	}
+
+	// existing file is replaced without leaving temporary files
+	cfg.Theme = DarkTheme
+	err = SaveConfig(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Theme != DarkTheme {
+		t.Errorf("theme = %v, want %v", loaded.Theme, DarkTheme)
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "config.json" {
+		t.Errorf("got files %v, want config.json only", files)
+	}
+}
+
+func TestSaveConfigFailure(t *testing.T) {
+	path, cleanup := tempConfig(t)
+	defer cleanup()
+	writeConfig(t, path, `{"version":1,"theme":"light"}`)
+
+	// rename fails when a directory is in the way of config file so
+	// previous settings are left as they were
+	dir := filepath.Dir(path)
+	err := SaveConfig(dir, DefaultConfig())
+	if err == nil {
+		t.Fatal("expected error when replacing directory")
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != LightTheme {
+		t.Errorf("theme = %v, want previous %v", cfg.Theme, LightTheme)
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got files %v, want temporary file removed", files)
+	}
+}
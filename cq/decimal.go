@@ -0,0 +1,329 @@
+package cq
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExponent limits exponents accepted by ParseDecimal
+const maxExponent = 1000
+
+// ErrDivByZero is returned when dividing a Decimal by zero
+var ErrDivByZero = errors.New("division by zero")
+
+// Decimal is an exact base 10 number used for prices and sizes
+// Value is coef / 10^scale.  Zero value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+// NewDecimal returns coef / 10^scale
+func NewDecimal(coef int64, scale int) Decimal {
+	d := Decimal{coef: big.NewInt(coef), scale: scale}
+	if scale < 0 {
+		return d.rescale(0)
+	}
+	return d
+}
+
+// ParseDecimal parses decimal strings returned by exchange apis, including
+// exponents such as "1e-8"
+// Decimal places are kept as written so "1.50" has 2 places.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil || e > maxExponent || e < -maxExponent {
+			return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+		}
+		exp, str = e, str[:i]
+	}
+
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg, str = true, str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+
+	whole, frac := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		whole, frac = str[:i], str[i+1:]
+	}
+	digits := whole + frac
+	if len(digits) == 0 || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("invalid decimal: %q", s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	d := Decimal{coef: coef, scale: len(frac) - exp}
+	if d.scale < 0 {
+		return d.rescale(0), nil
+	}
+	return d, nil
+}
+
+// decimalFromFloat returns shortest decimal that parses to f
+// Used for chart axis values that are computed as floats
+func decimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// int returns coefficient with nil treated as zero
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// rescale returns d with scale increased to scale without changing value
+func (d Decimal) rescale(scale int) Decimal {
+	c := new(big.Int).Set(d.int())
+	if scale > d.scale {
+		c.Mul(c, pow10(scale-d.scale))
+	}
+	return Decimal{coef: c, scale: scale}
+}
+
+// align returns coefficients of d and d2 at common scale
+func align(d Decimal, d2 Decimal) (*big.Int, *big.Int, int) {
+	scale := d.scale
+	if d2.scale > scale {
+		scale = d2.scale
+	}
+	return d.rescale(scale).coef, d2.rescale(scale).coef, scale
+}
+
+// Places returns number of decimal places as written
+func (d Decimal) Places() int {
+	return d.scale
+}
+
+// normalize returns d without trailing zero places so equal values have
+// equal strings
+func (d Decimal) normalize() Decimal {
+	c := new(big.Int).Set(d.int())
+	scale := d.scale
+	ten := big.NewInt(10)
+	r := new(big.Int)
+	for scale > 0 && c.Sign() != 0 {
+		q, m := new(big.Int).QuoRem(c, ten, r)
+		if m.Sign() != 0 {
+			break
+		}
+		c, scale = q, scale-1
+	}
+	if c.Sign() == 0 {
+		scale = 0
+	}
+	return Decimal{coef: c, scale: scale}
+}
+
+// Sign returns -1, 0 or 1
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and d2 and returns -1, 0 or 1
+func (d Decimal) Cmp(d2 Decimal) int {
+	a, b, _ := align(d, d2)
+	return a.Cmp(b)
+}
+
+// leadingZeros returns number of zeros between decimal point and first
+// significant digit of d
+// Returns 0 if |d| >= 1.
+func (d Decimal) leadingZeros() int {
+	n := len(new(big.Int).Abs(d.int()).String())
+	if n >= d.scale {
+		return 0
+	}
+	return d.scale - n
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Add returns d + d2 with places of the more precise operand
+func (d Decimal) Add(d2 Decimal) Decimal {
+	a, b, scale := align(d, d2)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - d2 with places of the more precise operand
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	return d.Add(d2.Neg())
+}
+
+// Mul returns d * d2 exactly
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), d2.int()), scale: d.scale + d2.scale}
+}
+
+// Div returns d / d2 rounded half away from zero to places
+func (d Decimal) Div(d2 Decimal, places int) (Decimal, error) {
+	if d2.IsZero() {
+		return Decimal{}, ErrDivByZero
+	}
+	if places < 0 {
+		places = 0
+	}
+	// d / d2 * 10^places as integer division of coefficients
+	num := new(big.Int).Mul(d.int(), pow10(places+d2.scale))
+	den := new(big.Int).Mul(d2.int(), pow10(d.scale))
+	return Decimal{coef: quo(num, den, roundHalf), scale: places}, nil
+}
+
+// Round returns d rounded half away from zero to places
+// Places are padded with zeros if d has fewer places.
+func (d Decimal) Round(places int) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d.rescale(places)
+	}
+	return Decimal{coef: quo(d.int(), pow10(d.scale-places), roundHalf), scale: places}
+}
+
+// RoundToTick returns nearest multiple of tick
+// d is returned unchanged if tick is not positive
+func (d Decimal) RoundToTick(tick Decimal) Decimal {
+	return d.toTick(tick, roundHalf)
+}
+
+// FloorToTick returns largest multiple of tick not above d
+func (d Decimal) FloorToTick(tick Decimal) Decimal {
+	return d.toTick(tick, roundFloor)
+}
+
+// CeilToTick returns smallest multiple of tick not below d
+func (d Decimal) CeilToTick(tick Decimal) Decimal {
+	return d.toTick(tick, roundCeil)
+}
+
+// toTick returns multiple of tick chosen by mode
+func (d Decimal) toTick(tick Decimal, mode roundMode) Decimal {
+	if tick.Sign() <= 0 {
+		return d
+	}
+	a, b, scale := align(d, tick)
+	n := quo(a, b, mode)
+	return Decimal{coef: n.Mul(n, b), scale: scale}
+}
+
+// String returns d in plain notation with its places
+func (d Decimal) String() string {
+	c := d.int()
+	digits := new(big.Int).Abs(c).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		i := len(digits) - d.scale
+		digits = digits[:i] + "." + digits[i:]
+	}
+	if c.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalJSON writes d as a JSON number with its places
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads d from a JSON number or a string holding a number
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	v, err := ParseDecimal(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// StringFixed returns d rounded to places in plain notation
+func (d Decimal) StringFixed(places int) string {
+	return d.Round(places).String()
+}
+
+// Float64 returns nearest float64 to d
+// Used where floats are needed for drawing
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+const (
+	roundHalf roundMode = iota
+	roundFloor
+	roundCeil
+)
+
+// roundMode sets how quo rounds
+type roundMode int
+
+// quo returns num / den rounded by mode
+func quo(num *big.Int, den *big.Int, mode roundMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// sign of exact quotient
+	sign := num.Sign() * den.Sign()
+	switch mode {
+	case roundHalf:
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		if twice.Cmp(new(big.Int).Abs(den)) >= 0 {
+			q.Add(q, big.NewInt(int64(sign)))
+		}
+	case roundFloor:
+		if sign < 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+	case roundCeil:
+		if sign > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
@@ -0,0 +1,320 @@
+package cq
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+// dec parses s and fails test if it is not a decimal
+func dec(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   string
+		places int
+	}{
+		{"1", "1", 0},
+		{"1.50", "1.50", 2},
+		{" 7000.01 ", "7000.01", 2},
+		{"+2.5", "2.5", 1},
+		{"-0.00000001", "-0.00000001", 8},
+		{"0.000000001", "0.000000001", 9},
+		{"1e-8", "0.00000001", 8},
+		{"1.5E-10", "0.00000000015", 11},
+		{"-2.5e3", "-2500", 0},
+		{"12e2", "1200", 0},
+		{".5", "0.5", 1},
+		{"5.", "5", 0},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", 9},
+	}
+	for _, test := range tests {
+		d, err := ParseDecimal(test.s)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", test.s, err)
+			continue
+		}
+		if got := d.String(); got != test.want || d.Places() != test.places {
+			t.Errorf("ParseDecimal(%q) = %v with %v places, want %v with %v", test.s, got, d.Places(), test.want, test.places)
+		}
+	}
+
+	for _, s := range []string{"", "-", ".", "abc", "1.2.3", "1e", "1e1001", "1e-1001", "--1", "1,5", "0x10"} {
+		if d, err := ParseDecimal(s); err == nil {
+			t.Errorf("ParseDecimal(%q) = %v, want error", s, d)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var h struct {
+		Amount Decimal `json:"amount"`
+		Cost   Decimal `json:"cost"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":0.10000000,"cost":"1.5e3"}`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Amount.String() != "0.10000000" || h.Cost.String() != "1500" {
+		t.Errorf("got amount %v, cost %v", h.Amount, h.Cost)
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != `{"amount":0.10000000,"cost":1500}` {
+		t.Errorf("got %v", got)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"abc"}`), &h); err == nil {
+		t.Error("expected error for invalid amount")
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		a, b          string
+		sum, diff, pr string
+	}{
+		{"1", "2", "3", "-1", "2"},
+		{"0.1", "0.2", "0.3", "-0.1", "0.02"},
+		{"1.50", "0.5", "2.00", "1.00", "0.750"},
+		// sub-satoshi values
+		{"0.00000001", "0.000000001", "0.000000011", "0.000000009", "0.00000000000000001"},
+		// negatives
+		{"-7000.01", "7000.01", "0.00", "-14000.02", "-49000140.0001"},
+		{"-0.5", "-0.25", "-0.75", "-0.25", "0.125"},
+		// very large volumes
+		{"987654321098765.4321", "123456789012345.6789", "1111111110111111.1110", "864197532086419.7532", "121932631137021795223746380111.12635269"},
+		{"0", "-3.3", "-3.3", "3.3", "0.0"},
+	}
+	for _, test := range tests {
+		a, b := dec(t, test.a), dec(t, test.b)
+		if got := a.Add(b).String(); got != test.sum {
+			t.Errorf("%v + %v = %v, want %v", test.a, test.b, got, test.sum)
+		}
+		if got := a.Sub(b).String(); got != test.diff {
+			t.Errorf("%v - %v = %v, want %v", test.a, test.b, got, test.diff)
+		}
+		if got := a.Mul(b).String(); got != test.pr {
+			t.Errorf("%v * %v = %v, want %v", test.a, test.b, got, test.pr)
+		}
+	}
+
+	// zero value is 0
+	var zero Decimal
+	if got := zero.Add(dec(t, "1.5")).String(); got != "1.5" {
+		t.Errorf("0 + 1.5 = %v", got)
+	}
+	if !zero.Mul(dec(t, "3")).IsZero() || zero.String() != "0" {
+		t.Errorf("zero value is not 0")
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		places int
+		want   string
+	}{
+		{"1", "3", 8, "0.33333333"},
+		{"2", "3", 8, "0.66666667"},
+		{"-2", "3", 8, "-0.66666667"},
+		{"1", "-3", 2, "-0.33"},
+		{"10", "4", 0, "3"},
+		{"-10", "4", 0, "-3"},
+		{"5", "2", 0, "3"},
+		{"-5", "2", 0, "-3"},
+		{"0.00000001", "3", 10, "0.0000000033"},
+		{"7000.01", "0.00000001", 2, "700001000000.00"},
+		{"1", "8", -1, "0"},
+		{"123456789012345678901234567890", "0.1", 1, "1234567890123456789012345678900.0"},
+	}
+	for _, test := range tests {
+		got, err := dec(t, test.a).Div(dec(t, test.b), test.places)
+		if err != nil {
+			t.Errorf("%v / %v: %v", test.a, test.b, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("%v / %v to %v places = %v, want %v", test.a, test.b, test.places, got, test.want)
+		}
+	}
+
+	if _, err := dec(t, "1").Div(Decimal{}, 2); err != ErrDivByZero {
+		t.Errorf("got error %v dividing by zero value, want ErrDivByZero", err)
+	}
+	if _, err := dec(t, "1").Div(dec(t, "0.000"), 2); err != ErrDivByZero {
+		t.Errorf("got error %v dividing by 0.000, want ErrDivByZero", err)
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1.000", 0},
+		{"0.00000001", "0.000000009", 1},
+		{"-0.00000001", "0.000000009", -1},
+		{"-2", "-10", 1},
+		{"0", "-0.00", 0},
+		{"1000000000000000000000", "999999999999999999999.99999999", 1},
+	}
+	for _, test := range tests {
+		a, b := dec(t, test.a), dec(t, test.b)
+		if got := a.Cmp(b); got != test.want {
+			t.Errorf("Cmp(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := b.Cmp(a); got != -test.want {
+			t.Errorf("Cmp(%v, %v) = %v, want %v", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		d      string
+		places int
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"-1.004", 2, "-1.00"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.000000005", 8, "0.00000001"},
+		{"0.000000004999", 8, "0.00000000"},
+		{"1.5", 4, "1.5000"},
+		{"1.5", -2, "2"},
+		{"99999999999999999.999", 2, "100000000000000000.00"},
+	}
+	for _, test := range tests {
+		if got := dec(t, test.d).Round(test.places).String(); got != test.want {
+			t.Errorf("Round(%v, %v) = %v, want %v", test.d, test.places, got, test.want)
+		}
+	}
+}
+
+func TestDecimalTicks(t *testing.T) {
+	tests := []struct {
+		d, tick            string
+		round, floor, ceil string
+	}{
+		{"7000.014", "0.01", "7000.01", "7000.01", "7000.02"},
+		{"7000.015", "0.01", "7000.02", "7000.01", "7000.02"},
+		{"7000.01", "0.01", "7000.01", "7000.01", "7000.01"},
+		{"-7000.015", "0.01", "-7000.02", "-7000.02", "-7000.01"},
+		// ticks that are not powers of ten
+		{"7000.12", "0.25", "7000.00", "7000.00", "7000.25"},
+		{"7000.13", "0.25", "7000.25", "7000.00", "7000.25"},
+		{"-7000.13", "0.25", "-7000.25", "-7000.25", "-7000.00"},
+		{"101", "5", "100", "100", "105"},
+		{"102.5", "5", "105.0", "100.0", "105.0"},
+		{"0.000000013", "0.000000005", "0.000000015", "0.000000010", "0.000000015"},
+		{"12", "2.5", "12.5", "10.0", "12.5"},
+		{"1234567890123.456", "0.3", "1234567890123.6", "1234567890123.3", "1234567890123.6"},
+	}
+	for _, test := range tests {
+		d, tick := dec(t, test.d), dec(t, test.tick)
+		if got := d.RoundToTick(tick); got.Cmp(dec(t, test.round)) != 0 {
+			t.Errorf("RoundToTick(%v, %v) = %v, want %v", test.d, test.tick, got, test.round)
+		}
+		if got := d.FloorToTick(tick); got.Cmp(dec(t, test.floor)) != 0 {
+			t.Errorf("FloorToTick(%v, %v) = %v, want %v", test.d, test.tick, got, test.floor)
+		}
+		if got := d.CeilToTick(tick); got.Cmp(dec(t, test.ceil)) != 0 {
+			t.Errorf("CeilToTick(%v, %v) = %v, want %v", test.d, test.tick, got, test.ceil)
+		}
+	}
+
+	// results keep places of the more precise operand
+	if got := dec(t, "7000.014").FloorToTick(dec(t, "0.01")).String(); got != "7000.010" {
+		t.Errorf("FloorToTick(7000.014, 0.01) = %v, want places of 7000.014", got)
+	}
+
+	// non-positive ticks leave value unchanged
+	d := dec(t, "1.234")
+	for _, tick := range []Decimal{{}, dec(t, "0"), dec(t, "-0.1")} {
+		if got := d.RoundToTick(tick); got.Cmp(d) != 0 {
+			t.Errorf("RoundToTick(1.234, %v) = %v", tick, got)
+		}
+	}
+}
+
+// randomTick returns positive tick of 1 to 99 units at 0 to 10 places
+func randomTick(r *rand.Rand) Decimal {
+	return NewDecimal(int64(1+r.Intn(99)), r.Intn(11))
+}
+
+func TestDecimalProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		as, bs := randomDecimal(r), randomDecimal(r)
+		a, b := dec(t, as), dec(t, bs)
+
+		if got := dec(t, a.String()); got.Cmp(a) != 0 || got.Places() != a.Places() {
+			t.Fatalf("%v does not round trip through String", as)
+		}
+		if got := a.Add(b).Sub(b); got.Cmp(a) != 0 {
+			t.Fatalf("%v + %v - %v = %v", as, bs, bs, got)
+		}
+		if a.Add(b).Cmp(b.Add(a)) != 0 || a.Mul(b).Cmp(b.Mul(a)) != 0 {
+			t.Fatalf("%v and %v do not commute", as, bs)
+		}
+		if a.Cmp(b) != -b.Cmp(a) {
+			t.Fatalf("Cmp(%v, %v) is not antisymmetric", as, bs)
+		}
+		if a.Sub(b).Sign() != a.Cmp(b) {
+			t.Fatalf("sign of %v - %v disagrees with Cmp", as, bs)
+		}
+
+		// product divided back is exact at places of dividend
+		if !b.IsZero() {
+			q, err := a.Mul(b).Div(b, a.Places())
+			if err != nil || q.Cmp(a) != 0 {
+				t.Fatalf("%v * %v / %v = %v, %v", as, bs, bs, q, err)
+			}
+		}
+		// rounded quotient is within half a unit of last place
+		if !b.IsZero() {
+			places := r.Intn(12)
+			q, _ := a.Div(b, places)
+			diff := q.Mul(b).Sub(a).Abs()
+			half := NewDecimal(5, places+1).Mul(b.Abs())
+			if diff.Cmp(half) > 0 {
+				t.Fatalf("%v / %v to %v places = %v is off by more than half a unit", as, bs, places, q)
+			}
+		}
+
+		tick := randomTick(r)
+		floor, ceil, round := a.FloorToTick(tick), a.CeilToTick(tick), a.RoundToTick(tick)
+		if floor.Cmp(a) > 0 || ceil.Cmp(a) < 0 || ceil.Sub(floor).Cmp(tick) > 0 {
+			t.Fatalf("ticks %v of %v: floor %v, ceil %v", tick, as, floor, ceil)
+		}
+		for _, v := range []Decimal{floor, ceil, round} {
+			if v.FloorToTick(tick).Cmp(v) != 0 {
+				t.Fatalf("%v is not a multiple of tick %v", v, tick)
+			}
+		}
+		if round.Cmp(floor) != 0 && round.Cmp(ceil) != 0 {
+			t.Fatalf("RoundToTick(%v, %v) = %v, want %v or %v", as, tick, round, floor, ceil)
+		}
+		if round.Sub(a).Abs().Cmp(a.Sub(floor).Abs()) > 0 || round.Sub(a).Abs().Cmp(ceil.Sub(a).Abs()) > 0 {
+			t.Fatalf("RoundToTick(%v, %v) = %v is not nearest", as, tick, round)
+		}
+
+		places := r.Intn(12)
+		rounded := a.Round(places)
+		if rounded.Places() < places || rounded.Sub(a).Abs().Cmp(NewDecimal(5, places+1)) > 0 {
+			t.Fatalf("Round(%v, %v) = %v", as, places, rounded)
+		}
+	}
+}
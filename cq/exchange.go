@@ -1,6 +1,7 @@
 package cq
 
 import (
+	"strings"
 	"sync"
 )
 
@@ -16,9 +17,31 @@ const (
 // ExchangeID identifies which exchange a price quote comes from
 type ExchangeID int
 
+// String returns name of exchange
+func (id ExchangeID) String() string {
+	switch id {
+	case Coinbase:
+		return "Coinbase"
+	case Bitfinex:
+		return "Bitfinex"
+	case HitBTC:
+		return "HitBTC"
+	}
+	return "Unknown"
+}
+
+// parseExchangeID returns ID of exchange with name ignoring case
+func parseExchangeID(s string) (ExchangeID, bool) {
+	for _, id := range []ExchangeID{Coinbase, Bitfinex, HitBTC} {
+		if strings.EqualFold(s, id.String()) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
 // Exchange defines necessary methods for exchange to be used by main package
 type Exchange interface {
-	Init()
 	SetID(ExchangeID)
 	GetID() ExchangeID
 	GetDefaultPairs() []Pair
@@ -26,7 +49,10 @@ type Exchange interface {
 	GetWatchlist() *Watchlist
 	AddAvailablePair(...Pair)
 	GetAvailablePairs() []Pair
+	AddInstrument(...Instrument)
+	GetInstrument(Pair) Instrument
 	AddWatchedPair(...Pair)
+	RemoveWatchedPair(...Pair)
 	GetWatchedPairs() []Pair
 	UpdateQuote(UpdateMsg)
 }
@@ -39,6 +65,8 @@ type BaseExchange struct {
 
 	// all pairs available through exchange api
 	availablePairs []Pair
+	// instruments holds trading rules of available pairs
+	instruments map[Pair]Instrument
 
 	watchlist *Watchlist
 }
@@ -61,12 +89,13 @@ func (e *BaseExchange) GetID() ExchangeID {
 
 // GetDefaultPairs returns a slice of Pair(s) for any exchange
 func (e *BaseExchange) GetDefaultPairs() []Pair {
+	id := e.GetID()
 	return []Pair{
-		NewPair("BTC", "USD"),
-		NewPair("BCH", "USD"),
-		NewPair("ETH", "USD"),
-		NewPair("LTC", "USD"),
-		NewPair("ZRX", "USD"),
+		NewPair("BTC", "USD").WithExchange(id),
+		NewPair("BCH", "USD").WithExchange(id),
+		NewPair("ETH", "USD").WithExchange(id),
+		NewPair("LTC", "USD").WithExchange(id),
+		NewPair("ZRX", "USD").WithExchange(id),
 	}
 }
 
@@ -78,6 +107,9 @@ func (e *BaseExchange) SetWatchlist(pairs ...Pair) *Watchlist {
 	}
 
 	w := NewWatchlist(pairs...)
+	e.RLock()
+	w.SetInstruments(e.instruments)
+	e.RUnlock()
 
 	e.watchlist = w
 
@@ -110,6 +142,32 @@ func (e *BaseExchange) GetAvailablePairs() []Pair {
 	return e.availablePairs
 }
 
+// AddInstrument adds instruments and their pairs to available pairs
+func (e *BaseExchange) AddInstrument(instruments ...Instrument) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.instruments == nil {
+		e.instruments = map[Pair]Instrument{}
+	}
+	for _, i := range instruments {
+		e.instruments[i.Pair] = i
+		e.availablePairs = append(e.availablePairs, i.Pair)
+	}
+}
+
+// GetInstrument returns trading rules of pair
+// Pairs without instrument return one with unknown rules
+func (e *BaseExchange) GetInstrument(p Pair) Instrument {
+	e.RLock()
+	defer e.RUnlock()
+
+	if i, ok := e.instruments[p]; ok {
+		return i
+	}
+	return NewInstrument(p)
+}
+
 // AddWatchedPair adds crypto pair/s to the watchlist
 func (e *BaseExchange) AddWatchedPair(pairs ...Pair) {
 	e.Lock()
@@ -122,6 +180,22 @@ func (e *BaseExchange) AddWatchedPair(pairs ...Pair) {
 	}
 }
 
+// RemoveWatchedPair removes crypto pair/s from the watchlist
+// Pairs that are not watched are ignored
+func (e *BaseExchange) RemoveWatchedPair(pairs ...Pair) {
+	e.Lock()
+	defer e.Unlock()
+
+	for _, pair := range pairs {
+		if _, ok := e.watchlist.Index[pair]; !ok {
+			continue
+		}
+		e.watchlist.RemoveQuote(Quote{
+			ID: pair,
+		})
+	}
+}
+
 // GetWatchedPairs returns slice with all pairs in current watchlist
 func (e *BaseExchange) GetWatchedPairs() []Pair {
 	e.RLock()
@@ -158,31 +232,39 @@ func (e *BaseExchange) GetQuote(p Pair) Quote {
 }
 
 // UpdateQuote uses data from UpdateMsg to change quotes of watched pairs
+// Updates for pairs that are no longer watched are dropped
 func (e *BaseExchange) UpdateQuote(upd UpdateMsg) {
 	e.Lock()
 	defer e.Unlock()
 
-	i := e.watchlist.Index[upd.Quote.ID]
+	i, ok := e.watchlist.Index[upd.Quote.ID]
+	if !ok {
+		return
+	}
+
+	switch upd.Type {
+	case InitUpd, TradeUpd, TickerUpd, FlashUpd:
+		e.watchlist.UpdateQuote(mergeQuote(e.watchlist.Quotes[i], upd), upd.Type)
+	}
+}
 
+// mergeQuote returns quote q with fields carried by update message
+// InitUpd replaces all fields, TradeUpd sets price and size and TickerUpd
+// sets all other market data
+func mergeQuote(q Quote, upd UpdateMsg) Quote {
 	switch upd.Type {
 	case InitUpd:
-		e.watchlist.UpdateQuote(upd.Quote, upd.Type)
+		return upd.Quote
 	case TradeUpd:
-		q := e.watchlist.Quotes[i]
 		q.Price = upd.Quote.Price
 		q.Size = upd.Quote.Size
-		e.watchlist.UpdateQuote(q, upd.Type)
 	case TickerUpd:
-		q := e.watchlist.Quotes[i]
 		q.Ask = upd.Quote.Ask
 		q.Bid = upd.Quote.Bid
 		q.Low = upd.Quote.Low
 		q.High = upd.Quote.High
 		q.Open = upd.Quote.Open
 		q.Volume = upd.Quote.Volume
-		e.watchlist.UpdateQuote(q, upd.Type)
-	case FlashUpd:
-		q := e.watchlist.Quotes[i]
-		e.watchlist.UpdateQuote(q, upd.Type)
 	}
+	return q
 }
@@ -1,86 +1,193 @@
 package cq
 
-import (
-	"fmt"
-	"strconv"
-)
-
 // FmtQuote will format all the data fields of an instance of cq.Quote
+// Uses default precision.  Instrument.FmtQuote uses pair's tick size.
 func FmtQuote(q Quote) Quote {
-	q.Change, q.ChangePerc, q.PriceChange = FmtDelta(q.Price, q.Open)
-	q.Price = FmtPrice(q.Price)
-	q.Bid = FmtPrice(q.Bid)
-	q.Ask = FmtPrice(q.Ask)
-	q.Low = FmtPrice(q.Low)
-	q.High = FmtPrice(q.High)
-	q.Open = FmtPrice(q.Open)
-	q.Volume = FmtVolume(q.Volume)
+	return Instrument{}.FmtQuote(q)
+}
+
+// FmtQuote will format all the data fields of an instance of cq.Quote with
+// instrument's precision
+func (i Instrument) FmtQuote(q Quote) Quote {
+	q.Change, q.ChangePerc, q.PriceChange = i.FmtDelta(q.Price, q.Open)
+	q.Price = i.FmtPrice(q.Price)
+	q.Bid = i.FmtPrice(q.Bid)
+	q.Ask = i.FmtPrice(q.Ask)
+	q.Low = i.FmtPrice(q.Low)
+	q.High = i.FmtPrice(q.High)
+	q.Open = i.FmtPrice(q.Open)
+	q.Volume = i.FmtVolume(q.Volume)
 
 	return q
 }
 
+// FmtPrice formats price with as many decimal places as tick size
+// Falls back to FmtPrice if tick size is unknown
+func (i Instrument) FmtPrice(price string) string {
+	places, ok := i.PricePlaces()
+	if !ok {
+		return FmtPrice(price)
+	}
+	d, err := ParseDecimal(price)
+	if err != nil {
+		return "-"
+	}
+	return d.StringFixed(places)
+}
+
+// FmtSize formats size with as many decimal places as lot size
+// Falls back to FmtSize if lot size is unknown
+func (i Instrument) FmtSize(size string) string {
+	places, ok := i.SizePlaces()
+	if !ok {
+		return FmtSize(size)
+	}
+	d, err := ParseDecimal(size)
+	if err != nil {
+		return "-"
+	}
+	return d.StringFixed(places)
+}
+
+// fmtValue formats a calculated price, such as a chart level, with as many
+// decimal places as tick size
+// Falls back to fmtValue if tick size is unknown
+func (i Instrument) fmtValue(d Decimal) string {
+	places, ok := i.PricePlaces()
+	if !ok {
+		return fmtValue(d)
+	}
+	return d.StringFixed(places)
+}
+
+// FmtDelta calculates change in price and price delta as percentage
+// Change is formatted with instrument's precision
+func (i Instrument) FmtDelta(price string, open string) (string, string, PriceChange) {
+	change, perc, dchange := FmtDelta(price, open)
+	if _, ok := i.PricePlaces(); !ok || change == "" || change == "-" {
+		return change, perc, dchange
+	}
+	p, _ := ParseDecimal(price)
+	o, _ := ParseDecimal(open)
+	return i.FmtPrice(p.Sub(o).String()), perc, dchange
+}
+
 // FmtPrice formats price data for display
-// If price is >= 10 it uses 2 decimal places
-// If price is below 10 it uses 5 decimal places
+// If |price| is >= 10 it uses at least 2 decimal places
+// If |price| is below 10 it uses at least 5 decimal places or more so
+// sub-satoshi prices keep 3 significant digits
+// Places of price are never rounded away.
 func FmtPrice(price string) string {
-	num, err := strconv.ParseFloat(price, 64)
+	d, err := ParseDecimal(price)
 	if err != nil {
 		return "-"
 	}
-	if num >= 10 {
-		num = float64(int64(num*100+0.5)) / 100
-		return fmt.Sprintf("%.2f", num)
+	return d.StringFixed(maxInt(pricePlaces(d), d.Places()))
+}
+
+// fmtValue formats a calculated price, such as a converted price, with
+// FmtPrice's places
+// Unlike FmtPrice extra places are rounded.
+func fmtValue(d Decimal) string {
+	return d.StringFixed(pricePlaces(d))
+}
+
+// pricePlaces returns minimum number of decimal places FmtPrice shows for d
+func pricePlaces(d Decimal) int {
+	if d.Abs().Cmp(NewDecimal(10, 0)) >= 0 {
+		return 2
+	}
+	places := 5
+	if p := d.leadingZeros() + 3; !d.IsZero() && p > places {
+		places = p
 	}
+	return places
+}
 
-	num = float64(int64(num*100000+0.5)) / 100000
-	return fmt.Sprintf("%.5f", num)
+// maxInt returns larger of a and b
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // FmtDelta calculates change in price and price delta as percentage
+// Percentage is "-" if open is zero
 func FmtDelta(price string, open string) (string, string, PriceChange) {
 	if len(price) > 0 && len(open) > 0 {
-		p, err := strconv.ParseFloat(price, 64)
+		p, err := ParseDecimal(price)
 		if err != nil {
 			return "", "-", Even
 		}
-		o, err := strconv.ParseFloat(open, 64)
+		o, err := ParseDecimal(open)
 		if err != nil {
 			return "", "-", Even
 		}
 
 		// set price change
 		var dchange PriceChange
-		switch true {
-		case p > o:
+		switch p.Cmp(o) {
+		case 1:
 			dchange = Up
-		case p < o:
+		case -1:
 			dchange = Down
 		default:
 			dchange = Even
 		}
 
-		c := (p - o)
-		d := c / o * 100
-		return FmtPrice(strconv.FormatFloat(c, 'f', -1, 64)), strconv.FormatFloat(d, 'f', 2, 64), dchange
+		c := p.Sub(o)
+		perc := "-"
+		if d, err := c.Mul(NewDecimal(100, 0)).Div(o, 2); err == nil {
+			perc = d.String()
+		}
+		return FmtPrice(c.String()), perc, dchange
 	}
 	return "-", "-", Even
 }
 
-// FmtSize formats trade size data with 8 decimal places
+// FmtSize formats trade size data with at least 8 decimal places
+// Places of size are never rounded away.
 func FmtSize(size string) string {
-	num, err := strconv.ParseFloat(size, 64)
+	d, err := ParseDecimal(size)
 	if err != nil {
 		return "-"
 	}
-	num = float64(int64(num*100000000+0.5)) / 100000000
-	return fmt.Sprintf("%.8f", num)
+	return d.StringFixed(maxInt(8, d.Places()))
+}
+
+// addSizes returns exact sum of decimal sizes a and b with as many decimal
+// places as the more precise of the two
+// Invalid sizes are treated as zero
+func addSizes(a, b string) string {
+	x, _ := ParseDecimal(a)
+	y, _ := ParseDecimal(b)
+	return x.Add(y).String()
+}
+
+// FmtVolume formats volume with as many decimal places as lot size, up to
+// 2 places
+// Falls back to FmtVolume if lot size is unknown
+func (i Instrument) FmtVolume(vol string) string {
+	places, ok := i.SizePlaces()
+	if !ok {
+		return FmtVolume(vol)
+	}
+	d, err := ParseDecimal(vol)
+	if err != nil {
+		return "-"
+	}
+	if places > 2 {
+		places = 2
+	}
+	return d.StringFixed(places)
 }
 
 // FmtVolume formats volume data by rounding to nearest whole number
 func FmtVolume(vol string) string {
-	num, err := strconv.ParseFloat(vol, 64)
+	d, err := ParseDecimal(vol)
 	if err != nil {
 		return "-"
 	}
-	return fmt.Sprint(int64(num + 0.5))
+	return d.StringFixed(0)
 }
@@ -0,0 +1,130 @@
+package cq
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFmtSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want string
+	}{
+		{"1", "1.00000000"},
+		{"0.5", "0.50000000"},
+		{"0.000000001", "0.000000001"},
+		{"1e-10", "0.0000000001"},
+		{"-2.123456789", "-2.123456789"},
+		{"abc", "-"},
+	}
+	for _, test := range tests {
+		if got := FmtSize(test.size); got != test.want {
+			t.Errorf("FmtSize(%q) = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
+
+func TestFmtPrice(t *testing.T) {
+	tests := []struct {
+		price string
+		want  string
+	}{
+		{"9123.4", "9123.40"},
+		{"9123.456", "9123.456"},
+		{"1.5", "1.50000"},
+		{"0.00000123", "0.00000123"},
+		{"0.0000000123", "0.0000000123"},
+		{"0.000000012", "0.0000000120"},
+		{"0", "0.00000"},
+		{"", "-"},
+	}
+	for _, test := range tests {
+		if got := FmtPrice(test.price); got != test.want {
+			t.Errorf("FmtPrice(%q) = %q, want %q", test.price, got, test.want)
+		}
+	}
+}
+
+// randomDecimal returns decimal string with up to 12 integer digits and
+// up to 16 places
+func randomDecimal(r *rand.Rand) string {
+	digits := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte('0' + r.Intn(10))
+		}
+		return string(b)
+	}
+	s := digits(1 + r.Intn(12))
+	if places := r.Intn(17); places > 0 {
+		s += "." + digits(places)
+	}
+	if r.Intn(4) == 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// fmtPlaces returns number of decimal places in formatted string
+func fmtPlaces(s string) int {
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return 0
+	}
+	return len(s) - i - 1
+}
+
+func TestFmtKeepsValue(t *testing.T) {
+	formats := []struct {
+		name string
+		fmt  func(string) string
+		min  func(Decimal) int
+	}{
+		{"FmtSize", FmtSize, func(Decimal) int { return 8 }},
+		{"FmtPrice", FmtPrice, pricePlaces},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		s := randomDecimal(r)
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range formats {
+			got := f.fmt(s)
+			v, err := ParseDecimal(got)
+			if err != nil {
+				t.Fatalf("%v(%q) = %q: %v", f.name, s, got, err)
+			}
+			if v.Cmp(d) != 0 {
+				t.Fatalf("%v(%q) = %q changes value", f.name, s, got)
+			}
+			if places := fmtPlaces(got); places < f.min(d) || places < d.Places() {
+				t.Fatalf("%v(%q) = %q has %v places", f.name, s, got, places)
+			}
+		}
+	}
+}
+
+func TestInstrumentFmtVolume(t *testing.T) {
+	tests := []struct {
+		name string
+		lot  Decimal
+		vol  string
+		want string
+	}{
+		{"unknown lot", Decimal{}, "1234.567", "1235"},
+		{"whole lot", NewDecimal(1, 0), "1234.567", "1235"},
+		{"one place lot", NewDecimal(1, 1), "1234.567", "1234.6"},
+		{"fine lot", NewDecimal(1, 8), "0.004567", "0.00"},
+		{"invalid", NewDecimal(1, 8), "x", "-"},
+	}
+	for _, test := range tests {
+		i := Instrument{LotSize: test.lot}
+		if got := i.FmtVolume(test.vol); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
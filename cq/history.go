@@ -3,92 +3,272 @@ package cq
 import (
 	"fmt"
 	"image/color"
+	"time"
 
 	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
 
 	fl "github.com/3cb/fyne-list"
 )
 
+const (
+	// ColorBySide colors trades green for buys and red for sells
+	ColorBySide ColorMode = iota
+	// ColorByTick colors trades by price change from previous trade
+	ColorByTick
+)
+
+// ColorMode sets how History colors trades
+type ColorMode int
+
+// String returns name of color mode
+func (m ColorMode) String() string {
+	if m == ColorByTick {
+		return "tick"
+	}
+	return "side"
+}
+
+// MarshalText encodes color mode by name
+func (m ColorMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText decodes color mode from name
+func (m *ColorMode) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "side":
+		*m = ColorBySide
+	case "tick":
+		*m = ColorByTick
+	default:
+		return fmt.Errorf("invalid color mode: %v", string(b))
+	}
+	return nil
+}
+
+const (
+	// DefaultHistoryDepth is number of trades shown when none is configured
+	DefaultHistoryDepth = 50
+	// DefaultHistoryBuffer is number of trades kept after they scroll out of
+	// History
+	DefaultHistoryBuffer = 500
+)
+
+// HistoryCfg holds settings for History widget
+type HistoryCfg struct {
+	ColorMode ColorMode `json:"colorMode"`
+	// Depth is number of trades shown
+	Depth int `json:"depth"`
+	// Buffer is number of trades kept in memory after they are dropped from
+	// view so they can be shown again without a request
+	Buffer int `json:"buffer"`
+	// Aggregate is window in milliseconds in which consecutive trades on
+	// the same side at the same price are merged into one row
+	// Streaming trades are delayed by up to the full window.  Zero disables
+	// aggregation.
+	Aggregate int `json:"aggregate"`
+}
+
+// AggregateWindow returns aggregation window as a duration
+func (c HistoryCfg) AggregateWindow() time.Duration {
+	return time.Duration(c.Aggregate) * time.Millisecond
+}
+
 // History describes a widget that displays a list of trades in a scrolling
-// container with a header.  Max number of trades is 50.
+// container with a header.  Number of trades shown is set by HistoryCfg.Depth
+// and grows as older trades are loaded.
 type History struct {
-	*fl.List
+	widget.ScrollContainer
+
+	// OnScrolledToBottom is called when History is scrolled to its bottom
+	// row
+	OnScrolledToBottom func()
 
 	Pair Pair
+	list *fl.List
+	// atBottom reports whether bottom row was in view after last scroll
+	atBottom bool
 	// key values are Trade.ID
 	Index     map[float64]int
-	lastPrice float64
+	mode      ColorMode
+	rows      int
+	depth     int
+	lastPrice Decimal
+	// lastColor is the tick direction color of the most recent trade
 	lastColor color.Color
+	// trades holds trades from newest down to bottom of list ordered newest
+	// first including trades hidden by filter so they can be shown again
+	// when filter changes
+	trades []Trade
+	// older holds trades dropped from bottom of list
+	older  *tradeRing
+	filter TradeFilter
+	// window is aggregation window applied to trades from rest api
+	window time.Duration
+	// instrument sets precision of prices and sizes
+	instrument Instrument
 }
 
-// NewHistory returns a new instance of the History widget with 50 trades
-func NewHistory(pair Pair, trades []Trade) *History {
-	index := map[float64]int{}
-	for i, trade := range trades {
-		index[trade.ID] = i
-	}
-	temp := []fyne.CanvasObject{}
-
-	// set colors
-	lastColor := setColor(Even)
-	last := trades[len(trades)-1].PriceFloat()
-	// skip earliest trade
-	for i := len(trades) - 2; i >= 0; i-- {
-		t := trades[i]
-		switch true {
-		case t.PriceFloat() > last:
-			o := newHistoryRow(t, setColor(Up), false)
-			lastColor = setColor(Up)
-			temp = append(temp, o)
-		case t.PriceFloat() < last:
-			o := newHistoryRow(t, setColor(Down), false)
-			lastColor = setColor(Down)
-			temp = append(temp, o)
-		case t.PriceFloat() == last:
-			o := newHistoryRow(t, lastColor, false)
-			temp = append(temp, o)
-		}
-		last = t.PriceFloat()
+// NewHistory returns a new instance of the History widget
+// Trades must be ordered newest first.  Trades are merged by aggregation
+// window and trades beyond depth are buffered.  Trades hidden by filter are
+// kept without a row.
+func NewHistory(pair Pair, trades []Trade, cfg HistoryCfg, filter TradeFilter) *History {
+	depth := cfg.Depth
+	if depth < 1 {
+		depth = DefaultHistoryDepth
 	}
-
-	objects := []fyne.CanvasObject{}
-	for _, o := range temp {
-		objects = append([]fyne.CanvasObject{o}, objects...)
+	h := &History{
+		Pair:       pair,
+		Index:      map[float64]int{},
+		mode:       cfg.ColorMode,
+		depth:      depth,
+		lastColor:  setColor(Even),
+		older:      newTradeRing(cfg.Buffer),
+		filter:     filter,
+		window:     cfg.AggregateWindow(),
+		instrument: NewInstrument(pair),
 	}
 
 	headers := []string{
+		"Side",
 		"Size",
 		fmt.Sprintf("Price(%v)", pair.QuoteCurrency()),
 		"Time",
 	}
 	header := fl.NewHeader(white, headers...)
 
-	list := fl.NewListWithScroller(header, objects[:50]...)
+	h.list = fl.NewList(header)
+	h.Content = h.list
+	h.ExtendBaseWidget(h)
+	h.fill(mergeTrades(trades, h.window))
 
-	return &History{
-		List:      list,
-		Pair:      pair,
-		Index:     index,
-		lastPrice: trades[0].PriceFloat(),
-		lastColor: lastColor,
+	return h
+}
+
+// fill adds trades ordered newest first to bottom of list until depth rows
+// are shown and buffers the rest
+func (h *History) fill(trades []Trade) {
+	n := len(trades)
+	for i, t := range trades {
+		if h.rows == h.depth {
+			n = i
+			break
+		}
+		h.append(t)
+	}
+	for i := len(trades) - 1; i >= n; i-- {
+		h.older.push(trades[i])
+	}
+	h.trim()
+	h.recolor()
+}
+
+// color returns color of trade for History's color mode
+// Trades without side use tick direction
+func (h *History) color(t Trade, prev Decimal) color.Color {
+	switch t.PriceDecimal().Cmp(prev) {
+	case 1:
+		h.lastColor = setColor(Up)
+	case -1:
+		h.lastColor = setColor(Down)
+	}
+
+	if h.mode == ColorBySide {
+		switch t.Side {
+		case Buy:
+			return setColor(Up)
+		case Sell:
+			return setColor(Down)
+		}
+	}
+	return h.lastColor
+}
+
+// recolor sets color of all rows starting from oldest
+// Colors depend on previous trade, including trades hidden by filter, so
+// the newest buffered trade is used as reference for the oldest trade.
+// Without one the oldest trade keeps tick color.
+func (h *History) recolor() {
+	h.lastColor = setColor(Even)
+	if len(h.trades) == 0 {
+		return
+	}
+
+	last := h.trades[len(h.trades)-1].PriceDecimal()
+	if t, ok := h.older.peek(); ok {
+		last = t.PriceDecimal()
+	}
+	i := h.rows - 1
+	for j := len(h.trades) - 1; j >= 0; j-- {
+		t := h.trades[j]
+		c := h.color(t, last)
+		last = t.PriceDecimal()
+		if h.filter.Hidden(t) {
+			continue
+		}
+		h.row(i).setColor(c)
+		i--
+	}
+	h.lastPrice = last
+}
+
+// newRow returns row for trade shown in bold if trade is a whale
+func (h *History) newRow(t Trade, c color.Color, isHighlighted bool) *historyRow {
+	return newHistoryRow(t, h.instrument, c, isHighlighted, h.filter.IsWhale(t))
+}
+
+// row returns row at index i
+func (h *History) row(i int) *historyRow {
+	return h.list.GetRow(i).(*historyRow)
+}
+
+// SetColorMode changes color mode and recolors all rows
+func (h *History) SetColorMode(mode ColorMode) {
+	h.mode = mode
+	h.recolor()
+}
+
+// SetInstrument sets trading rules used to format prices and sizes of
+// all rows
+func (h *History) SetInstrument(i Instrument) {
+	h.instrument = i
+	for j := 0; j < h.rows; j++ {
+		h.row(j).setInstrument(i)
 	}
 }
 
 // MinSize returns the size that this widget should not shrink below
 func (h *History) MinSize() fyne.Size {
-	return fyne.NewSize(340, 100)
+	return fyne.NewSize(420, 100)
+}
+
+// Scrolled scrolls History and calls OnScrolledToBottom once bottom row
+// comes into view
+// Further scrolling at bottom is ignored until rows are added below or
+// History is scrolled up.
+func (h *History) Scrolled(ev *fyne.ScrollEvent) {
+	h.ScrollContainer.Scrolled(ev)
+
+	atBottom := h.Offset.Y+h.Size().Height >= h.Content.Size().Height
+	if atBottom && !h.atBottom && h.OnScrolledToBottom != nil {
+		h.OnScrolledToBottom()
+	}
+	h.atBottom = atBottom
 }
 
 // Add prepends new trade to History widget with text color
 // and row highlight set
+// Trades hidden by filter are kept without a row.  Oldest row is moved to
+// buffer once depth is reached.
 func (h *History) Add(t Trade) {
-	switch true {
-	case t.PriceFloat() > h.lastPrice:
-		h.lastColor = setColor(Up)
-	case t.PriceFloat() < h.lastPrice:
-		h.lastColor = setColor(Down)
-	case t.PriceFloat() == h.lastPrice:
-
+	c := h.color(t, h.lastPrice)
+	h.lastPrice = t.PriceDecimal()
+	h.trades = append([]Trade{t}, h.trades...)
+	if h.filter.Hidden(t) {
+		h.trim()
+		return
 	}
 
 	// shift index
@@ -96,13 +276,138 @@ func (h *History) Add(t Trade) {
 		h.Index[k]++
 	}
 	h.Index[t.ID] = 0
-	h.lastPrice = t.PriceFloat()
-	h.List.Pop()
-	h.List.Prepend(newHistoryRow(t, h.lastColor, true))
+	h.list.Prepend(h.newRow(t, c, true))
+	h.rows++
+	h.trim()
+}
+
+// trim moves trades at bottom of list to buffer until no more than depth
+// rows are shown and drops oldest hidden trades until no more of them are
+// kept than the buffer holds
+func (h *History) trim() {
+	for h.rows > h.depth {
+		last := len(h.trades) - 1
+		t := h.trades[last]
+		h.trades = h.trades[:last]
+		h.older.push(t)
+		if h.filter.Hidden(t) {
+			continue
+		}
+		delete(h.Index, t.ID)
+		h.list.Pop()
+		h.rows--
+	}
+
+	hidden := len(h.trades) - h.rows
+	for i := len(h.trades) - 1; i >= 0 && hidden > h.older.capacity(); i-- {
+		if h.filter.Hidden(h.trades[i]) {
+			h.trades = append(h.trades[:i], h.trades[i+1:]...)
+			hidden--
+		}
+	}
+}
+
+// Oldest returns oldest trade kept below bottom row, which may be hidden
+// by filter, or trade in bottom row
+func (h *History) Oldest() (Trade, bool) {
+	if len(h.trades) == 0 {
+		return Trade{}, false
+	}
+	return h.trades[len(h.trades)-1], true
+}
+
+// ShowOlder moves buffered trades to bottom of list until n rows are added
+// and returns number of rows added
+func (h *History) ShowOlder(n int) int {
+	shown := 0
+	for shown < n {
+		t, ok := h.older.pop()
+		if !ok {
+			break
+		}
+		if h.append(t) {
+			shown++
+		}
+	}
+	h.trim()
+	if shown > 0 {
+		h.recolor()
+	}
+	return shown
+}
+
+// AppendOlder adds trades requested from REST api to bottom of list and
+// returns number of trades shown
+// Trades must be ordered newest first.  Buffered trades are shown first so
+// trades stay in order, trades are merged by aggregation window and trades
+// that are not older than oldest trade are ignored.
+func (h *History) AppendOlder(trades []Trade) int {
+	shown := h.ShowOlder(h.older.len())
+	for _, t := range mergeTrades(trades, h.window) {
+		if oldest, ok := h.Oldest(); ok && t.ID >= oldest.ID {
+			continue
+		}
+		if h.append(t) {
+			shown++
+		}
+	}
+	h.trim()
+	h.recolor()
+	return shown
+}
+
+// SetAggregation sets window in which trades from rest api are merged
+// Rows already shown are not changed.
+func (h *History) SetAggregation(window time.Duration) {
+	h.window = window
+}
+
+// append adds trade to bottom of list and reports whether a row is shown
+// for it
+// Depth is extended to keep the row and scrolling down to the new bottom
+// row calls OnScrolledToBottom again.
+func (h *History) append(t Trade) bool {
+	h.trades = append(h.trades, t)
+	if h.filter.Hidden(t) {
+		return false
+	}
+	h.Index[t.ID] = h.list.Append(h.newRow(t, nil, false))
+	h.rows++
+	h.atBottom = false
+	if h.rows > h.depth {
+		h.depth = h.rows
+	}
+	return true
+}
+
+// SetFilter changes trade filter and rebuilds rows from kept trades
+// Trades hidden by previous filter are shown again and whale rows are shown
+// in bold.  Row highlights are removed.
+func (h *History) SetFilter(filter TradeFilter) {
+	h.filter = filter
+
+	trades := h.trades
+	for {
+		t, ok := h.older.pop()
+		if !ok {
+			break
+		}
+		trades = append(trades, t)
+	}
+	for ; h.rows > 0; h.rows-- {
+		h.list.Pop()
+	}
+	h.trades = nil
+	h.Index = map[float64]int{}
+	h.fill(trades)
 }
 
 // RemoveHighlight resets row without highlight
+// Trades that are not in History are ignored
 func (h *History) RemoveHighlight(t Trade) {
-	i := h.Index[t.ID]
-	h.List.GetRow(i).(*historyRow).removeHighlight()
+	i, ok := h.Index[t.ID]
+	if !ok {
+		return
+	}
+	h.row(i).removeHighlight()
 }
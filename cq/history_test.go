@@ -0,0 +1,101 @@
+package cq
+
+import (
+	"reflect"
+	"testing"
+)
+
+// rowIDs returns IDs of trades shown in History from top row
+func rowIDs(h *History) []float64 {
+	ids := []float64{}
+	for i := 0; i < h.rows; i++ {
+		ids = append(ids, h.row(i).data.ID)
+	}
+	return ids
+}
+
+// sizedTrades returns trades with IDs counting down from len(sizes) so they
+// are ordered newest first
+func sizedTrades(sizes ...string) []Trade {
+	trades := []Trade{}
+	for i, size := range sizes {
+		trades = append(trades, trade(float64(len(sizes)-i), Buy, "100", size, -i))
+	}
+	return trades
+}
+
+func TestHistorySetFilter(t *testing.T) {
+	small := TradeFilter{MinSize: NewDecimal(5, 1)}
+	cfg := HistoryCfg{Depth: 2, Buffer: 10}
+	h := NewHistory(NewPair("BTC", "USD"), sizedTrades("1", "0.1", "2", "0.1", "3", "4"), cfg, small)
+
+	steps := []struct {
+		name string
+		do   func()
+		want []float64
+	}{
+		{"hidden trades dropped", func() {}, []float64{6, 4}},
+		{"filter removed", func() { h.SetFilter(TradeFilter{}) }, []float64{6, 5}},
+		{"older trades shown", func() { h.ShowOlder(10) }, []float64{6, 5, 4, 3, 2, 1}},
+		{"filter raised", func() { h.SetFilter(small) }, []float64{6, 4, 2, 1}},
+		{"hidden trade added", func() { h.Add(trade(7, Sell, "100", "0.2", 1)) }, []float64{6, 4, 2, 1}},
+		{"filter lowered", func() { h.SetFilter(TradeFilter{MinSize: NewDecimal(15, 2)}) }, []float64{7, 6, 4, 2, 1}},
+		{"filter removed again", func() { h.SetFilter(TradeFilter{}) }, []float64{7, 6, 5, 4, 3, 2}},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := rowIDs(h); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%v: got rows %v, want %v", step.name, got, step.want)
+		}
+		for i, id := range step.want {
+			if h.Index[id] != i {
+				t.Fatalf("%v: index of %v is %v, want %v", step.name, id, h.Index[id], i)
+			}
+		}
+	}
+
+	// trade below bottom row stays buffered
+	if oldest, ok := h.Oldest(); !ok || oldest.ID != 2 {
+		t.Errorf("got oldest %v, want 2", oldest.ID)
+	}
+	if got := h.ShowOlder(1); got != 1 {
+		t.Errorf("got %v older rows shown, want 1", got)
+	}
+}
+
+func TestHistoryHiddenLimit(t *testing.T) {
+	cfg := HistoryCfg{Depth: 5, Buffer: 2}
+	h := NewHistory(NewPair("BTC", "USD"), sizedTrades("1"), cfg, TradeFilter{MinSize: NewDecimal(5, 1)})
+	for id := 2; id <= 6; id++ {
+		h.Add(trade(float64(id), Buy, "100", "0.1", id))
+	}
+	if got := len(h.trades) - h.rows; got != 2 {
+		t.Errorf("got %v hidden trades kept, want 2", got)
+	}
+
+	h.SetFilter(TradeFilter{})
+	want := []float64{6, 5, 1}
+	if got := rowIDs(h); !reflect.DeepEqual(got, want) {
+		t.Errorf("got rows %v, want %v", got, want)
+	}
+}
+
+func TestHistorySetInstrument(t *testing.T) {
+	h := NewHistory(NewPair("BTC", "USD"), []Trade{trade(1, Buy, "9123.4", "0.5", 0)}, HistoryCfg{}, TradeFilter{})
+	row := h.row(0)
+	if got := row.data.sizeText(row.instrument); got != "0.50000000" {
+		t.Errorf("got default size %q, want 0.50000000", got)
+	}
+
+	h.SetInstrument(Instrument{TickSize: NewDecimal(1, 2), LotSize: NewDecimal(1, 4)})
+	h.Add(trade(2, Sell, "9123.5", "1", 1))
+	for i, want := range []string{"1.0000", "0.5000"} {
+		row := h.row(i)
+		if got := row.data.sizeText(row.instrument); got != want {
+			t.Errorf("row %v: got size %q, want %q", i, got, want)
+		}
+		if got := row.instrument.FmtPrice(row.data.Price); got != row.data.Price+"0" {
+			t.Errorf("row %v: got price %q", i, got)
+		}
+	}
+}
@@ -7,53 +7,127 @@ import (
 
 // HistoryRouter routes history update messages from websockets
 // to the main event loop and sets timers to remove row highlights
+// When aggregation is enabled consecutive trades on the same side at the
+// same price are merged and routed once the aggregation window closes, so
+// every trade, even one that is never merged, is delayed by up to the full
+// window (5s at most in the UI)
 type HistoryRouter struct {
 	sync.RWMutex
-	pair        Pair
-	highlighted map[float64]struct{}
-	tradeIn     chan Trade
-	tradeOut    chan HistoryUpdMsg
-	shutdown    chan struct{}
+	tradeIn   chan Trade
+	tradeOut  chan HistoryUpdMsg
+	retarget  chan historyTarget
+	aggregate chan time.Duration
+	filter    chan TradeFilter
+	shutdown  chan struct{}
+}
+
+// whaleTicks is number of highlight ticks that whale trades stay highlighted
+const whaleTicks = 5
+
+// historyTarget is the pair routed to History, the ID of its most recent
+// trade and its trade filter
+type historyTarget struct {
+	pair   Pair
+	lastID float64
+	filter TradeFilter
 }
 
 // StartHistoryRouter creates router and launches goroutine to route
 // update messages to main event loop
-func StartHistoryRouter(pair Pair, lastID float64) *HistoryRouter {
+func StartHistoryRouter(pair Pair, lastID float64, filter TradeFilter) *HistoryRouter {
 	r := &HistoryRouter{
-		pair:     pair,
-		tradeIn:  make(chan Trade, queueSize),
-		tradeOut: make(chan HistoryUpdMsg, queueSize),
-		shutdown: make(chan struct{}, 1),
+		tradeIn:   make(chan Trade, queueSize),
+		tradeOut:  make(chan HistoryUpdMsg, queueSize),
+		retarget:  make(chan historyTarget),
+		aggregate: make(chan time.Duration),
+		filter:    make(chan TradeFilter),
+		shutdown:  make(chan struct{}, 1),
 	}
 
 	go func() {
 		ticker := time.NewTicker(600 * time.Millisecond)
 		defer ticker.Stop()
-		index := make(map[float64]struct{})
+		// index holds highlighted trades and ticks until highlight is removed
+		index := make(map[float64]int)
+
+		// pending is the aggregated trade waiting for its window to close
+		var window time.Duration
+		var pending *Trade
+		var timer *time.Timer
+		var closed <-chan time.Time
+
+		send := func(t Trade) {
+			r.tradeOut <- HistoryUpdMsg{
+				Type:  HistoryUpd,
+				Trade: t,
+			}
+			// hidden trades have no row to highlight
+			if filter.Hidden(t) {
+				return
+			}
+			ticks := 1
+			if filter.IsWhale(t) {
+				ticks = whaleTicks
+			}
+			index[t.ID] = ticks
+		}
+		flush := func() {
+			if pending == nil {
+				return
+			}
+			timer.Stop()
+			send(*pending)
+			pending, closed = nil, nil
+		}
 
 	EventLoop:
 		for {
 			select {
 			case <-r.shutdown:
 				break EventLoop
+			case t := <-r.retarget:
+				// highlights and pending trade belong to previous History widget
+				pair, lastID, filter = t.pair, t.lastID, t.filter
+				index = make(map[float64]int)
+				if pending != nil {
+					timer.Stop()
+					pending, closed = nil, nil
+				}
+			case f := <-r.filter:
+				filter = f
+			case w := <-r.aggregate:
+				flush()
+				window = w
+			case <-closed:
+				flush()
 			case <-ticker.C:
-				for id := range index {
+				for id, ticks := range index {
+					if ticks > 1 {
+						index[id] = ticks - 1
+						continue
+					}
 					r.tradeOut <- HistoryUpdMsg{
 						Type:  HistoryHighlightUpd,
-						Trade: Trade{ID: id},
+						Trade: Trade{Pair: pair, ID: id},
 					}
 					delete(index, id)
 				}
 			case t := <-r.tradeIn:
-				if t.Pair == r.pair {
-					if t.ID > lastID {
-						r.tradeOut <- HistoryUpdMsg{
-							Type:  HistoryUpd,
-							Trade: t,
-						}
-						index[t.ID] = struct{}{}
-					}
+				if t.Pair != pair || t.ID <= lastID {
+					continue
+				}
+				if window <= 0 {
+					send(t)
+					continue
 				}
+				if pending != nil && mergeTrade(pending, t) {
+					continue
+				}
+				flush()
+				t.Count = 1
+				pending = &t
+				timer = time.NewTimer(window)
+				closed = timer.C
 			}
 		}
 	}()
@@ -61,6 +135,53 @@ func StartHistoryRouter(pair Pair, lastID float64) *HistoryRouter {
 	return r
 }
 
+// mergeTrade merges t into pending and reports whether it was merged
+// Only trades on the same side at the same price are merged.  Merged trade
+// takes ID and time of t, which must be the newer trade.
+func mergeTrade(pending *Trade, t Trade) bool {
+	if pending.Side != t.Side || pending.Price != t.Price {
+		return false
+	}
+	pending.Size = addSizes(pending.Size, t.Size)
+	pending.Count++
+	pending.ID = t.ID
+	pending.Timestamp = t.Timestamp
+	return true
+}
+
+// mergeTrades merges trades requested from rest api by the same rule
+// HistoryRouter applies to streaming trades
+// Trades must be ordered newest first.  A trade is merged into the
+// preceding older trade when it is on the same side at the same price and
+// within window of the oldest trade merged.  Zero window returns trades
+// unchanged.
+func mergeTrades(trades []Trade, window time.Duration) []Trade {
+	if window <= 0 || len(trades) == 0 {
+		return trades
+	}
+	merged := []Trade{}
+	var pending Trade
+	var start time.Time
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		if i < len(trades)-1 && t.Timestamp.Sub(start) < window && mergeTrade(&pending, t) {
+			continue
+		}
+		if i < len(trades)-1 {
+			merged = append(merged, pending)
+		}
+		t.Count = 1
+		pending, start = t, t.Timestamp
+	}
+	merged = append(merged, pending)
+
+	// restore newest first order
+	for i, j := 0, len(merged)-1; i < j; i, j = i+1, j-1 {
+		merged[i], merged[j] = merged[j], merged[i]
+	}
+	return merged
+}
+
 // GetChannels returns the router's inbound and outbound channels
 func (r *HistoryRouter) GetChannels() (chan<- Trade, <-chan HistoryUpdMsg) {
 	r.RLock()
@@ -69,6 +190,32 @@ func (r *HistoryRouter) GetChannels() (chan<- Trade, <-chan HistoryUpdMsg) {
 	return r.tradeIn, r.tradeOut
 }
 
+// SetPair changes pair routed to main event loop and its trade filter
+// Only trades newer than lastID are routed
+// Messages for previous pair may still be queued so receivers should check
+// Trade.Pair
+func (r *HistoryRouter) SetPair(pair Pair, lastID float64, filter TradeFilter) {
+	r.retarget <- historyTarget{
+		pair:   pair,
+		lastID: lastID,
+		filter: filter,
+	}
+}
+
+// SetFilter changes trade filter of current pair
+// Whale trades stay highlighted longer.  Trades below minimum size are
+// still routed so History can show them again when filter changes.
+func (r *HistoryRouter) SetFilter(filter TradeFilter) {
+	r.filter <- filter
+}
+
+// SetAggregation sets window in which consecutive trades on the same side at
+// the same price are merged into one trade
+// Zero window disables aggregation.  Pending trade is routed first.
+func (r *HistoryRouter) SetAggregation(window time.Duration) {
+	r.aggregate <- window
+}
+
 // Shutdown sends signal to event loop to shutdown routing goroutine
 func (r *HistoryRouter) Shutdown() {
 	r.Lock()
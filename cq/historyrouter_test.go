@@ -0,0 +1,108 @@
+package cq
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// trade returns trade at ms milliseconds past base time
+func trade(id float64, side Side, price, size string, ms int) Trade {
+	return Trade{
+		ID:        id,
+		Side:      side,
+		Price:     price,
+		Size:      size,
+		Timestamp: time.Unix(1577836800, 0).Add(time.Duration(ms) * time.Millisecond),
+	}
+}
+
+// merged returns t with size and count of merged trades
+func merged(t Trade, size string, count int) Trade {
+	t.Size = size
+	t.Count = count
+	return t
+}
+
+func TestMergeTrades(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		trades []Trade
+		want   []Trade
+	}{
+		{
+			name:   "disabled",
+			window: 0,
+			trades: []Trade{
+				trade(2, Buy, "100", "1", 10),
+				trade(1, Buy, "100", "1", 0),
+			},
+			want: []Trade{
+				trade(2, Buy, "100", "1", 10),
+				trade(1, Buy, "100", "1", 0),
+			},
+		},
+		{
+			name:   "empty",
+			window: time.Second,
+			trades: []Trade{},
+			want:   []Trade{},
+		},
+		{
+			name:   "single trade",
+			window: time.Second,
+			trades: []Trade{trade(1, Buy, "100", "1", 0)},
+			want:   []Trade{merged(trade(1, Buy, "100", "1", 0), "1", 1)},
+		},
+		{
+			name:   "same side and price",
+			window: time.Second,
+			trades: []Trade{
+				trade(3, Buy, "100", "0.25", 900),
+				trade(2, Buy, "100", "0.5", 400),
+				trade(1, Buy, "100", "1", 0),
+			},
+			want: []Trade{merged(trade(3, Buy, "100", "1.75", 900), "1.75", 3)},
+		},
+		{
+			name:   "window measured from oldest trade",
+			window: time.Second,
+			trades: []Trade{
+				trade(3, Buy, "100", "1", 1500),
+				trade(2, Buy, "100", "1", 800),
+				trade(1, Buy, "100", "1", 0),
+			},
+			want: []Trade{
+				merged(trade(3, Buy, "100", "1", 1500), "1", 1),
+				merged(trade(2, Buy, "100", "2", 800), "2", 2),
+			},
+		},
+		{
+			name:   "side and price changes",
+			window: time.Second,
+			trades: []Trade{
+				trade(5, Buy, "100", "1", 40),
+				trade(4, Buy, "100", "1", 30),
+				trade(3, Sell, "100", "1", 20),
+				trade(2, Buy, "101", "1", 10),
+				trade(1, Buy, "100", "1", 0),
+			},
+			want: []Trade{
+				merged(trade(5, Buy, "100", "2", 40), "2", 2),
+				merged(trade(3, Sell, "100", "1", 20), "1", 1),
+				merged(trade(2, Buy, "101", "1", 10), "1", 1),
+				merged(trade(1, Buy, "100", "1", 0), "1", 1),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := mergeTrades(test.trades, test.window)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package cq
 
 import (
+	"fmt"
 	"image/color"
 	"sort"
-	"strconv"
+	"strings"
+	"time"
 
 	"fyne.io/fyne"
 	"fyne.io/fyne/canvas"
@@ -11,35 +13,135 @@ import (
 	"fyne.io/fyne/widget"
 )
 
+const (
+	// Buy is a trade where the aggressor bought
+	Buy Side = iota + 1
+	// Sell is a trade where the aggressor sold
+	Sell
+)
+
+// Side is the side of the aggressor (taker) of a trade
+// Zero value means side is unknown
+type Side int
+
+// NewSide returns Side for "buy" or "sell" in any case
+func NewSide(s string) Side {
+	switch strings.ToLower(s) {
+	case "buy":
+		return Buy
+	case "sell":
+		return Sell
+	}
+	return 0
+}
+
+// Opposite returns the other side
+// Used by exchanges that report the maker's side
+func (s Side) Opposite() Side {
+	switch s {
+	case Buy:
+		return Sell
+	case Sell:
+		return Buy
+	}
+	return s
+}
+
+// String returns side in upper case
+func (s Side) String() string {
+	switch s {
+	case Buy:
+		return "BUY"
+	case Sell:
+		return "SELL"
+	}
+	return "-"
+}
+
 // Trade contains data necessary to create tow in History list
+// Price and size are kept as written by the exchange and are parsed with
+// ParseDecimal where they are compared or summed.
 type Trade struct {
-	Pair  Pair
-	ID    float64
-	Price string
-	Size  string
-	Time  string
+	Pair      Pair
+	ID        float64
+	Price     string
+	Size      string
+	Side      Side
+	Timestamp time.Time
+	// Count is number of trades merged into Trade by HistoryRouter
+	// Zero or one means a single trade
+	Count int
 }
 
-// PriceFloat returns the price as a float64
-func (t *Trade) PriceFloat() float64 {
-	p, _ := strconv.ParseFloat(t.Price, 64)
+// PriceDecimal returns the price as a Decimal
+// Invalid prices are zero
+func (t *Trade) PriceDecimal() Decimal {
+	p, _ := ParseDecimal(t.Price)
 	return p
 }
 
+// SizeDecimal returns the size as a Decimal
+// Invalid sizes are zero
+func (t *Trade) SizeDecimal() Decimal {
+	s, _ := ParseDecimal(t.Size)
+	return s
+}
+
+// sizeText formats size with instrument's precision for display with
+// number of merged trades
+func (t *Trade) sizeText(i Instrument) string {
+	size := i.FmtSize(t.Size)
+	if t.Count > 1 {
+		return fmt.Sprintf("%v (%d)", size, t.Count)
+	}
+	return size
+}
+
+// localTime formats timestamp in local timezone for display
+func (t *Trade) localTime() string {
+	if t.Timestamp.IsZero() {
+		return "-"
+	}
+	return t.Timestamp.Local().Format("03:04:05")
+}
+
+// historyColumns is the number of data columns in History
+const historyColumns = 4
+
 type historyRow struct {
 	widget.BaseWidget
 
 	isHighlighted bool
-	data          Trade
-	textColor     color.Color
-	bgColor       color.Color
+	// isWhale shows row in bold
+	isWhale   bool
+	data      Trade
+	textColor color.Color
+	bgColor   color.Color
+	// instrument sets precision of price and size
+	instrument Instrument
 }
 
-func newHistoryRow(t Trade, color color.Color, isHighlighted bool) *historyRow {
+func newHistoryRow(t Trade, instrument Instrument, color color.Color, isHighlighted bool, isWhale bool) *historyRow {
 	if isHighlighted {
-		return &historyRow{widget.BaseWidget{}, true, t, theme.BackgroundColor(), color}
+		return &historyRow{widget.BaseWidget{}, true, isWhale, t, theme.BackgroundColor(), color, instrument}
 	}
-	return &historyRow{widget.BaseWidget{}, false, t, color, theme.BackgroundColor()}
+	return &historyRow{widget.BaseWidget{}, false, isWhale, t, color, theme.BackgroundColor(), instrument}
+}
+
+// setInstrument changes precision of price and size
+func (r *historyRow) setInstrument(i Instrument) {
+	r.instrument = i
+	r.Refresh()
+}
+
+// setColor changes row's color keeping highlight state
+func (r *historyRow) setColor(c color.Color) {
+	if r.isHighlighted {
+		r.bgColor = c
+	} else {
+		r.textColor = c
+	}
+	r.Refresh()
 }
 
 func (r *historyRow) removeHighlight() {
@@ -55,58 +157,67 @@ func (r *historyRow) MinSize() fyne.Size {
 
 func (r *historyRow) CreateRenderer() fyne.WidgetRenderer {
 	r.ExtendBaseWidget(r)
-	size := canvas.NewText(r.data.Size, r.textColor)
+	side := canvas.NewText(r.data.Side.String(), r.textColor)
+	side.Alignment = fyne.TextAlignTrailing
+	size := canvas.NewText(r.data.sizeText(r.instrument), r.textColor)
 	size.Alignment = fyne.TextAlignTrailing
-	price := canvas.NewText(r.data.Price, r.textColor)
+	price := canvas.NewText(r.instrument.FmtPrice(r.data.Price), r.textColor)
 	price.Alignment = fyne.TextAlignTrailing
-	time := canvas.NewText(r.data.Time, r.textColor)
+	time := canvas.NewText(r.data.localTime(), r.textColor)
 	time.Alignment = fyne.TextAlignTrailing
 
 	// add 5 space margin on right side
 	margin := canvas.NewText("     ", r.textColor)
 	margin.Alignment = fyne.TextAlignTrailing
+	for _, t := range []*canvas.Text{side, size, price, time} {
+		t.TextStyle.Bold = r.isWhale
+	}
 	bg := canvas.NewRectangle(r.bgColor)
-	objects := []fyne.CanvasObject{bg, size, price, time, margin}
-	return &historyRowRenderer{bg: bg, size: size, price: price, time: time, margin: margin, objects: objects, row: r}
+	objects := []fyne.CanvasObject{bg, side, size, price, time, margin}
+	return &historyRowRenderer{bg: bg, side: side, size: size, price: price, time: time, margin: margin, objects: objects, row: r}
 }
 
 type historyRowRenderer struct {
-	size, price, time, margin *canvas.Text
-	bg                        *canvas.Rectangle
+	side, size, price, time, margin *canvas.Text
+	bg                              *canvas.Rectangle
 
 	objects []fyne.CanvasObject
 	row     *historyRow
 }
 
 func (r *historyRowRenderer) MinSize() fyne.Size {
+	sideMin := r.side.MinSize()
 	sizeMin := r.size.MinSize()
 	priceMin := r.price.MinSize()
 	timeMin := r.time.MinSize()
 	marginMin := r.margin.MinSize()
-	mins := []int{sizeMin.Width, priceMin.Width, timeMin.Width, marginMin.Width}
+	mins := []int{sideMin.Width, sizeMin.Width, priceMin.Width, timeMin.Width, marginMin.Width}
 	sort.Ints(mins)
 
-	return fyne.NewSize(3*(mins[len(mins)-1])+marginMin.Width, sizeMin.Height)
+	return fyne.NewSize(historyColumns*(mins[len(mins)-1])+marginMin.Width, sizeMin.Height)
 }
 
 func (r *historyRowRenderer) Layout(size fyne.Size) {
 	marWidth := r.margin.MinSize().Width
-	columnWidth := (size.Width - marWidth) / 3
-	columnSize := fyne.NewSize((size.Width-marWidth)/3, size.Height)
+	columnWidth := (size.Width - marWidth) / historyColumns
+	columnSize := fyne.NewSize(columnWidth, size.Height)
 
 	r.bg.Move(fyne.NewPos(0, 0))
 	r.bg.Resize(size)
 
-	r.size.Move(fyne.NewPos(0, 0))
+	r.side.Move(fyne.NewPos(0, 0))
+	r.side.Resize(columnSize)
+
+	r.size.Move(fyne.NewPos(columnWidth, 0))
 	r.size.Resize(columnSize)
 
-	r.price.Move(fyne.NewPos(columnWidth, 0))
+	r.price.Move(fyne.NewPos(columnWidth*2, 0))
 	r.price.Resize(columnSize)
 
-	r.time.Move(fyne.NewPos(columnWidth*2, 0))
+	r.time.Move(fyne.NewPos(columnWidth*3, 0))
 	r.time.Resize(columnSize)
 
-	r.margin.Move(fyne.NewPos(columnWidth*3, 0))
+	r.margin.Move(fyne.NewPos(columnWidth*historyColumns, 0))
 	r.margin.Resize(fyne.NewSize(marWidth, size.Height))
 }
 
@@ -120,11 +231,15 @@ func (r *historyRowRenderer) Objects() []fyne.CanvasObject {
 
 func (r *historyRowRenderer) Refresh() {
 	r.bg.FillColor = r.row.bgColor
-	r.size.Color = r.row.textColor
-	r.price.Color = r.row.textColor
-	r.time.Color = r.row.textColor
+	r.size.Text = r.row.data.sizeText(r.row.instrument)
+	r.price.Text = r.row.instrument.FmtPrice(r.row.data.Price)
+	for _, t := range []*canvas.Text{r.side, r.size, r.price, r.time} {
+		t.Color = r.row.textColor
+		t.TextStyle.Bold = r.row.isWhale
+	}
 	r.Layout(r.row.Size())
 	r.bg.Refresh()
+	r.side.Refresh()
 	r.size.Refresh()
 	r.price.Refresh()
 	r.time.Refresh()
@@ -0,0 +1,40 @@
+package cq
+
+// Instrument holds trading rules of a pair on an exchange
+// Exchanges fill in what their api provides.  Zero TickSize or LotSize
+// means it is unknown and default formatting is used.
+type Instrument struct {
+	Pair Pair
+	// TickSize is the smallest price increment
+	TickSize Decimal
+	// LotSize is the smallest size increment
+	LotSize Decimal
+	// FeeCurrency is the currency fees are charged in
+	FeeCurrency string
+	// MakerFee and TakerFee are fee rates as fractions of trade value
+	MakerFee Decimal
+	TakerFee Decimal
+}
+
+// NewInstrument returns Instrument for pair with unknown trading rules
+func NewInstrument(pair Pair) Instrument {
+	return Instrument{Pair: pair}
+}
+
+// PricePlaces returns number of decimal places in tick size
+// Returns false if tick size is unknown
+func (i Instrument) PricePlaces() (int, bool) {
+	if i.TickSize.Sign() <= 0 {
+		return 0, false
+	}
+	return decimals(i.TickSize.String()), true
+}
+
+// SizePlaces returns number of decimal places in lot size
+// Returns false if lot size is unknown
+func (i Instrument) SizePlaces() (int, bool) {
+	if i.LotSize.Sign() <= 0 {
+		return 0, false
+	}
+	return decimals(i.LotSize.String()), true
+}
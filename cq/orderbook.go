@@ -0,0 +1,110 @@
+package cq
+
+import (
+	"sort"
+)
+
+// BookLevel is a single price level of an L2 order book
+type BookLevel struct {
+	Price string
+	Size  string
+}
+
+// PriceDecimal returns the price as a Decimal
+// Invalid prices are zero
+func (l BookLevel) PriceDecimal() Decimal {
+	p, _ := ParseDecimal(l.Price)
+	return p
+}
+
+// SizeDecimal returns the size as a Decimal
+// Invalid sizes are zero
+func (l BookLevel) SizeDecimal() Decimal {
+	s, _ := ParseDecimal(l.Size)
+	return s
+}
+
+// OrderBook is a local copy of an exchange's L2 order book
+// Exchanges apply snapshots and updates from their websocket api and send
+// top levels to main event loop in BookUpdMsg
+type OrderBook struct {
+	// Sequence is the exchange's sequence number of last applied message
+	Sequence int64
+
+	// levels are keyed by normalized price so "100.10" and "100.1" are the
+	// same level
+	bids map[string]bookEntry
+	asks map[string]bookEntry
+}
+
+// bookEntry is a level with its parsed price
+type bookEntry struct {
+	price Decimal
+	level BookLevel
+}
+
+// NewOrderBook returns an empty order book
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		bids: make(map[string]bookEntry),
+		asks: make(map[string]bookEntry),
+	}
+}
+
+// Reset replaces all levels with snapshot
+func (b *OrderBook) Reset(seq int64, bids []BookLevel, asks []BookLevel) {
+	b.bids = make(map[string]bookEntry)
+	b.asks = make(map[string]bookEntry)
+	b.Update(seq, bids, asks)
+}
+
+// Update changes levels in book
+// Levels with zero size are removed
+func (b *OrderBook) Update(seq int64, bids []BookLevel, asks []BookLevel) {
+	b.Sequence = seq
+	apply(b.bids, bids)
+	apply(b.asks, asks)
+}
+
+func apply(side map[string]bookEntry, levels []BookLevel) {
+	for _, l := range levels {
+		p := l.PriceDecimal()
+		key := p.normalize().String()
+		if l.SizeDecimal().IsZero() {
+			delete(side, key)
+			continue
+		}
+		side[key] = bookEntry{price: p, level: l}
+	}
+}
+
+// Bids returns up to depth bids ordered from highest price
+func (b *OrderBook) Bids(depth int) []BookLevel {
+	return top(b.bids, depth, 1)
+}
+
+// Asks returns up to depth asks ordered from lowest price
+func (b *OrderBook) Asks(depth int) []BookLevel {
+	return top(b.asks, depth, -1)
+}
+
+// top returns up to depth levels of side ordered by price
+// better is the result of Decimal.Cmp for a price ahead of another
+func top(side map[string]bookEntry, depth int, better int) []BookLevel {
+	entries := make([]bookEntry, 0, len(side))
+	for _, e := range side {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].price.Cmp(entries[j].price) == better
+	})
+	if depth < len(entries) {
+		entries = entries[:depth]
+	}
+
+	levels := make([]BookLevel, 0, len(entries))
+	for _, e := range entries {
+		levels = append(levels, e.level)
+	}
+	return levels
+}
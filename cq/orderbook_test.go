@@ -0,0 +1,31 @@
+package cq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderBook(t *testing.T) {
+	b := NewOrderBook()
+	b.Reset(1,
+		[]BookLevel{{"100.10", "1"}, {"99.9", "2"}, {"100.05", "3"}},
+		[]BookLevel{{"100.2", "1"}, {"100.15", "2"}, {"0.1e3", "5"}},
+	)
+	// same prices written differently update the same level
+	b.Update(2,
+		[]BookLevel{{"100.1", "4"}, {"99.90", "0"}},
+		[]BookLevel{{"100", "0.0"}},
+	)
+
+	wantBids := []BookLevel{{"100.1", "4"}, {"100.05", "3"}}
+	if got := b.Bids(5); !reflect.DeepEqual(got, wantBids) {
+		t.Errorf("got bids %v, want %v", got, wantBids)
+	}
+	wantAsks := []BookLevel{{"100.15", "2"}}
+	if got := b.Asks(1); !reflect.DeepEqual(got, wantAsks) {
+		t.Errorf("got asks %v, want %v", got, wantAsks)
+	}
+	if b.Sequence != 2 {
+		t.Errorf("got sequence %v, want 2", b.Sequence)
+	}
+}
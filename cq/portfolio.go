@@ -0,0 +1,147 @@
+package cq
+
+import (
+	"strings"
+	"sync"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/widget"
+)
+
+// percentPlaces is number of decimal places kept in percentages
+const percentPlaces = 4
+
+// hundred converts fractions to percentages
+var hundred = NewDecimal(100, 0)
+
+// Holding is an amount of a currency with the total cost paid for it
+type Holding struct {
+	Currency string  `json:"currency"`
+	Amount   Decimal `json:"amount"`
+	// Cost is total amount paid in CostCurrency
+	Cost         Decimal `json:"cost"`
+	CostCurrency string  `json:"costCurrency"`
+}
+
+// PortfolioCfg holds holdings and the home currency they are valued in
+type PortfolioCfg struct {
+	Home     string    `json:"home"`
+	Holdings []Holding `json:"holdings"`
+}
+
+// Valuation is a holding valued in home currency
+// Priced is false if no rate to home currency is known
+type Valuation struct {
+	Holding
+	Priced bool
+	// Price, Value, Cost and PnL are in home currency
+	Price Decimal
+	Value Decimal
+	Cost  Decimal
+	PnL   Decimal
+	// PnLPerc and Allocation are percentages rounded to 4 places
+	PnLPerc    Decimal
+	Allocation Decimal
+}
+
+// Value values holdings in home currency with rates
+// Cost is converted to home currency at current rate if it was paid in
+// another currency.  Returns valuations and total value of priced holdings.
+func (c PortfolioCfg) Value(rates *Rates) ([]Valuation, Decimal) {
+	home := strings.ToUpper(c.Home)
+	vals := []Valuation{}
+	total := Decimal{}
+	for _, h := range c.Holdings {
+		v := Valuation{Holding: h}
+		price, ok := rates.Rate(strings.ToUpper(h.Currency), home)
+		costRate, costOK := rates.Rate(strings.ToUpper(h.CostCurrency), home)
+		if ok && costOK {
+			v.Priced = true
+			v.Price = price
+			v.Value = h.Amount.Mul(price)
+			v.Cost = h.Cost.Mul(costRate)
+			v.PnL = v.Value.Sub(v.Cost)
+			// zero cost leaves percentage at zero
+			v.PnLPerc, _ = v.PnL.Mul(hundred).Div(v.Cost, percentPlaces)
+			total = total.Add(v.Value)
+		}
+		vals = append(vals, v)
+	}
+	for i := range vals {
+		if vals[i].Priced {
+			vals[i].Allocation, _ = vals[i].Value.Mul(hundred).Div(total, percentPlaces)
+		}
+	}
+	return vals, total
+}
+
+// Portfolio is a widget that shows holdings valued in home currency from
+// live prices with unrealised profit and loss and allocation
+type Portfolio struct {
+	widget.BaseWidget
+	sync.RWMutex
+
+	cfg   PortfolioCfg
+	rates *Rates
+}
+
+// NewPortfolio returns a new instance of Portfolio widget valued with rates
+func NewPortfolio(cfg PortfolioCfg, rates *Rates) *Portfolio {
+	p := &Portfolio{
+		cfg:   cfg,
+		rates: rates,
+	}
+	p.ExtendBaseWidget(p)
+
+	return p
+}
+
+// SetHoldings replaces holdings and home currency
+func (p *Portfolio) SetHoldings(cfg PortfolioCfg) {
+	p.Lock()
+	p.cfg = cfg
+	p.Unlock()
+
+	p.Refresh()
+}
+
+// Uses reports whether price of pair is used to value portfolio
+// Pair is used if it is on the path converting a holding or its cost to
+// home currency.
+func (p *Portfolio) Uses(pair Pair) bool {
+	currencies, home := p.Currencies()
+	return p.rates.Converts(pair, currencies, home)
+}
+
+// Currencies returns currencies that are converted to home currency
+func (p *Portfolio) Currencies() ([]string, string) {
+	p.RLock()
+	defer p.RUnlock()
+
+	currencies := []string{}
+	for _, h := range p.cfg.Holdings {
+		currencies = append(currencies, strings.ToUpper(h.Currency), strings.ToUpper(h.CostCurrency))
+	}
+	return currencies, strings.ToUpper(p.cfg.Home)
+}
+
+// values returns valuations of holdings, total value and home currency
+func (p *Portfolio) values() ([]Valuation, Decimal, string) {
+	p.RLock()
+	defer p.RUnlock()
+
+	vals, total := p.cfg.Value(p.rates)
+	return vals, total, strings.ToUpper(p.cfg.Home)
+}
+
+// MinSize returns the size that this widget should not shrink below
+func (p *Portfolio) MinSize() fyne.Size {
+	p.ExtendBaseWidget(p)
+	return fyne.NewSize(400, 150)
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (p *Portfolio) CreateRenderer() fyne.WidgetRenderer {
+	p.ExtendBaseWidget(p)
+	return &portfolioRenderer{portfolio: p}
+}
@@ -0,0 +1,100 @@
+package cq
+
+import (
+	"testing"
+)
+
+func TestPortfolioValue(t *testing.T) {
+	r := newTestRates(t, []Pair{
+		NewMarketPair(HitBTC, "XRP", "JPY", Spot),
+	}, map[string]string{
+		"HITBTC:ETH/BTC": "0.02",
+		"HITBTC:BTC/USD": "7000",
+		"HITBTC:BTC/EUR": "5600",
+	})
+	cfg := PortfolioCfg{
+		Home: "usd",
+		Holdings: []Holding{
+			{Currency: "eth", Amount: dec(t, "10"), Cost: dec(t, "1000"), CostCurrency: "USD"},
+			// cost in EUR is converted at current rate of 1.25 USD
+			{Currency: "BTC", Amount: dec(t, "0.5"), Cost: dec(t, "3000"), CostCurrency: "EUR"},
+			{Currency: "XRP", Amount: dec(t, "100"), Cost: dec(t, "5000"), CostCurrency: "JPY"},
+			{Currency: "USD", Amount: dec(t, "1100"), CostCurrency: "USD"},
+		},
+	}
+
+	vals, total := cfg.Value(r)
+	if total.Cmp(dec(t, "6000")) != 0 {
+		t.Errorf("got total %v, want 6000", total)
+	}
+	want := []struct {
+		priced                  bool
+		price, value, cost, pnl string
+		pnlPerc, allocation     string
+	}{
+		{true, "140", "1400", "1000", "400", "40", "23.3333"},
+		{true, "7000", "3500", "3750", "-250", "-6.6667", "58.3333"},
+		{false, "0", "0", "0", "0", "0", "0"},
+		// zero cost leaves percentage at zero
+		{true, "1", "1100", "0", "1100", "0", "18.3333"},
+	}
+	if len(vals) != len(want) {
+		t.Fatalf("got %v valuations, want %v", len(vals), len(want))
+	}
+	for i, w := range want {
+		v := vals[i]
+		if v.Holding != cfg.Holdings[i] || v.Priced != w.priced {
+			t.Errorf("%v: got %+v, want priced %v", i, v, w.priced)
+			continue
+		}
+		got := []Decimal{v.Price, v.Value, v.Cost, v.PnL, v.PnLPerc, v.Allocation}
+		for j, s := range []string{w.price, w.value, w.cost, w.pnl, w.pnlPerc, w.allocation} {
+			if got[j].Cmp(dec(t, s)) != 0 {
+				t.Errorf("%v: got %v, want %v", i, got, want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestPortfolioUses(t *testing.T) {
+	r := newTestRates(t, nil, map[string]string{
+		"HITBTC:ETH/BTC": "0.02",
+		"HITBTC:BTC/USD": "7000",
+		"HITBTC:BTC/EUR": "5600",
+		"HITBTC:EUR/USD": "1.25",
+		"HITBTC:XRP/JPY": "30",
+	})
+	eth := PortfolioCfg{
+		Home:     "USD",
+		Holdings: []Holding{{Currency: "ETH", Amount: dec(t, "1"), CostCurrency: "USD"}},
+	}
+	ethEUR := PortfolioCfg{
+		Home:     "USD",
+		Holdings: []Holding{{Currency: "ETH", Amount: dec(t, "1"), CostCurrency: "EUR"}},
+	}
+
+	tests := []struct {
+		name string
+		cfg  PortfolioCfg
+		key  string
+		want bool
+	}{
+		{"holding pair", eth, "HITBTC:ETH/BTC", true},
+		{"pair on path", eth, "HITBTC:BTC/USD", true},
+		{"pair on longer path", eth, "HITBTC:BTC/EUR", false},
+		{"unlinked pair", eth, "HITBTC:XRP/JPY", false},
+		{"cost currency pair", ethEUR, "HITBTC:EUR/USD", true},
+		{"empty portfolio", PortfolioCfg{Home: "USD"}, "HITBTC:BTC/USD", false},
+	}
+	for _, test := range tests {
+		pair, err := ParsePair(test.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewPortfolio(test.cfg, r)
+		if got := p.Uses(pair); got != test.want {
+			t.Errorf("%v: Uses(%v) = %v, want %v", test.name, test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cq
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/theme"
+)
+
+// portfolioColumns is the number of columns in Portfolio
+const portfolioColumns = 6
+
+type portfolioRenderer struct {
+	portfolio *Portfolio
+
+	// objects is rebuilt every time portfolio is laid out
+	objects []fyne.CanvasObject
+}
+
+func (r *portfolioRenderer) MinSize() fyne.Size {
+	return r.portfolio.MinSize()
+}
+
+// Layout draws header, a row for each holding and total
+func (r *portfolioRenderer) Layout(size fyne.Size) {
+	vals, total, home := r.portfolio.values()
+	rowHeight := canvas.NewText("0", white).MinSize().Height
+	columnWidth := size.Width / portfolioColumns
+
+	objects := []fyne.CanvasObject{}
+	text := func(s string, c color.Color, col int, y int) {
+		t := canvas.NewText(s, c)
+		t.Alignment = fyne.TextAlignTrailing
+		t.Move(fyne.NewPos(col*columnWidth, y))
+		t.Resize(fyne.NewSize(columnWidth-theme.Padding(), rowHeight))
+		objects = append(objects, t)
+	}
+	// percent draws percentage as float with 2 places
+	percent := func(d Decimal) string {
+		return fmt.Sprintf("%.2f%%", d.Float64())
+	}
+
+	headers := []string{"Currency", "Amount", fmt.Sprintf("Price(%v)", home), "Value", "P&L", "Alloc"}
+	for i, h := range headers {
+		text(h, white, i, 0)
+	}
+
+	y := rowHeight
+	for _, v := range vals {
+		text(v.Currency, white, 0, y)
+		text(v.Amount.String(), white, 1, y)
+		if !v.Priced {
+			for col := 2; col < portfolioColumns; col++ {
+				text("-", white, col, y)
+			}
+			y += rowHeight
+			continue
+		}
+		pnlColor := setColor(Even)
+		switch v.PnL.Sign() {
+		case 1:
+			pnlColor = setColor(Up)
+		case -1:
+			pnlColor = setColor(Down)
+		}
+		text(fmtValue(v.Price), white, 2, y)
+		text(fmtValue(v.Value), white, 3, y)
+		text(fmt.Sprintf("%v (%v)", fmtValue(v.PnL), percent(v.PnLPerc)), pnlColor, 4, y)
+		text(percent(v.Allocation), white, 5, y)
+		y += rowHeight
+	}
+
+	text("Total", white, 0, y)
+	text(fmtValue(total), white, 3, y)
+
+	r.objects = objects
+}
+
+func (r *portfolioRenderer) BackgroundColor() color.Color {
+	return theme.BackgroundColor()
+}
+
+func (r *portfolioRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *portfolioRenderer) Refresh() {
+	r.Layout(r.portfolio.Size())
+	canvas.Refresh(r.portfolio)
+}
+
+func (r *portfolioRenderer) Destroy() {}
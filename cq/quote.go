@@ -2,10 +2,13 @@ package cq
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
 // Quote contains all quote data as strings to support display
+// Values are kept as written by the exchange, empty when missing from a
+// partial update, and are parsed with ParseDecimal where they are compared.
 type Quote struct {
 	ExchangeID ExchangeID
 	ID         Pair
@@ -24,14 +27,52 @@ type Quote struct {
 	Volume      string
 }
 
+const (
+	// Spot market
+	Spot MarketType = iota
+	// Perpetual swap market
+	Perpetual
+	// Futures market
+	Futures
+)
+
+// MarketType identifies kind of market an instrument trades in
+type MarketType int
+
+// String returns name of market type used in pair keys
+func (m MarketType) String() string {
+	switch m {
+	case Perpetual:
+		return "PERP"
+	case Futures:
+		return "FUTURES"
+	}
+	return "SPOT"
+}
+
+// parseMarketType returns market type with name
+func parseMarketType(s string) (MarketType, bool) {
+	for _, m := range []MarketType{Spot, Perpetual, Futures} {
+		if strings.EqualFold(s, m.String()) {
+			return m, true
+		}
+	}
+	return Spot, false
+}
+
 // Pair is a crypto instrument with a base currency and a quote currency
 // First one listed is the base currency (ie, BTC in BTC/USD)
+// Pair also identifies the exchange and market type it trades on so the
+// same currencies on two exchanges are different pairs.
 type Pair struct {
+	exchange      ExchangeID
 	baseCurrency  string
 	quoteCurrency string
+	market        MarketType
 }
 
 // NewPair creates a new currency pair with the base and quote currencies in all caps
+// Pair is a spot pair without exchange.  Use WithExchange to set it.
 func NewPair(b string, q string) Pair {
 	return Pair{
 		baseCurrency:  strings.ToUpper(b),
@@ -39,11 +80,103 @@ func NewPair(b string, q string) Pair {
 	}
 }
 
+// NewMarketPair creates a new pair traded on exchange in market
+func NewMarketPair(id ExchangeID, b string, q string, m MarketType) Pair {
+	p := NewPair(b, q)
+	p.exchange, p.market = id, m
+	return p
+}
+
+// ParsePair parses pair keys in "EXCHANGE:BASE/QUOTE" format with optional
+// ":MARKET" suffix for markets other than spot
+// Exchange may be left out as in "BTC/USD".
+func ParsePair(s string) (Pair, error) {
+	t := strings.Split(s, ":")
+	if len(t) > 3 {
+		return Pair{}, fmt.Errorf("invalid pair: %v", s)
+	}
+
+	// first segment is an exchange unless it holds the currencies as in
+	// "BTC/USD:PERP"
+	var id ExchangeID
+	if len(t) > 1 && !strings.Contains(t[0], "/") {
+		var ok bool
+		id, ok = parseExchangeID(t[0])
+		if !ok {
+			return Pair{}, fmt.Errorf("invalid pair exchange: %v", s)
+		}
+		t = t[1:]
+	}
+
+	market := Spot
+	if len(t) > 2 {
+		return Pair{}, fmt.Errorf("invalid pair: %v", s)
+	}
+	if len(t) == 2 {
+		var ok bool
+		market, ok = parseMarketType(t[1])
+		if !ok {
+			return Pair{}, fmt.Errorf("invalid pair market: %v", s)
+		}
+	}
+
+	c := strings.Split(t[0], "/")
+	if len(c) != 2 || len(c[0]) == 0 || len(c[1]) == 0 {
+		return Pair{}, fmt.Errorf("invalid pair: %v", s)
+	}
+	return NewMarketPair(id, c[0], c[1], market), nil
+}
+
 // String returns pair as a string - all CAPS separated by "/"
 func (p Pair) String() string {
 	return fmt.Sprintf("%v/%v", p.baseCurrency, p.quoteCurrency)
 }
 
+// Key returns pair in format parsed by ParsePair such as "HITBTC:BTC/USD"
+// Exchange is left out if it is not set.
+func (p Pair) Key() string {
+	key := p.String()
+	if p.exchange != 0 {
+		key = strings.ToUpper(p.exchange.String()) + ":" + key
+	}
+	if p.market != Spot {
+		key += ":" + p.market.String()
+	}
+	return key
+}
+
+// MarshalText encodes pair in the same format as Key
+func (p Pair) MarshalText() ([]byte, error) {
+	return []byte(p.Key()), nil
+}
+
+// UnmarshalText decodes pair from format of Key or "BASE/QUOTE" format
+func (p *Pair) UnmarshalText(b []byte) error {
+	pair, err := ParsePair(string(b))
+	if err != nil {
+		return err
+	}
+	*p = pair
+	return nil
+}
+
+// Exchange returns ID of exchange pair trades on
+// Zero if pair was created without exchange
+func (p Pair) Exchange() ExchangeID {
+	return p.exchange
+}
+
+// Market returns market type of pair
+func (p Pair) Market() MarketType {
+	return p.market
+}
+
+// WithExchange returns pair traded on exchange id
+func (p Pair) WithExchange(id ExchangeID) Pair {
+	p.exchange = id
+	return p
+}
+
 // BaseCurrency returns the base currency's abbreviation as a string
 func (p Pair) BaseCurrency() string {
 	return p.baseCurrency
@@ -53,3 +186,30 @@ func (p Pair) BaseCurrency() string {
 func (p Pair) QuoteCurrency() string {
 	return p.quoteCurrency
 }
+
+// FilterPairs returns pairs matching query sorted by name
+// Query "ETH" matches pairs with base or quote currency starting with ETH
+// and query "ETH/B" matches base currency ETH and quote currency starting
+// with B
+func FilterPairs(pairs []Pair, query string) []Pair {
+	query = strings.ToUpper(strings.TrimSpace(query))
+
+	matches := []Pair{}
+	for _, p := range pairs {
+		if matchPair(p, query) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].String() < matches[j].String()
+	})
+	return matches
+}
+
+// matchPair reports whether pair matches upper case query
+func matchPair(p Pair, query string) bool {
+	if i := strings.Index(query, "/"); i >= 0 {
+		return strings.HasPrefix(p.baseCurrency, query[:i]) && strings.HasPrefix(p.quoteCurrency, query[i+1:])
+	}
+	return strings.HasPrefix(p.baseCurrency, query) || strings.HasPrefix(p.quoteCurrency, query)
+}
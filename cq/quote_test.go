@@ -0,0 +1,65 @@
+package cq
+
+import (
+	"testing"
+)
+
+func TestPairKeyRoundTrip(t *testing.T) {
+	pairs := []Pair{
+		NewPair("BTC", "USD"),
+		NewMarketPair(0, "BTC", "USD", Perpetual),
+		NewMarketPair(0, "ETH", "BTC", Futures),
+		NewMarketPair(HitBTC, "BTC", "USD", Spot),
+		NewMarketPair(Coinbase, "ETH", "USD", Spot),
+		NewMarketPair(Bitfinex, "BTCF0", "USTF0", Perpetual),
+		NewMarketPair(Bitfinex, "DOGE", "USD", Futures),
+	}
+	for _, p := range pairs {
+		got, err := ParsePair(p.Key())
+		if err != nil {
+			t.Errorf("ParsePair(%q): %v", p.Key(), err)
+			continue
+		}
+		if got != p {
+			t.Errorf("ParsePair(%q) = %q", p.Key(), got.Key())
+		}
+	}
+}
+
+func TestParsePair(t *testing.T) {
+	tests := []struct {
+		key  string
+		want Pair
+	}{
+		{"btc/usd", NewPair("BTC", "USD")},
+		{"BTC/USD:PERP", NewMarketPair(0, "BTC", "USD", Perpetual)},
+		{"hitbtc:BTC/USD", NewMarketPair(HitBTC, "BTC", "USD", Spot)},
+		{"BITFINEX:BTC/USD:SPOT", NewMarketPair(Bitfinex, "BTC", "USD", Spot)},
+	}
+	for _, test := range tests {
+		got, err := ParsePair(test.key)
+		if err != nil {
+			t.Errorf("ParsePair(%q): %v", test.key, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParsePair(%q) = %q, want %q", test.key, got.Key(), test.want.Key())
+		}
+	}
+
+	invalid := []string{
+		"",
+		"BTCUSD",
+		"BTC/",
+		"KRAKEN:BTC/USD",
+		"BTC/USD:SWAP",
+		"BTC/USD:PERP:SPOT",
+		"HITBTC:BTC/USD:PERP:SPOT",
+		"HITBTC:BTCUSD",
+	}
+	for _, key := range invalid {
+		if p, err := ParsePair(key); err == nil {
+			t.Errorf("ParsePair(%q) = %q, want error", key, p.Key())
+		}
+	}
+}
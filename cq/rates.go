@@ -0,0 +1,225 @@
+package cq
+
+import (
+	"sync"
+)
+
+// ratePlaces is number of decimal places kept when a rate divides by a
+// price
+const ratePlaces = 18
+
+// Rates is a conversion graph of currencies linked by pairs
+// Pairs available on an exchange link currencies and last prices of pairs
+// convert between them along the shortest path of priced pairs.
+type Rates struct {
+	sync.RWMutex
+
+	prices map[Pair]Decimal
+	// edges links each currency to pairs it is traded in
+	edges map[string][]Pair
+}
+
+// NewRates returns Rates without pairs or prices
+func NewRates() *Rates {
+	return &Rates{
+		prices: map[Pair]Decimal{},
+		edges:  map[string][]Pair{},
+	}
+}
+
+// SetPairs adds pairs to conversion graph
+// Used with exchange's available pairs so paths can be found before
+// their pairs are priced
+func (r *Rates) SetPairs(pairs []Pair) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, p := range pairs {
+		r.addEdge(p)
+	}
+}
+
+// addEdge links currencies of pair if they are not already linked
+func (r *Rates) addEdge(p Pair) {
+	for _, e := range r.edges[p.BaseCurrency()] {
+		if e == p {
+			return
+		}
+	}
+	r.edges[p.BaseCurrency()] = append(r.edges[p.BaseCurrency()], p)
+	r.edges[p.QuoteCurrency()] = append(r.edges[p.QuoteCurrency()], p)
+}
+
+// Update sets last price of pair from update message
+// Returns true if price changed
+func (r *Rates) Update(upd UpdateMsg) bool {
+	if upd.Type != InitUpd && upd.Type != TradeUpd {
+		return false
+	}
+	p, err := ParseDecimal(upd.Quote.Price)
+	if err != nil || p.Sign() <= 0 {
+		return false
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	pair := upd.Quote.ID
+	if prev, ok := r.prices[pair]; ok && prev.Cmp(p) == 0 {
+		return false
+	}
+	r.prices[pair] = p
+	r.addEdge(pair)
+	return true
+}
+
+// Rate returns price of one unit of currency from in currency to using the
+// shortest path of priced pairs
+// Returns false if currencies are not linked by priced pairs
+// Rates that divide by a price are rounded to 18 decimal places.
+func (r *Rates) Rate(from string, to string) (Decimal, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	path, ok := r.path(from, to, r.priced)
+	if !ok {
+		return Decimal{}, false
+	}
+
+	// prices divided by are multiplied into den so rate is only rounded once
+	rate, den, cur := NewDecimal(1, 0), NewDecimal(1, 0), from
+	for _, p := range path {
+		if p.BaseCurrency() == cur {
+			rate = rate.Mul(r.prices[p])
+			cur = p.QuoteCurrency()
+		} else {
+			den = den.Mul(r.prices[p])
+			cur = p.BaseCurrency()
+		}
+	}
+	if den.Cmp(NewDecimal(1, 0)) == 0 {
+		return rate, true
+	}
+	// prices are positive so division can't fail
+	rate, _ = rate.Div(den, ratePlaces)
+	return rate, true
+}
+
+// Convert returns amount of currency from in currency to
+func (r *Rates) Convert(amount Decimal, from string, to string) (Decimal, bool) {
+	rate, ok := r.Rate(from, to)
+	return amount.Mul(rate), ok
+}
+
+// Path returns shortest path of pairs from currency from to currency to
+// Pairs in path may not be priced yet
+func (r *Rates) Path(from string, to string) ([]Pair, bool) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.path(from, to, func(Pair) bool { return true })
+}
+
+// Missing returns unpriced pairs on shortest paths from currencies to
+// currency to
+// Callers can request quotes for them so currencies can be converted
+func (r *Rates) Missing(currencies []string, to string) []Pair {
+	r.RLock()
+	defer r.RUnlock()
+
+	seen := map[Pair]struct{}{}
+	missing := []Pair{}
+	for _, c := range currencies {
+		if _, ok := r.path(c, to, r.priced); ok {
+			continue
+		}
+		path, _ := r.path(c, to, func(Pair) bool { return true })
+		for _, p := range path {
+			if _, ok := r.prices[p]; ok {
+				continue
+			}
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				missing = append(missing, p)
+			}
+		}
+	}
+	return missing
+}
+
+// Converts reports whether price of pair is used to convert any of
+// currencies to currency to
+// Only pairs on shortest paths of priced pairs are used by Rate.
+func (r *Rates) Converts(pair Pair, currencies []string, to string) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, c := range currencies {
+		path, _ := r.path(c, to, r.priced)
+		for _, p := range path {
+			if p == pair {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// priced reports whether pair has a price
+// Must be called with lock held
+func (r *Rates) priced(p Pair) bool {
+	_, ok := r.prices[p]
+	return ok
+}
+
+// path finds shortest path by number of pairs with breadth first search
+// over pairs accepted by usable
+// Must be called with lock held
+func (r *Rates) path(from string, to string, usable func(Pair) bool) ([]Pair, bool) {
+	if from == to {
+		return nil, true
+	}
+
+	// prev holds pair used to reach each currency
+	prev := map[string]Pair{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, p := range r.edges[cur] {
+			if !usable(p) {
+				continue
+			}
+			next := p.QuoteCurrency()
+			if next == cur {
+				next = p.BaseCurrency()
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			prev[next] = p
+			if next == to {
+				return walkBack(prev, from, to), true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+// walkBack returns path from currency from to currency to recorded in prev
+func walkBack(prev map[string]Pair, from string, to string) []Pair {
+	path := []Pair{}
+	for cur := to; cur != from; {
+		p := prev[cur]
+		path = append([]Pair{p}, path...)
+		if p.BaseCurrency() == cur {
+			cur = p.QuoteCurrency()
+		} else {
+			cur = p.BaseCurrency()
+		}
+	}
+	return path
+}
@@ -0,0 +1,157 @@
+package cq
+
+import (
+	"testing"
+)
+
+// newTestRates returns rates with pairs priced at prices given by key
+func newTestRates(t *testing.T, pairs []Pair, prices map[string]string) *Rates {
+	t.Helper()
+	r := NewRates()
+	r.SetPairs(pairs)
+	for key, price := range prices {
+		pair, err := ParsePair(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Update(UpdateMsg{Type: InitUpd, Quote: Quote{ID: pair, Price: price}})
+	}
+	return r
+}
+
+// pairKeys returns keys of pairs
+func pairKeys(pairs []Pair) []string {
+	keys := []string{}
+	for _, p := range pairs {
+		keys = append(keys, p.Key())
+	}
+	return keys
+}
+
+func TestRatesConvert(t *testing.T) {
+	r := newTestRates(t, nil, map[string]string{
+		"HITBTC:ETH/BTC": "0.02",
+		"HITBTC:BTC/USD": "7000",
+		"HITBTC:BTC/EUR": "6000",
+	})
+
+	tests := []struct {
+		amount   string
+		from, to string
+		want     string
+	}{
+		{"2", "ETH", "USD", "280.00"},
+		{"0.5", "BTC", "BTC", "0.5"},
+		// rates dividing by prices are rounded to 18 places
+		{"140", "USD", "ETH", "1.000000000000000020"},
+		{"1", "USD", "EUR", "0.857142857142857143"},
+		{"1000", "EUR", "ETH", "8.333333333333333000"},
+	}
+	for _, test := range tests {
+		got, ok := r.Convert(dec(t, test.amount), test.from, test.to)
+		if !ok || got.String() != test.want {
+			t.Errorf("Convert(%v %v to %v) = %v, %v, want %v", test.amount, test.from, test.to, got, ok, test.want)
+		}
+	}
+
+	if got, ok := r.Convert(dec(t, "1"), "ETH", "JPY"); ok {
+		t.Errorf("converted ETH to JPY without path: %v", got)
+	}
+}
+
+func TestRatesShortestPath(t *testing.T) {
+	// ETH reaches USD directly, through BTC and through BTC and EUR
+	r := newTestRates(t, nil, map[string]string{
+		"HITBTC:ETH/BTC": "0.02",
+		"HITBTC:BTC/EUR": "6000",
+		"HITBTC:EUR/USD": "1.2",
+		"HITBTC:BTC/USD": "7000",
+	})
+
+	// path through BTC/USD is shorter than through EUR until ETH/USD is priced
+	if rate, ok := r.Rate("ETH", "USD"); !ok || rate.Cmp(dec(t, "140")) != 0 {
+		t.Errorf("got rate %v, want 140 through BTC/USD", rate)
+	}
+	r.Update(UpdateMsg{Type: TradeUpd, Quote: Quote{ID: NewMarketPair(HitBTC, "ETH", "USD", Spot), Price: "150"}})
+	if rate, ok := r.Rate("ETH", "USD"); !ok || rate.Cmp(dec(t, "150")) != 0 {
+		t.Errorf("got rate %v, want 150 from ETH/USD", rate)
+	}
+
+	path, ok := r.Path("BTC", "USD")
+	if keys := pairKeys(path); !ok || len(keys) != 1 || keys[0] != "HITBTC:BTC/USD" {
+		t.Errorf("got path %v, want BTC/USD", keys)
+	}
+}
+
+func TestRatesNoPath(t *testing.T) {
+	r := newTestRates(t, []Pair{
+		NewMarketPair(HitBTC, "ETH", "BTC", Spot),
+		NewMarketPair(HitBTC, "XRP", "JPY", Spot),
+	}, map[string]string{"HITBTC:BTC/USD": "7000"})
+
+	if _, ok := r.Rate("XRP", "USD"); ok {
+		t.Error("got rate of XRP in USD without path")
+	}
+	if _, ok := r.Path("XRP", "USD"); ok {
+		t.Error("got path from XRP to USD")
+	}
+	// ETH/BTC is linked but not priced
+	if _, ok := r.Rate("ETH", "USD"); ok {
+		t.Error("got rate of ETH in USD through unpriced pair")
+	}
+}
+
+func TestRatesMissing(t *testing.T) {
+	r := newTestRates(t, []Pair{
+		NewMarketPair(HitBTC, "ETH", "BTC", Spot),
+		NewMarketPair(HitBTC, "LTC", "BTC", Spot),
+		NewMarketPair(HitBTC, "BTC", "USD", Spot),
+		NewMarketPair(HitBTC, "XRP", "JPY", Spot),
+	}, map[string]string{"HITBTC:LTC/BTC": "0.005"})
+
+	missing := pairKeys(r.Missing([]string{"ETH", "LTC", "XRP", "USD"}, "USD"))
+	want := []string{"HITBTC:ETH/BTC", "HITBTC:BTC/USD"}
+	if len(missing) != len(want) || missing[0] != want[0] || missing[1] != want[1] {
+		t.Errorf("got missing %v, want %v", missing, want)
+	}
+
+	r.Update(UpdateMsg{Type: InitUpd, Quote: Quote{ID: NewMarketPair(HitBTC, "ETH", "BTC", Spot), Price: "0.02"}})
+	r.Update(UpdateMsg{Type: InitUpd, Quote: Quote{ID: NewMarketPair(HitBTC, "BTC", "USD", Spot), Price: "7000"}})
+	if missing := r.Missing([]string{"ETH", "LTC"}, "USD"); len(missing) != 0 {
+		t.Errorf("got missing %v after pricing path", pairKeys(missing))
+	}
+}
+
+func TestRatesConverts(t *testing.T) {
+	r := newTestRates(t, nil, map[string]string{
+		"HITBTC:ETH/BTC": "0.02",
+		"HITBTC:BTC/USD": "7000",
+		"HITBTC:BTC/EUR": "6000",
+		"HITBTC:EUR/USD": "1.2",
+		"HITBTC:XRP/JPY": "30",
+	})
+	currencies := []string{"ETH", "BTC"}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"HITBTC:ETH/BTC", true},
+		{"HITBTC:BTC/USD", true},
+		// longer path through EUR is not used
+		{"HITBTC:BTC/EUR", false},
+		{"HITBTC:EUR/USD", false},
+		{"HITBTC:XRP/JPY", false},
+		// unpriced pair
+		{"HITBTC:LTC/BTC", false},
+	}
+	for _, test := range tests {
+		pair, err := ParsePair(test.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Converts(pair, currencies, "USD"); got != test.want {
+			t.Errorf("Converts(%v) = %v, want %v", test.key, got, test.want)
+		}
+	}
+}
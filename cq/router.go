@@ -15,6 +15,8 @@ type Router struct {
 	sync.RWMutex
 
 	// list provides the necessary channels for each watchlist pair to be routed
+	// Pairs include exchange so the same currencies on two exchanges are
+	// routed separately
 	list map[Pair]chans
 
 	// inbound/outbound channels carry new price Quotes
@@ -41,10 +43,6 @@ func StartRouter(pairs []Pair) *Router {
 	}
 
 	for _, p := range pairs {
-		r.list[p] = chans{
-			update:   make(chan UpdateMsg, queueSize),
-			shutdown: make(chan struct{}),
-		}
 		r.AddPair(p)
 	}
 
@@ -56,8 +54,11 @@ func StartRouter(pairs []Pair) *Router {
 				r.stopAll()
 				break EventLoop
 			case msg := <-r.quoteIn:
-				ch := r.findChan(msg.Quote.ID)
-				ch <- msg
+				// drop updates for pairs that are not routed
+				ch, ok := r.findChan(msg.Quote.ID)
+				if ok {
+					ch <- msg
+				}
 			}
 		}
 	}()
@@ -65,10 +66,14 @@ func StartRouter(pairs []Pair) *Router {
 	return r
 }
 
+// AddPair launches goroutine to route updates for pair
 func (r *Router) AddPair(pair Pair) {
 	r.Lock()
 	defer r.Unlock()
 
+	if _, ok := r.list[pair]; ok {
+		return
+	}
 	r.list[pair] = chans{
 		update:   make(chan UpdateMsg, queueSize),
 		shutdown: make(chan struct{}),
@@ -112,11 +117,16 @@ func (r *Router) AddPair(pair Pair) {
 	}(pair, r.list[pair])
 }
 
+// RemovePair stops routing updates for pair
 func (r *Router) RemovePair(pair Pair) {
 	r.Lock()
 	defer r.Unlock()
 
-	r.list[pair].shutdown <- struct{}{}
+	ch, ok := r.list[pair]
+	if !ok {
+		return
+	}
+	ch.shutdown <- struct{}{}
 	delete(r.list, pair)
 }
 
@@ -135,11 +145,13 @@ func (r *Router) GetQuoteOut() <-chan UpdateMsg {
 }
 
 // FindChan returns appropriate channel for timer group
-func (r *Router) findChan(p Pair) chan UpdateMsg {
+// Returns false if pair is not routed
+func (r *Router) findChan(p Pair) (chan UpdateMsg, bool) {
 	r.RLock()
 	defer r.RUnlock()
 
-	return r.list[p].update
+	ch, ok := r.list[p]
+	return ch.update, ok
 }
 
 // Shutdown stops main event loop as well as  individual pair loops
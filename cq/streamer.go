@@ -0,0 +1,66 @@
+package cq
+
+import "errors"
+
+// ErrNotSupported is returned by Streamer and MarketData methods that an
+// exchange's api does not provide
+var ErrNotSupported = errors.New("not supported by exchange")
+
+// StreamChans holds channels that carry streaming data to main event loop
+type StreamChans struct {
+	// Quotes carries ticker and trade updates to Router
+	Quotes chan<- UpdateMsg
+	// Candles carries candle snapshots and updates to Chart
+	Candles chan<- CandleUpdMsg
+	// Trades carries trades to HistoryRouter
+	Trades chan<- Trade
+	// Book carries order book levels to BookRouter
+	Book chan<- BookUpdMsg
+}
+
+// Streamer defines methods to stream data from an exchange's websocket api
+type Streamer interface {
+	// Stream launches event loop which sends data to chans and subscribes to
+	// quotes for pairs
+	Stream(chans StreamChans, pairs ...Pair) error
+	// SubQuotes subscribes to tickers and trades used by Watchlist
+	SubQuotes(...Pair) error
+	// UnsubQuotes unsubscribes from tickers and trades
+	UnsubQuotes(...Pair) error
+	// SubTrades subscribes to trades only
+	SubTrades(...Pair) error
+	// UnsubTrades unsubscribes from trades
+	UnsubTrades(...Pair) error
+	// SubCandles subscribes to candles with interval in minutes
+	SubCandles(pair Pair, interval int, maxBars int) error
+	// UnsubCandles unsubscribes from candles
+	UnsubCandles(pair Pair, interval int, maxBars int) error
+	// SubOrderBook subscribes to L2 order book
+	// Returns ErrNotSupported if exchange does not stream order books
+	SubOrderBook(Pair) error
+	// UnsubOrderBook unsubscribes from order book
+	UnsubOrderBook(Pair) error
+	// Status returns channel that receives connection state changes
+	Status() <-chan ConnStatusMsg
+	// Errors returns channel that receives errors that do not stop streaming
+	Errors() <-chan error
+	// Shutdown stops event loop and closes connection
+	Shutdown() error
+}
+
+// MarketData defines methods to query an exchange's REST api
+type MarketData interface {
+	// GetPairs returns all pairs traded on exchange
+	GetPairs() ([]Pair, error)
+	// GetInstruments returns trading rules of all pairs traded on exchange
+	GetInstruments() ([]Instrument, error)
+	// GetQuotes returns current quotes for pairs
+	GetQuotes(...Pair) ([]Quote, error)
+	// GetTrades returns most recent trades for pair, newest first
+	GetTrades(Pair) ([]Trade, error)
+	// GetOlderTrades returns up to limit trades older than before, newest
+	// first
+	GetOlderTrades(pair Pair, before Trade, limit int) ([]Trade, error)
+	// GetCandles returns most recent candles with interval in minutes
+	GetCandles(pair Pair, interval int, limit int) ([]CandleData, error)
+}
@@ -0,0 +1,75 @@
+package cq
+
+import (
+	"fmt"
+)
+
+const (
+	// BaseUnit measures trades by size in base currency
+	BaseUnit SizeUnit = iota
+	// NotionalUnit measures trades by value in quote currency
+	NotionalUnit
+)
+
+// SizeUnit sets how TradeFilter measures trades
+type SizeUnit int
+
+// String returns name of unit
+func (u SizeUnit) String() string {
+	if u == NotionalUnit {
+		return "notional"
+	}
+	return "base"
+}
+
+// MarshalText encodes unit by name
+func (u SizeUnit) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText decodes unit from name
+func (u *SizeUnit) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "base":
+		*u = BaseUnit
+	case "notional":
+		*u = NotionalUnit
+	default:
+		return fmt.Errorf("invalid size unit: %v", string(b))
+	}
+	return nil
+}
+
+// TradeFilter hides small trades and marks large ("whale") trades in History
+// Zero thresholds are disabled
+type TradeFilter struct {
+	Unit SizeUnit `json:"unit"`
+	// MinSize hides trades below it
+	MinSize Decimal `json:"minSize"`
+	// Whale marks trades at or above it
+	Whale Decimal `json:"whale"`
+}
+
+// IsZero reports whether filter has base unit and no thresholds
+func (f TradeFilter) IsZero() bool {
+	return f.Unit == BaseUnit && f.MinSize.IsZero() && f.Whale.IsZero()
+}
+
+// value returns size of trade in filter's unit
+func (f TradeFilter) value(t Trade) Decimal {
+	size := t.SizeDecimal()
+	if f.Unit == NotionalUnit {
+		return size.Mul(t.PriceDecimal())
+	}
+	return size
+}
+
+// Hidden reports whether trade is below minimum size
+func (f TradeFilter) Hidden(t Trade) bool {
+	return f.MinSize.Sign() > 0 && f.value(t).Cmp(f.MinSize) < 0
+}
+
+// IsWhale reports whether trade is at or above whale threshold
+func (f TradeFilter) IsWhale(t Trade) bool {
+	return f.Whale.Sign() > 0 && f.value(t).Cmp(f.Whale) >= 0
+}
@@ -0,0 +1,58 @@
+package cq
+
+// tradeRing is a fixed capacity buffer of trades ordered oldest to newest.
+// Pushing to a full ring overwrites its oldest trade.
+type tradeRing struct {
+	buf   []Trade
+	start int
+	n     int
+}
+
+// newTradeRing returns a ring that holds up to size trades
+func newTradeRing(size int) *tradeRing {
+	if size < 0 {
+		size = 0
+	}
+	return &tradeRing{buf: make([]Trade, size)}
+}
+
+// len returns number of trades in ring
+func (r *tradeRing) len() int {
+	return r.n
+}
+
+// capacity returns number of trades ring holds when full
+func (r *tradeRing) capacity() int {
+	return len(r.buf)
+}
+
+// push adds trade at newest end
+func (r *tradeRing) push(t Trade) {
+	if len(r.buf) == 0 {
+		return
+	}
+	if r.n == len(r.buf) {
+		r.buf[r.start] = t
+		r.start = (r.start + 1) % len(r.buf)
+		return
+	}
+	r.buf[(r.start+r.n)%len(r.buf)] = t
+	r.n++
+}
+
+// pop removes and returns newest trade
+func (r *tradeRing) pop() (Trade, bool) {
+	t, ok := r.peek()
+	if ok {
+		r.n--
+	}
+	return t, ok
+}
+
+// peek returns newest trade without removing it
+func (r *tradeRing) peek() (Trade, bool) {
+	if r.n == 0 {
+		return Trade{}, false
+	}
+	return r.buf[(r.start+r.n-1)%len(r.buf)], true
+}
@@ -1,5 +1,7 @@
 package cq
 
+import "fmt"
+
 const (
 	// InitUpd denotes an UpdateMsg that originates from a rest api call
 	InitUpd UpdateType = iota + 1
@@ -36,6 +38,7 @@ type CandleUpdType int
 // CandleUpdMsg carries data to update price chart
 type CandleUpdMsg struct {
 	Type CandleUpdType
+	Pair Pair
 
 	// CandleSnapshot will contain multiple bars but CandleUpd will only
 	// contain a single bar
@@ -58,3 +61,61 @@ type HistoryUpdMsg struct {
 	Type  HistoryUpdType
 	Trade Trade
 }
+
+const (
+	// Connecting is set while first connection to websocket api is made
+	Connecting ConnState = iota + 1
+	// Live means streaming data is being received
+	Live
+	// Stale means connection is open but no data has been received recently
+	Stale
+	// Reconnecting is set after connection drops until it is restored
+	Reconnecting
+	// Disconnected is set after streaming is shut down
+	Disconnected
+)
+
+// ConnState describes state of websocket connection to an exchange
+type ConnState int
+
+// String returns connection state as lower case text for display
+func (s ConnState) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Live:
+		return "live"
+	case Stale:
+		return "stale"
+	case Reconnecting:
+		return "reconnecting"
+	case Disconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+// ConnStatusMsg carries connection state changes from websocket controllers
+// to main event loop
+type ConnStatusMsg struct {
+	ExchangeID ExchangeID
+	State      ConnState
+	// Err holds the error that caused connection to drop if any
+	Err error
+}
+
+// String returns status message formatted for display
+func (m ConnStatusMsg) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%v: %v (%v)", m.ExchangeID, m.State, m.Err)
+	}
+	return fmt.Sprintf("%v: %v", m.ExchangeID, m.State)
+}
+
+// BookUpdMsg carries top levels of order book to main event loop
+// Bids are ordered from highest price and asks from lowest price
+type BookUpdMsg struct {
+	Pair Pair
+	Bids []BookLevel
+	Asks []BookLevel
+}
@@ -1,6 +1,8 @@
 package cq
 
 import (
+	"fmt"
+
 	"fyne.io/fyne"
 
 	fl "github.com/3cb/fyne-list"
@@ -11,44 +13,184 @@ import (
 type Watchlist struct {
 	*fl.List
 
+	// Index holds row of each pair
+	// Pairs include exchange so a watchlist can list several exchanges
 	Index  map[Pair]int
 	Quotes []Quote
+
+	// OnSelected is called with row's pair when row is tapped
+	OnSelected func(Pair)
+	// OnRemoved is called with row's pair when row's remove action is tapped
+	OnRemoved func(Pair)
+	selected  Pair
+
+	// ref is the reference currency of optional price and volume columns
+	ref   string
+	rates *Rates
+	// instruments sets precision of each pair's row
+	instruments map[Pair]Instrument
 }
 
 // NewWatchlist creates a new instance of a Watchlist
 func NewWatchlist(pairs ...Pair) *Watchlist {
+	w := &Watchlist{
+		Index:  map[Pair]int{},
+		Quotes: []Quote{},
+	}
+	for i, p := range pairs {
+		w.Index[p] = i
+		w.Quotes = append(w.Quotes, Quote{
+			ExchangeID: p.Exchange(),
+			ID:         p,
+		})
+	}
+	w.build()
+	return w
+}
+
+// build creates list with a row for each quote
+func (w *Watchlist) build() {
 	// set column headers
-	headers := []string{"Symbol", "Price", "Change"}
+	headers := []string{"Exchange", "Symbol", "Price", "Change"}
+	if w.ref != "" {
+		headers = append(headers, fmt.Sprintf("Price(%v)", w.ref), fmt.Sprintf("Vol(%v)", w.ref))
+	}
 	headerRow := fl.NewHeader(white, headers...)
 
-	index := map[Pair]int{}
-	quotes := []Quote{}
 	objects := []fyne.CanvasObject{}
-	for i, p := range pairs {
-		q := Quote{
-			ID: p,
+	for _, q := range w.Quotes {
+		row := w.newRow(q)
+		if q.Price != "" {
+			row.update(q, InitUpd)
 		}
-		index[p] = i
-		quotes = append(quotes, q)
-		objects = append(objects, newWatchlistRow(q))
+		objects = append(objects, row)
+	}
+	w.List = fl.NewListWithScroller(headerRow, objects...)
+}
+
+// newRow returns row for quote with reference columns if they are shown
+func (w *Watchlist) newRow(q Quote) *watchlistRow {
+	row := newWatchlistRow(q, w.tapped, w.removed)
+	if i, ok := w.instruments[q.ID]; ok {
+		row.instrument = i
 	}
-	list := fl.NewListWithScroller(headerRow, objects...)
-	return &Watchlist{
-		List:   list,
-		Index:  index,
-		Quotes: quotes,
+	if w.ref != "" {
+		row.convert = w.convert
 	}
+	return row
+}
+
+// SetInstruments sets trading rules used to format each pair's quote
+func (w *Watchlist) SetInstruments(instruments map[Pair]Instrument) {
+	w.instruments = map[Pair]Instrument{}
+	for p, i := range instruments {
+		w.instruments[p] = i
+	}
+	for i, q := range w.Quotes {
+		row := w.List.GetRow(i).(*watchlistRow)
+		row.instrument = w.instruments[q.ID]
+		if q.Price != "" {
+			row.update(q, InitUpd)
+		}
+	}
+}
+
+// SetInstrument sets trading rules used to format quote of instrument's pair
+// Used for pairs of other exchanges than the one that owns watchlist
+func (w *Watchlist) SetInstrument(instrument Instrument) {
+	if w.instruments == nil {
+		w.instruments = map[Pair]Instrument{}
+	}
+	w.instruments[instrument.Pair] = instrument
+
+	i, ok := w.Index[instrument.Pair]
+	if !ok {
+		return
+	}
+	row := w.List.GetRow(i).(*watchlistRow)
+	row.instrument = instrument
+	if q := w.Quotes[i]; q.Price != "" {
+		row.update(q, InitUpd)
+	}
+}
+
+// SetReference shows price and volume of each pair converted to reference
+// currency with rates
+// Empty reference hides columns.  List is rebuilt so containers holding
+// Watchlist must be laid out again.
+func (w *Watchlist) SetReference(ref string, rates *Rates) {
+	w.ref, w.rates = ref, rates
+	w.build()
+	w.SetSelected(w.selected)
+}
+
+// RefreshReference updates reference columns after rates change
+func (w *Watchlist) RefreshReference() {
+	if w.ref == "" {
+		return
+	}
+	for i, q := range w.Quotes {
+		w.List.GetRow(i).(*watchlistRow).setReference(q)
+	}
+}
+
+// convert returns price and volume of raw quote in reference currency
+// Values that can't be converted are shown as "-"
+func (w *Watchlist) convert(q Quote) (string, string) {
+	price, vol := "-", "-"
+	if p, err := ParseDecimal(q.Price); err == nil {
+		if v, ok := w.rates.Convert(p, q.ID.QuoteCurrency(), w.ref); ok {
+			price = fmtValue(v)
+		}
+	}
+	if p, err := ParseDecimal(q.Volume); err == nil {
+		if v, ok := w.rates.Convert(p, q.ID.BaseCurrency(), w.ref); ok {
+			vol = FmtVolume(v.String())
+		}
+	}
+	return price, vol
 }
 
 // AddQuote appends new quote to end of watchlist.
 func (w *Watchlist) AddQuote(q Quote) {
+	if q.ExchangeID == 0 {
+		q.ExchangeID = q.ID.Exchange()
+	}
 	w.Quotes = append(w.Quotes, q)
-	w.Index[q.ID] = w.List.Append(newWatchlistRow(q))
+	w.Index[q.ID] = w.List.Append(w.newRow(q))
+}
+
+// SetSelected marks row for pair as selected and clears previous selection
+func (w *Watchlist) SetSelected(p Pair) {
+	if i, ok := w.Index[w.selected]; ok {
+		w.List.GetRow(i).(*watchlistRow).setSelected(false)
+	}
+	w.selected = p
+	if i, ok := w.Index[p]; ok {
+		w.List.GetRow(i).(*watchlistRow).setSelected(true)
+	}
+}
+
+// removed passes pair of row to OnRemoved
+func (w *Watchlist) removed(p Pair) {
+	if w.OnRemoved != nil {
+		w.OnRemoved(p)
+	}
+}
+
+// tapped passes pair of tapped row to OnSelected
+func (w *Watchlist) tapped(p Pair) {
+	if w.OnSelected != nil {
+		w.OnSelected(p)
+	}
 }
 
 // UpdateQuote finds the appropriate quote and updates the price
 // It will also Highlight the quote row if required
 func (w *Watchlist) UpdateQuote(q Quote, u UpdateType) {
+	if q.ExchangeID == 0 {
+		q.ExchangeID = q.ID.Exchange()
+	}
 	i := w.Index[q.ID]
 	w.Quotes[i] = q
 
@@ -71,5 +213,8 @@ func (w *Watchlist) RemoveQuote(q Quote) {
 
 // MinSize returns the minimum allowable size of this widget
 func (w *Watchlist) MinSize() fyne.Size {
-	return fyne.NewSize(245, 100)
+	if w.ref != "" {
+		return fyne.NewSize(490, 100)
+	}
+	return fyne.NewSize(325, 100)
 }
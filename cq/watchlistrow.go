@@ -15,17 +15,70 @@ type watchlistRow struct {
 	widget.BaseWidget
 
 	isHighlighted bool
+	isSelected    bool
 	quote         Quote
+	instrument    Instrument
 	textColor     color.Color
 	bgColor       color.Color
+
+	onTapped func(Pair)
+	onRemove func(Pair)
+
+	// convert returns price and volume in reference currency
+	// Reference columns are hidden if it is nil
+	convert  func(Quote) (string, string)
+	refPrice string
+	refVol   string
+}
+
+// removeText is shown in margin on right side of row
+// Tapping it removes row's pair from watchlist
+const removeText = "  x  "
+
+func newWatchlistRow(q Quote, onTapped func(Pair), onRemove func(Pair)) *watchlistRow {
+	return &watchlistRow{
+		quote:     q,
+		textColor: setColor(q.PriceChange),
+		bgColor:   theme.BackgroundColor(),
+		onTapped:  onTapped,
+		onRemove:  onRemove,
+	}
 }
 
-func newWatchlistRow(q Quote) *watchlistRow {
-	return &watchlistRow{widget.BaseWidget{}, false, q, setColor(q.PriceChange), theme.BackgroundColor()}
+// Tapped removes row's pair if margin was tapped and selects it otherwise
+func (r *watchlistRow) Tapped(ev *fyne.PointEvent) {
+	marginWidth := canvas.NewText(removeText, r.textColor).MinSize().Width
+	if ev.Position.X >= r.Size().Width-marginWidth {
+		if r.onRemove != nil {
+			r.onRemove(r.quote.ID)
+		}
+		return
+	}
+	if r.onTapped != nil {
+		r.onTapped(r.quote.ID)
+	}
+}
+
+// TappedSecondary is required by fyne.Tappable
+func (r *watchlistRow) TappedSecondary(*fyne.PointEvent) {}
+
+// setSelected shows row's symbol in bold while pair is selected
+func (r *watchlistRow) setSelected(selected bool) {
+	r.isSelected = selected
+	r.Refresh()
+}
+
+// setReference updates reference columns from raw quote
+func (r *watchlistRow) setReference(q Quote) {
+	r.refPrice, r.refVol = r.convert(q)
+	r.Refresh()
 }
 
 func (r *watchlistRow) update(q Quote, u UpdateType) {
-	q = FmtQuote(q)
+	if r.convert != nil {
+		r.refPrice, r.refVol = r.convert(q)
+	}
+	q = r.instrument.FmtQuote(q)
 	r.quote = q
 	color := setColor(q.PriceChange)
 	switch u {
@@ -54,60 +107,94 @@ func (r *watchlistRow) update(q Quote, u UpdateType) {
 
 func (r *watchlistRow) CreateRenderer() fyne.WidgetRenderer {
 	r.ExtendBaseWidget(r)
+	exchange := canvas.NewText(r.quote.ExchangeID.String(), r.textColor)
+	exchange.Alignment = fyne.TextAlignTrailing
 	symbol := canvas.NewText(r.quote.ID.String(), r.textColor)
 	symbol.Alignment = fyne.TextAlignTrailing
+	symbol.TextStyle = fyne.TextStyle{Bold: r.isSelected}
 
 	price := canvas.NewText(r.quote.Price, r.textColor)
 	price.Alignment = fyne.TextAlignTrailing
 	change := canvas.NewText(fmt.Sprintf("%v%%", r.quote.ChangePerc), r.textColor)
 	change.Alignment = fyne.TextAlignTrailing
 
-	// add 5 space margin on right side
-	margin := canvas.NewText("     ", r.textColor)
+	refPrice := canvas.NewText(r.refPrice, r.textColor)
+	refPrice.Alignment = fyne.TextAlignTrailing
+	refVol := canvas.NewText(r.refVol, r.textColor)
+	refVol.Alignment = fyne.TextAlignTrailing
+
+	// add 5 space margin on right side with remove action
+	margin := canvas.NewText(removeText, r.textColor)
 	margin.Alignment = fyne.TextAlignTrailing
 	bg := canvas.NewRectangle(r.bgColor)
-	objects := []fyne.CanvasObject{bg, symbol, price, change, margin}
-	return &watchlistRowRenderer{bg: bg, symbol: symbol, price: price, change: change, margin: margin, objects: objects, row: r}
+	objects := []fyne.CanvasObject{bg, exchange, symbol, price, change, margin}
+	if r.convert != nil {
+		objects = append(objects, refPrice, refVol)
+	}
+	return &watchlistRowRenderer{bg: bg, exchange: exchange, symbol: symbol, price: price, change: change, refPrice: refPrice, refVol: refVol, margin: margin, objects: objects, row: r}
 }
 
 type watchlistRowRenderer struct {
-	symbol, price, change, margin *canvas.Text
-	bg                            *canvas.Rectangle
+	exchange, symbol, price, change *canvas.Text
+	refPrice, refVol, margin        *canvas.Text
+	bg                              *canvas.Rectangle
 
 	objects []fyne.CanvasObject
 	row     *watchlistRow
 }
 
+// columns returns number of data columns shown in row
+func (r *watchlistRowRenderer) columns() int {
+	if r.row.convert != nil {
+		return 6
+	}
+	return 4
+}
+
 func (r *watchlistRowRenderer) MinSize() fyne.Size {
+	exchangeMin := r.exchange.MinSize()
 	symbolMin := r.symbol.MinSize()
 	priceMin := r.price.MinSize()
 	changeMin := r.change.MinSize()
 	marginMin := r.margin.MinSize()
-	mins := []int{symbolMin.Width, priceMin.Width, changeMin.Width, marginMin.Width}
+	mins := []int{exchangeMin.Width, symbolMin.Width, priceMin.Width, changeMin.Width, marginMin.Width}
+	if r.row.convert != nil {
+		mins = append(mins, r.refPrice.MinSize().Width, r.refVol.MinSize().Width)
+	}
 	sort.Ints(mins)
 
-	return fyne.NewSize(3*(mins[len(mins)-1])+marginMin.Width, symbolMin.Height)
+	return fyne.NewSize(r.columns()*(mins[len(mins)-1])+marginMin.Width, symbolMin.Height)
 }
 
 func (r *watchlistRowRenderer) Layout(size fyne.Size) {
+	columns := r.columns()
 	marginWidth := r.margin.MinSize().Width
-	columnWidth := (size.Width - marginWidth) / 3
+	columnWidth := (size.Width - marginWidth) / columns
 	columnHeight := size.Height
-	columnSize := fyne.NewSize((size.Width-marginWidth)/3, size.Height)
+	columnSize := fyne.NewSize((size.Width-marginWidth)/columns, size.Height)
 
 	r.bg.Move(fyne.NewPos(0, 0))
 	r.bg.Resize(size)
 
-	r.symbol.Move(fyne.NewPos(0, 0))
+	r.exchange.Move(fyne.NewPos(0, 0))
+	r.exchange.Resize(columnSize)
+
+	r.symbol.Move(fyne.NewPos(columnWidth, 0))
 	r.symbol.Resize(columnSize)
 
-	r.price.Move(fyne.NewPos(columnWidth, 0))
+	r.price.Move(fyne.NewPos(columnWidth*2, 0))
 	r.price.Resize(columnSize)
 
-	r.change.Move(fyne.NewPos(columnWidth*2, 0))
+	r.change.Move(fyne.NewPos(columnWidth*3, 0))
 	r.change.Resize(columnSize)
 
-	r.margin.Move(fyne.NewPos(columnWidth*3, 0))
+	r.refPrice.Move(fyne.NewPos(columnWidth*4, 0))
+	r.refPrice.Resize(columnSize)
+
+	r.refVol.Move(fyne.NewPos(columnWidth*5, 0))
+	r.refVol.Resize(columnSize)
+
+	r.margin.Move(fyne.NewPos(columnWidth*columns, 0))
 	r.margin.Resize(fyne.NewSize(marginWidth, columnHeight))
 }
 
@@ -122,8 +209,12 @@ func (r *watchlistRowRenderer) Objects() []fyne.CanvasObject {
 func (r *watchlistRowRenderer) Refresh() {
 	r.bg.FillColor = r.row.bgColor
 
+	r.exchange.Text = r.row.quote.ExchangeID.String()
+	r.exchange.Color = r.row.textColor
+
 	r.symbol.Text = r.row.quote.ID.String()
 	r.symbol.Color = r.row.textColor
+	r.symbol.TextStyle = fyne.TextStyle{Bold: r.row.isSelected}
 
 	r.price.Text = r.row.quote.Price
 	r.price.Color = r.row.textColor
@@ -131,11 +222,23 @@ func (r *watchlistRowRenderer) Refresh() {
 	r.change.Text = fmt.Sprintf("%v%%", r.row.quote.ChangePerc)
 	r.change.Color = r.row.textColor
 
+	r.refPrice.Text = r.row.refPrice
+	r.refPrice.Color = r.row.textColor
+
+	r.refVol.Text = r.row.refVol
+	r.refVol.Color = r.row.textColor
+
+	r.margin.Color = r.row.textColor
+
 	r.Layout(r.row.Size())
 	r.bg.Refresh()
+	r.exchange.Refresh()
 	r.symbol.Refresh()
 	r.price.Refresh()
 	r.change.Refresh()
+	r.refPrice.Refresh()
+	r.refVol.Refresh()
+	r.margin.Refresh()
 }
 
 func (r *watchlistRowRenderer) Destroy() {}
@@ -0,0 +1,509 @@
+package cq
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// defaultStaleAfter is used when WSConfig.StaleAfter is not set
+	defaultStaleAfter = 15 * time.Second
+	// defaultResponseTimeout is used when WSConfig.ResponseTimeout is not set
+	defaultResponseTimeout = 10 * time.Second
+	// wsReadTimeout is the time without messages before connection is dropped
+	// and reconnected
+	wsReadTimeout = 60 * time.Second
+	// wsWriteTimeout limits time spent writing a single message
+	wsWriteTimeout = 10 * time.Second
+)
+
+// WSConfig configures connection of WSClient to an exchange's websocket api
+type WSConfig struct {
+	// Exchange is reported in status messages
+	Exchange ExchangeID
+	// API is the url of websocket api
+	API string
+	// StaleAfter is the time without messages before connection is marked
+	// Stale
+	StaleAfter time.Duration
+	// ResponseTimeout is the time to wait for reply to request
+	ResponseTimeout time.Duration
+}
+
+// WSHandler encodes requests and decodes messages of an exchange's websocket
+// api for WSClient
+// All methods except Decode are called from event loop of WSClient
+type WSHandler interface {
+	// Decode parses message read from websocket
+	// Messages that return error are reported and skipped
+	Decode(b []byte) (interface{}, error)
+	// Handle handles decoded message
+	// Returning error drops connection so it is reconnected
+	Handle(msg interface{}) error
+	// Send writes request with WSClient.Write and adds it to pending requests
+	// with WSClient.Pend or answers it with WSRequest.Reply
+	// Returned error is replied to request
+	Send(req WSRequest) error
+	// Resubscribe replays active subscriptions on new connection followed by
+	// requests made while disconnected
+	// Returns requests that were not sent if writing fails
+	Resubscribe(queued []WSRequest) ([]WSRequest, error)
+	// Disconnected drops state that belongs to lost connection
+	Disconnected()
+}
+
+// WSRequest is a request passed to event loop of WSClient
+// Requests without reply replay subscriptions after reconnect
+type WSRequest struct {
+	Msg   interface{}
+	reply chan error
+}
+
+// Reply answers caller waiting for request
+func (r WSRequest) Reply(err error) {
+	if r.reply != nil {
+		r.reply <- err
+	}
+}
+
+// Replayed returns true if request replays subscription and no caller is
+// waiting for it
+func (r WSRequest) Replayed() bool {
+	return r.reply == nil
+}
+
+// wsPending is a request that has been written to websocket and is waiting
+// for response
+type wsPending struct {
+	key  interface{}
+	desc string
+	req  WSRequest
+	sent time.Time
+}
+
+// WSClient owns a websocket connection to an exchange
+// Its event loop reconnects dropped connections with backoff, replays
+// subscriptions with WSHandler and matches responses to pending requests
+type WSClient struct {
+	sync.RWMutex
+	conn *websocket.Conn
+
+	cfg        WSConfig
+	h          WSHandler
+	streaming  bool
+	reqCh      chan WSRequest
+	statusCh   chan ConnStatusMsg
+	errCh      chan error
+	shutdownCh chan chan struct{}
+	// stopped is closed when event loop exits
+	stopped chan struct{}
+
+	// pending is only accessed by event loop and kept in order requests were
+	// sent
+	pending []wsPending
+	// backoff is only accessed by event loop
+	// Delay grows with each failed reconnect attempt until subscriptions are
+	// replayed
+	backoff Backoff
+}
+
+// ConnectWS returns client that is connected to websocket at cfg.API
+// Messages are encoded and decoded by h
+func ConnectWS(cfg WSConfig, h WSHandler) (*WSClient, error) {
+	if cfg.StaleAfter == 0 {
+		cfg.StaleAfter = defaultStaleAfter
+	}
+	if cfg.ResponseTimeout == 0 {
+		cfg.ResponseTimeout = defaultResponseTimeout
+	}
+
+	conn, err := dialWS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &WSClient{
+		conn:       conn,
+		cfg:        cfg,
+		h:          h,
+		reqCh:      make(chan WSRequest, 5),
+		statusCh:   make(chan ConnStatusMsg, 10),
+		errCh:      make(chan error, 10),
+		shutdownCh: make(chan chan struct{}),
+		stopped:    make(chan struct{}),
+		backoff: Backoff{
+			Min: 500 * time.Millisecond,
+			Max: 30 * time.Second,
+		},
+	}
+	c.setStatus(Connecting, nil)
+
+	return c, nil
+}
+
+// dialWS connects to websocket api
+func dialWS(cfg WSConfig) (*websocket.Conn, error) {
+	conn, resp, err := websocket.DefaultDialer.Dial(cfg.API, nil)
+	if err != nil || resp.StatusCode != 101 {
+		return nil, fmt.Errorf("unable to connect to %v websocket api", strings.ToLower(cfg.Exchange.String()))
+	}
+	return conn, nil
+}
+
+// Status returns channel that receives connection state changes
+func (c *WSClient) Status() <-chan ConnStatusMsg {
+	return c.statusCh
+}
+
+// setStatus sends connection state to status channel without blocking
+func (c *WSClient) setStatus(state ConnState, err error) {
+	select {
+	case c.statusCh <- ConnStatusMsg{
+		ExchangeID: c.cfg.Exchange,
+		State:      state,
+		Err:        err,
+	}:
+	default:
+	}
+}
+
+// Errors returns channel that receives errors from malformed or unexpected
+// websocket messages
+// These errors do not interrupt streaming
+func (c *WSClient) Errors() <-chan error {
+	return c.errCh
+}
+
+// ReportErr sends error to error channel without blocking
+func (c *WSClient) ReportErr(err error) {
+	select {
+	case c.errCh <- err:
+	default:
+	}
+}
+
+// Stream launches event loop
+func (c *WSClient) Stream() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.streaming {
+		return errors.New("websocket is already streaming")
+	}
+	c.streaming = true
+
+	go c.run()
+	return nil
+}
+
+// Request sends msg to event loop to be written to websocket and waits for
+// server's response
+// Returns error from server if request was rejected
+func (c *WSClient) Request(msg interface{}) error {
+	c.RLock()
+	streaming := c.streaming
+	c.RUnlock()
+	if !streaming {
+		return errors.New("websocket is not streaming")
+	}
+
+	req := WSRequest{
+		Msg:   msg,
+		reply: make(chan error, 1),
+	}
+	select {
+	case c.reqCh <- req:
+	case <-c.stopped:
+		return errors.New("websocket is shut down")
+	}
+
+	select {
+	case err := <-req.reply:
+		return err
+	case <-c.stopped:
+		return errors.New("websocket is shut down")
+	}
+}
+
+// Shutdown stops event loop and closes websocket connection
+func (c *WSClient) Shutdown() error {
+	c.Lock()
+	streaming := c.streaming
+	c.streaming = false
+	conn := c.conn
+	c.Unlock()
+
+	if !streaming {
+		return conn.Close()
+	}
+
+	wait := make(chan struct{})
+	select {
+	case c.shutdownCh <- wait:
+		<-wait
+	case <-c.stopped:
+	}
+
+	return nil
+}
+
+// Write writes message to websocket with write deadline set
+// Only called from WSHandler methods
+func (c *WSClient) Write(v interface{}) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(v)
+}
+
+// Pend adds request written to websocket to pending requests
+// key matches response to request and desc describes request in errors
+// Only called from WSHandler methods
+func (c *WSClient) Pend(key interface{}, desc string, req WSRequest) {
+	c.pending = append(c.pending, wsPending{
+		key:  key,
+		desc: desc,
+		req:  req,
+		sent: time.Now(),
+	})
+}
+
+// Pending returns oldest pending request for key
+// Only called from WSHandler methods
+func (c *WSClient) Pending(key interface{}) (WSRequest, bool) {
+	for _, p := range c.pending {
+		if p.key == key {
+			return p.req, true
+		}
+	}
+	return WSRequest{}, false
+}
+
+// Attach replaces oldest pending request for key with req so req is answered
+// by its response
+// Only called from WSHandler methods
+func (c *WSClient) Attach(key interface{}, req WSRequest) bool {
+	for i, p := range c.pending {
+		if p.key == key {
+			c.pending[i].req = req
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve removes and returns oldest pending request for key
+// Caller answers it with WSRequest.Reply
+// Only called from WSHandler methods
+func (c *WSClient) Resolve(key interface{}) (WSRequest, bool) {
+	for i, p := range c.pending {
+		if p.key == key {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return p.req, true
+		}
+	}
+	return WSRequest{}, false
+}
+
+// expirePending fails requests that have not received a response
+// Expired replays are reported on error channel
+func (c *WSClient) expirePending() {
+	pending := c.pending[:0]
+	for _, p := range c.pending {
+		if time.Since(p.sent) < c.cfg.ResponseTimeout {
+			pending = append(pending, p)
+			continue
+		}
+
+		err := fmt.Errorf("%v: no response from server", p.desc)
+		if p.req.Replayed() {
+			c.ReportErr(err)
+			continue
+		}
+		p.req.Reply(err)
+	}
+	c.pending = pending
+}
+
+// failPending fails all pending requests after connection is lost
+func (c *WSClient) failPending(err error) {
+	for _, p := range c.pending {
+		p.req.Reply(fmt.Errorf("connection lost: %v", err))
+	}
+	c.pending = nil
+}
+
+// send passes request to handler and replies with its error
+func (c *WSClient) send(req WSRequest) {
+	err := c.h.Send(req)
+	if err != nil {
+		req.Reply(err)
+	}
+}
+
+// run is the event loop which owns the websocket connection
+// All writes to websocket are made from this goroutine
+func (c *WSClient) run() {
+	defer close(c.stopped)
+
+	c.RLock()
+	conn := c.conn
+	c.RUnlock()
+	msgs, readErr, done := c.readLoop(conn)
+
+	staleCheck := time.NewTicker(c.cfg.StaleAfter / 3)
+	defer staleCheck.Stop()
+	lastMsg := time.Now()
+	state := Connecting
+
+	for {
+		select {
+		case confirmStop := <-c.shutdownCh:
+			close(done)
+			conn.Close()
+			c.setStatus(Disconnected, nil)
+			confirmStop <- struct{}{}
+			return
+		case req := <-c.reqCh:
+			c.send(req)
+		case msg := <-msgs:
+			lastMsg = time.Now()
+			if state != Live {
+				state = Live
+				c.setStatus(state, nil)
+			}
+			if c.h.Handle(msg) != nil {
+				// read error from closed connection triggers reconnect
+				conn.Close()
+			}
+		case <-staleCheck.C:
+			if state == Live && time.Since(lastMsg) > c.cfg.StaleAfter {
+				state = Stale
+				c.setStatus(state, nil)
+			}
+			c.expirePending()
+		case err := <-readErr:
+			close(done)
+			conn.Close()
+			state = Reconnecting
+			c.setStatus(state, err)
+			c.failPending(err)
+			c.h.Disconnected()
+
+			var ok bool
+			conn, ok = c.reconnect()
+			if !ok {
+				return
+			}
+			msgs, readErr, done = c.readLoop(conn)
+			lastMsg = time.Now()
+		}
+	}
+}
+
+// reconnect dials websocket api with exponential backoff until connected and
+// all subscriptions are replayed
+// Backoff is reset once subscriptions are replayed
+// Returns false if shutdown is requested while reconnecting
+func (c *WSClient) reconnect() (*websocket.Conn, bool) {
+	// queued holds requests made while disconnected
+	// They are sent after active subscriptions are replayed and resolved
+	// with server's response.  Requests still queued after response timeout
+	// are failed so callers are not blocked until connection is back.
+	queued := []WSRequest{}
+	queuedAt := map[chan error]time.Time{}
+	expire := time.NewTicker(c.cfg.ResponseTimeout / 3)
+	defer expire.Stop()
+	for {
+		timer := time.NewTimer(c.backoff.Next())
+	Wait:
+		for {
+			select {
+			case confirmStop := <-c.shutdownCh:
+				timer.Stop()
+				c.setStatus(Disconnected, nil)
+				confirmStop <- struct{}{}
+				return nil, false
+			case req := <-c.reqCh:
+				queued = append(queued, req)
+				queuedAt[req.reply] = time.Now()
+			case <-expire.C:
+				queued = c.expireQueued(queued, queuedAt)
+			case <-timer.C:
+				break Wait
+			}
+		}
+
+		queued = c.expireQueued(queued, queuedAt)
+		conn, err := dialWS(c.cfg)
+		if err != nil {
+			c.setStatus(Reconnecting, err)
+			continue
+		}
+		c.Lock()
+		c.conn = conn
+		c.Unlock()
+
+		queued, err = c.h.Resubscribe(queued)
+		if err != nil {
+			c.failPending(err)
+			c.h.Disconnected()
+			conn.Close()
+			c.setStatus(Reconnecting, err)
+			continue
+		}
+
+		c.backoff.Reset()
+		return conn, true
+	}
+}
+
+// expireQueued fails requests made while disconnected that have been queued
+// longer than response timeout and returns the rest
+func (c *WSClient) expireQueued(queued []WSRequest, queuedAt map[chan error]time.Time) []WSRequest {
+	kept := queued[:0]
+	for _, req := range queued {
+		if time.Since(queuedAt[req.reply]) < c.cfg.ResponseTimeout {
+			kept = append(kept, req)
+			continue
+		}
+		delete(queuedAt, req.reply)
+		req.Reply(errors.New("websocket is reconnecting"))
+	}
+	return kept
+}
+
+// readLoop launches goroutine to read messages from connection
+// First read error is sent to error channel and goroutine exits
+// Messages that cannot be decoded are reported and skipped
+// Closing done channel stops goroutine from blocking on send
+func (c *WSClient) readLoop(conn *websocket.Conn) (<-chan interface{}, <-chan error, chan struct{}) {
+	msgs := make(chan interface{})
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+			_, b, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			msg, err := c.h.Decode(b)
+			if err != nil {
+				c.ReportErr(err)
+				continue
+			}
+			select {
+			case msgs <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return msgs, readErr, done
+}
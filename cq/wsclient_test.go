@@ -0,0 +1,165 @@
+package cq
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// silentHandler writes requests and pends them without ever resolving them
+type silentHandler struct {
+	c *WSClient
+}
+
+func (h *silentHandler) Decode(b []byte) (interface{}, error) { return string(b), nil }
+func (h *silentHandler) Handle(msg interface{}) error         { return nil }
+func (h *silentHandler) Disconnected()                        {}
+
+func (h *silentHandler) Send(req WSRequest) error {
+	err := h.c.Write(req.Msg)
+	if err != nil {
+		return err
+	}
+	h.c.Pend(nil, req.Msg.(string), req)
+	return nil
+}
+
+func (h *silentHandler) Resubscribe(queued []WSRequest) ([]WSRequest, error) {
+	return queued, nil
+}
+
+func TestWSClientExpirePending(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	h := &silentHandler{}
+	c, err := ConnectWS(WSConfig{
+		Exchange:        HitBTC,
+		API:             "ws" + strings.TrimPrefix(srv.URL, "http"),
+		StaleAfter:      30 * time.Millisecond,
+		ResponseTimeout: 50 * time.Millisecond,
+	}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.c = c
+	if err := c.Request("subscribe"); err == nil || err.Error() != "websocket is not streaming" {
+		t.Errorf("got error %v before Stream, want not streaming", err)
+	}
+	if err := c.Stream(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Shutdown()
+
+	err = c.Request("subscribe")
+	if err == nil || err.Error() != "subscribe: no response from server" {
+		t.Errorf("got error %v, want no response", err)
+	}
+}
+
+func TestWSClientPending(t *testing.T) {
+	c := &WSClient{}
+	replies := map[string]chan error{}
+	for _, name := range []string{"first", "second", "third"} {
+		replies[name] = make(chan error, 1)
+	}
+
+	c.Pend(nil, "first", WSRequest{Msg: "first", reply: replies["first"]})
+	c.Pend(int64(2), "second", WSRequest{Msg: "second"})
+	c.Pend(nil, "third", WSRequest{Msg: "third", reply: replies["third"]})
+
+	// replay is answered to caller waiting for the same subscription
+	if req, ok := c.Pending(int64(2)); !ok || !req.Replayed() {
+		t.Fatalf("got %+v, want replayed request", req)
+	}
+	if !c.Attach(int64(2), WSRequest{Msg: "second", reply: replies["second"]}) {
+		t.Fatal("request was not attached")
+	}
+	if c.Attach(int64(4), WSRequest{}) {
+		t.Error("attached request without pending key")
+	}
+
+	// requests with the same key are resolved in order they were sent
+	for _, want := range []string{"first", "third"} {
+		req, ok := c.Resolve(nil)
+		if !ok || req.Msg != want {
+			t.Errorf("resolved %+v, want %v", req, want)
+		}
+	}
+	if _, ok := c.Resolve(nil); ok {
+		t.Error("resolved request twice")
+	}
+
+	c.failPending(errors.New("test"))
+	err := <-replies["second"]
+	if err == nil || err.Error() != "connection lost: test" {
+		t.Errorf("got error %v, want connection lost", err)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("%v requests still pending", len(c.pending))
+	}
+}
+
+func TestWSClientRequestWhileReconnecting(t *testing.T) {
+	// server drops every connection so client keeps reconnecting
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	h := &silentHandler{}
+	c, err := ConnectWS(WSConfig{
+		Exchange:        HitBTC,
+		API:             "ws" + strings.TrimPrefix(srv.URL, "http"),
+		ResponseTimeout: 50 * time.Millisecond,
+	}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.c = c
+	if err := c.Stream(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Shutdown()
+
+	for msg := range c.Status() {
+		if msg.State == Reconnecting {
+			break
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Request("subscribe")
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "websocket is reconnecting" {
+			t.Errorf("got error %v, want reconnecting", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request made while reconnecting was not answered")
+	}
+}
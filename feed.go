@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// feed streams data from a single exchange into session's routers
+// A session has a feed for its own exchange and one for each other exchange
+// with pairs in its watchlist
+type feed struct {
+	id       cq.ExchangeID
+	exchange cq.Exchange
+	rest     cq.MarketData
+	ws       cq.Streamer
+	// closed stops forwarding once feed is shut down while session runs
+	closed chan struct{}
+}
+
+// newFeed loads available pairs of exchange and connects to its websocket
+// api
+// Streaming starts once Stream is called on feed's streamer
+func newFeed(id cq.ExchangeID) (*feed, error) {
+	a, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("exchange not available: %v", id)
+	}
+
+	e, err := a.newExchange()
+	if err != nil {
+		return nil, err
+	}
+	ws, err := a.newStreamer()
+	if err != nil {
+		return nil, err
+	}
+
+	return &feed{
+		id:       id,
+		exchange: e,
+		rest:     a.rest,
+		ws:       ws,
+		closed:   make(chan struct{}),
+	}, nil
+}
+
+// close shuts down feed's streamer and stops forwarding its status and
+// errors
+func (f *feed) close() error {
+	close(f.closed)
+	return f.ws.Shutdown()
+}
+
+// forward passes connection status and errors of feed's streamer to status
+// and errs until done or feed is closed
+// Errors are prefixed with exchange name since feeds share errs
+func (f *feed) forward(status chan<- cq.ConnStatusMsg, errs chan<- error, done <-chan struct{}) {
+	statusCh, errCh := f.ws.Status(), f.ws.Errors()
+	for {
+		select {
+		case <-done:
+			return
+		case <-f.closed:
+			return
+		case msg := <-statusCh:
+			select {
+			case status <- msg:
+			case <-done:
+				return
+			case <-f.closed:
+				return
+			}
+		case err := <-errCh:
+			select {
+			case errs <- fmt.Errorf("%v: %v", f.id, err):
+			case <-done:
+				return
+			case <-f.closed:
+				return
+			}
+		}
+	}
+}
+
+// byExchange groups pairs by exchange they trade on
+func byExchange(pairs []cq.Pair) map[cq.ExchangeID][]cq.Pair {
+	groups := map[cq.ExchangeID][]cq.Pair{}
+	for _, p := range pairs {
+		groups[p.Exchange()] = append(groups[p.Exchange()], p)
+	}
+	return groups
+}
+
+// statusText joins latest connection status of each feed in selector order
+func statusText(states map[cq.ExchangeID]cq.ConnStatusMsg) string {
+	text := []string{}
+	for _, id := range exchangeIDs {
+		if msg, ok := states[id]; ok {
+			text = append(text, msg.String())
+		}
+	}
+	return strings.Join(text, "  ")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/widget"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// showFilterDialog shows dialog to edit trade filter of pair
+// onSave is called with filter entered by user
+// Empty thresholds are disabled
+func showFilterDialog(w fyne.Window, pair cq.Pair, filter cq.TradeFilter, onSave func(cq.TradeFilter)) {
+	units := []cq.SizeUnit{cq.BaseUnit, cq.NotionalUnit}
+	unitName := func(u cq.SizeUnit) string {
+		if u == cq.NotionalUnit {
+			return fmt.Sprintf("Notional (%v)", pair.QuoteCurrency())
+		}
+		return fmt.Sprintf("Size (%v)", pair.BaseCurrency())
+	}
+
+	unit := widget.NewSelect([]string{unitName(units[0]), unitName(units[1])}, nil)
+	unit.Selected = unitName(filter.Unit)
+	minSize := widget.NewEntry()
+	minSize.SetPlaceHolder("off")
+	minSize.SetText(thresholdText(filter.MinSize))
+	whale := widget.NewEntry()
+	whale.SetPlaceHolder("off")
+	whale.SetText(thresholdText(filter.Whale))
+
+	form := widget.NewForm(
+		&widget.FormItem{Text: "Measure by", Widget: unit},
+		&widget.FormItem{Text: "Hide trades below", Widget: minSize},
+		&widget.FormItem{Text: "Whale trades from", Widget: whale},
+	)
+
+	dialog.ShowCustomConfirm(fmt.Sprintf("%v Trade Filter", pair), "Save", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		f := cq.TradeFilter{}
+		for _, u := range units {
+			if unitName(u) == unit.Selected {
+				f.Unit = u
+			}
+		}
+		var err error
+		f.MinSize, err = parseThreshold(minSize.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid minimum size: %v", err), w)
+			return
+		}
+		f.Whale, err = parseThreshold(whale.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid whale threshold: %v", err), w)
+			return
+		}
+		onSave(f)
+	}, w)
+}
+
+// thresholdText formats threshold for entry
+// Disabled threshold is shown as empty entry
+func thresholdText(v cq.Decimal) string {
+	if v.Sign() <= 0 {
+		return ""
+	}
+	return v.String()
+}
+
+// parseThreshold parses threshold from entry
+// Empty entry disables threshold
+func parseThreshold(s string) (cq.Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return cq.Decimal{}, nil
+	}
+	v, err := cq.ParseDecimal(s)
+	if err != nil {
+		return cq.Decimal{}, err
+	}
+	if v.Sign() < 0 {
+		return cq.Decimal{}, fmt.Errorf("%v is negative", s)
+	}
+	return v, nil
+}
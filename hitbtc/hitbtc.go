@@ -2,28 +2,44 @@ package hitbtc
 
 import (
 	"errors"
-	"strings"
 
 	"github.com/3cb/cq-gui/cq"
 )
 
+// defaultSymbols are the symbols of default watchlist
+var defaultSymbols = []string{
+	"BTCUSD",
+	"BCHUSD",
+	"ETHUSD",
+	"ETHBTC",
+	"LTCUSD",
+	"LTCBTC",
+	"ZECUSD",
+	"ZECBTC",
+	"ZRXUSD",
+}
+
 // Exchange implements the cq.Exchange interface
 type Exchange struct {
 	cq.BaseExchange
+	// rest resolves symbols of default pairs
+	rest REST
 }
 
 // New returns new instance which implements cq.Exchange interface
 // Sets id, available Pair(s), and default watchlist
-func New() (*Exchange, error) {
+// Symbol metadata is loaded into rest's registry.
+func New(rest REST) (*Exchange, error) {
 	e := &Exchange{
 		cq.BaseExchange{},
+		rest,
 	}
-	pairs, err := GetPairs()
+	instruments, err := rest.GetInstruments()
 	if err != nil {
 		return nil, errors.New("unable to get available pairs")
 	}
 	e.SetID(cq.HitBTC)
-	e.AddAvailablePair(pairs...)
+	e.AddInstrument(instruments...)
 	e.SetWatchlist(e.GetDefaultPairs()...)
 
 	return e, nil
@@ -31,29 +47,26 @@ func New() (*Exchange, error) {
 
 // GetDefaultPairs returns a slice of cq.Pair(s) for HitBTC exchange
 func (e *Exchange) GetDefaultPairs() []cq.Pair {
-	return []cq.Pair{
-		NewPair("BTCUSD"),
-		NewPair("BCHUSD"),
-		NewPair("ETHUSD"),
-		NewPair("ETHBTC"),
-		NewPair("LTCUSD"),
-		NewPair("LTCBTC"),
-		NewPair("ZECUSD"),
-		NewPair("ZECBTC"),
-		NewPair("ZRXUSD"),
+	pairs := []cq.Pair{}
+	for _, s := range defaultSymbols {
+		if p, err := e.rest.NewPair(s); err == nil {
+			pairs = append(pairs, p)
+		}
 	}
+	return pairs
 }
 
 // NewPair takes a string in the format used by HitBTC APIs (ALL CAPS) and returns
 // and instance of cq.Pair.
-func NewPair(s string) cq.Pair {
-	t := strings.Split(s, "")
-	return cq.NewPair(strings.Join(t[:3], ""), strings.Join(t[3:], ""))
+// Symbol is split at a known quote currency.  REST.NewPair resolves symbols
+// with symbol metadata first.  Symbols that cannot be split return an error.
+func NewPair(s string) (cq.Pair, error) {
+	return splitSymbol(s)
 }
 
 // NewSymbol takes a cq.Pair and returns a symbol string formatted
 // for use by API
+// REST.NewSymbol resolves pairs with symbol metadata first.
 func NewSymbol(p cq.Pair) string {
-	t := strings.Split(p.String(), "/")
-	return strings.Join(t, "")
+	return p.BaseCurrency() + p.QuoteCurrency()
 }
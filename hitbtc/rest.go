@@ -2,51 +2,158 @@ package hitbtc
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strconv"
 
 	"github.com/3cb/cq-gui/cq"
 )
 
+// restAPI is the base url of HitBTC's REST API
+const restAPI = "https://api.hitbtc.com/api/2"
+
+// REST implements cq.MarketData with HitBTC's REST API
+// API is the base url of REST API.  Empty API uses HitBTC's api.
+// Symbols are resolved with metadata loaded by GetInstruments so REST
+// values that share it, including Exchange and WSCtlr made from them,
+// resolve symbols the same way.
+type REST struct {
+	API     string
+	symbols *symbolRegistry
+}
+
+// NewREST returns REST for HitBTC's api with an empty symbol registry
+func NewREST() REST {
+	return REST{symbols: newSymbolRegistry()}
+}
+
+// url returns url of path on REST API
+func (r REST) url(path string) string {
+	if len(r.API) == 0 {
+		return restAPI + path
+	}
+	return r.API + path
+}
+
+// NewPair returns pair of symbol using base and quote currencies from
+// symbol metadata
+// Symbols missing from metadata are split by package NewPair.
+func (r REST) NewPair(s string) (cq.Pair, error) {
+	if p, ok := r.symbols.pair(s); ok {
+		return p, nil
+	}
+	return NewPair(s)
+}
+
+// NewSymbol returns symbol of pair from symbol metadata
+// Pairs missing from metadata are joined by package NewSymbol.
+func (r REST) NewSymbol(p cq.Pair) string {
+	if s, ok := r.symbols.symbol(p); ok {
+		return s
+	}
+	return NewSymbol(p)
+}
+
+// ErrorResp contains error data returned by REST API
+// https://api.hitbtc.com/#error-response
+type ErrorResp struct {
+	Error RPCError `json:"error"`
+}
+
+// getJSON performs http GET request and decodes response body into v
+// Non-200 responses are returned as errors with message from API if present
+func getJSON(api string, v interface{}) error {
+	resp, err := http.Get(api)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e := ErrorResp{}
+		if json.Unmarshal(body, &e) == nil && len(e.Error.Message) > 0 {
+			return fmt.Errorf("hitbtc api error %v: %v", e.Error.Code, e.Error.Error())
+		}
+		return fmt.Errorf("hitbtc api error: %v", resp.Status)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
 // SymbolsResp contains data for http response for symbols query
 // https://api.hitbtc.com/#symbols
 type SymbolsResp struct {
 	ID                   string `json:"id"`
 	BaseCurrency         string `json:"baseCurrency"`
 	QuoteCurrency        string `json:"quoteCurrency"`
-	QuantityIncrement    string `json:"quantity"`
+	QuantityIncrement    string `json:"quantityIncrement"`
 	TickSize             string `json:"tickSize"`
-	TakeLiquidity        string `json:"takeLiquidity"`
+	TakeLiquidityRate    string `json:"takeLiquidityRate"`
 	ProvideLiquidityRate string `json:"provideLiquidityRate"`
 	FeeCurrency          string `json:"feeCurrency"`
 }
 
 // GetPairs queries REST API to get all available crypto pairs.
 // Returns a slice of cq.Pair
-func GetPairs() ([]cq.Pair, error) {
-	pairs := []cq.Pair{}
-
-	resp, err := http.Get("https://api.hitbtc.com/api/2/public/symbol")
+func (r REST) GetPairs() ([]cq.Pair, error) {
+	instruments, err := r.GetInstruments()
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+
+	pairs := []cq.Pair{}
+	for _, i := range instruments {
+		pairs = append(pairs, i.Pair)
 	}
+	return pairs, nil
+}
+
+// GetInstruments queries REST API to get tick size, quantity increment and
+// fees of all available crypto pairs
+// Symbols are registered so they resolve to pairs of their metadata.
+func (r REST) GetInstruments() ([]cq.Instrument, error) {
 	symbols := []SymbolsResp{}
-	err = json.Unmarshal(body, &symbols)
+	err := getJSON(r.url("/public/symbol"), &symbols)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, symbol := range symbols {
-		pairs = append(pairs, NewPair(symbol.ID))
+	instruments := []cq.Instrument{}
+	for _, s := range symbols {
+		i, ok := r.instrument(s)
+		if !ok {
+			continue
+		}
+		instruments = append(instruments, i)
 	}
+	return instruments, nil
+}
 
-	return pairs, nil
+// instrument returns trading rules of symbol and registers symbol's pair
+// Fields that fail to parse are left unknown.  Returns false for symbols
+// without currencies that cannot be split.
+func (r REST) instrument(s SymbolsResp) (cq.Instrument, bool) {
+	pair, err := r.NewPair(s.ID)
+	if s.BaseCurrency != "" && s.QuoteCurrency != "" {
+		pair, err = cq.NewMarketPair(cq.HitBTC, s.BaseCurrency, s.QuoteCurrency, cq.Spot), nil
+		r.symbols.add(s.ID, pair)
+	}
+	if err != nil {
+		return cq.Instrument{}, false
+	}
+
+	i := cq.NewInstrument(pair)
+	i.TickSize, _ = cq.ParseDecimal(s.TickSize)
+	i.LotSize, _ = cq.ParseDecimal(s.QuantityIncrement)
+	i.FeeCurrency = s.FeeCurrency
+	i.MakerFee, _ = cq.ParseDecimal(s.ProvideLiquidityRate)
+	i.TakerFee, _ = cq.ParseDecimal(s.TakeLiquidityRate)
+	return i, true
 }
 
 // TickerEntry holds data for element of ticker response array
@@ -74,37 +181,27 @@ type TradesEntry struct {
 	Timestamp string `json:"timestamp"`
 }
 
-func GetQuotes(pairs ...cq.Pair) ([]cq.Quote, error) {
+// GetQuotes queries tickers for pairs
+func (r REST) GetQuotes(pairs ...cq.Pair) ([]cq.Quote, error) {
 	tickers := []TickerEntry{}
 	quotes := []cq.Quote{}
 
-	resp, err := http.Get("https://api.hitbtc.com/api/2/public/ticker")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(bytes, &tickers)
+	err := getJSON(r.url("/public/ticker"), &tickers)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, pair := range pairs {
 		for _, v := range tickers {
-			if NewPair(v.Symbol) == pair {
-				l, _ := strconv.ParseFloat(v.Last, 64)
-				o, _ := strconv.ParseFloat(v.Open, 64)
+			if p, err := r.NewPair(v.Symbol); err == nil && p == pair {
+				l, _ := cq.ParseDecimal(v.Last)
+				o, _ := cq.ParseDecimal(v.Open)
 
 				quotes = append(quotes, cq.Quote{
 					ExchangeID: cq.HitBTC,
 					ID:         pair,
 					Price:      v.Last,
-					Change:     strconv.FormatFloat((l - o), 'f', -1, 64),
+					Change:     l.Sub(o).String(),
 					Size:       "",
 					Bid:        v.Bid,
 					Ask:        v.Ask,
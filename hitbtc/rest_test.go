@@ -0,0 +1,277 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// newFakeREST returns REST client for fake server which serves routes by
+// path
+func newFakeREST(routes map[string]http.HandlerFunc) (REST, *httptest.Server) {
+	srv := fakeapi.NewREST(routes, `{"error":{"code":2001,"message":"Symbol not found","description":"Try get /api/2/public/symbol, to get list of all available symbols."}}`)
+	rest := NewREST()
+	rest.API = srv.URL
+	return rest, srv
+}
+
+// candleHistory returns handler serving n one minute candles ending at last
+// Pages are served newest first and till is inclusive like candles endpoint.
+// Each request is counted in requests.
+func candleHistory(n int, last time.Time, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		q := r.URL.Query()
+		if q.Get("period") != "M1" || q.Get("sort") != "DESC" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(q.Get("limit"))
+		if err != nil || limit > maxCandlesPage {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":1001,"message":"Invalid limit"}}`))
+			return
+		}
+		till := last
+		if t := q.Get("till"); t != "" {
+			till, err = time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		entries := []CandleEntry{}
+		for i := 0; i < n && len(entries) < limit; i++ {
+			ts := last.Add(-time.Duration(i) * time.Minute)
+			if ts.After(till) {
+				continue
+			}
+			entries = append(entries, CandleEntry{
+				Timestamp: ts.Format(time.RFC3339),
+				Open:      "1",
+				Close:     "1",
+				Min:       "1",
+				Max:       "1",
+				Volume:    strconv.Itoa(i),
+			})
+		}
+		json.NewEncoder(w).Encode(entries)
+	}
+}
+
+func TestQueryCandlesPaging(t *testing.T) {
+	last := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		history  int
+		limit    int
+		sort     string
+		want     int
+		requests int
+	}{
+		{"single page", 2500, 500, "", 500, 1},
+		{"full pages", 2500, 2000, "", 2000, 3},
+		{"several pages", 2500, 2200, "ASC", 2200, 3},
+		{"descending", 2500, 1500, "DESC", 1500, 2},
+		{"history shorter than limit", 2500, 3000, "", 2500, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			rest, srv := newFakeREST(map[string]http.HandlerFunc{
+				"/public/candles/BTCUSD": candleHistory(test.history, last, &requests),
+			})
+			defer srv.Close()
+
+			candles, err := rest.QueryCandles(mustPair("BTCUSD"), CandlesQuery{
+				Period: M1,
+				Limit:  test.limit,
+				Sort:   test.sort,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(candles) != test.want {
+				t.Fatalf("got %v candles, want %v", len(candles), test.want)
+			}
+			if requests != test.requests {
+				t.Errorf("got %v requests, want %v", requests, test.requests)
+			}
+
+			// candles are consecutive and include most recent one
+			step := time.Minute
+			newest := candles[len(candles)-1]
+			if test.sort == "DESC" {
+				step = -time.Minute
+				newest = candles[0]
+			}
+			if !newest.Timestamp.Equal(last) {
+				t.Errorf("newest candle at %v, want %v", newest.Timestamp, last)
+			}
+			for i := 1; i < len(candles); i++ {
+				if d := candles[i].Timestamp.Sub(candles[i-1].Timestamp); d != step {
+					t.Fatalf("candle %v is %v after previous, want %v", i, d, step)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryCandlesError(t *testing.T) {
+	last := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	requests := 0
+	history := candleHistory(2500, last, &requests)
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/public/candles/BTCUSD": func(w http.ResponseWriter, r *http.Request) {
+			// second page fails
+			if requests == 1 {
+				requests++
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"error":{"code":500,"message":"Internal Server Error"}}`))
+				return
+			}
+			history(w, r)
+		},
+	})
+	defer srv.Close()
+
+	candles, err := rest.QueryCandles(mustPair("BTCUSD"), CandlesQuery{Period: M1, Limit: 1500})
+	if err == nil || err.Error() != "hitbtc api error 500: Internal Server Error" {
+		t.Errorf("got error %v, want api error", err)
+	}
+	if candles != nil {
+		t.Errorf("got %v candles with error", len(candles))
+	}
+
+	_, err = rest.QueryCandles(mustPair("BTCUSD"), CandlesQuery{Sort: "UP"})
+	if err == nil {
+		t.Error("expected error for invalid sort order")
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/public/ticker": fakeapi.Respond(`[{"symbol":"ETHBTC","ask":"0.020","bid":"0.019","last":"0.0195","open":"0.0190","low":"0.018","high":"0.021","volume":"1000","volumeQuote":"19.5","timestamp":"2020-01-02T03:04:05.000Z"},{"symbol":"BTCUSD","ask":"7000.02","bid":"7000.00","last":"7000.01","open":"6900.00","low":"6800.00","high":"7100.00","volume":"100","volumeQuote":"700000","timestamp":"2020-01-02T03:04:05.000Z"}]`),
+	})
+	defer srv.Close()
+
+	quotes, err := rest.GetQuotes(mustPair("BTCUSD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cq.Quote{
+		ExchangeID: cq.HitBTC,
+		ID:         mustPair("BTCUSD"),
+		Price:      "7000.01",
+		Change:     "100.01",
+		Bid:        "7000.00",
+		Ask:        "7000.02",
+		Low:        "6800.00",
+		High:       "7100.00",
+		Open:       "6900.00",
+		Volume:     "100",
+	}
+	if len(quotes) != 1 || quotes[0] != want {
+		t.Errorf("got %+v, want %+v", quotes, want)
+	}
+}
+
+func TestGetTrades(t *testing.T) {
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/public/trades/BTCUSD": fakeapi.Respond(`[{"id":11,"price":"7000.01","quantity":"0.25","side":"sell","timestamp":"2020-01-02T03:04:05.123Z"},{"id":10,"price":"6999.99","quantity":"1","side":"buy","timestamp":"2020-01-02T03:04:04.5Z"}]`),
+	})
+	defer srv.Close()
+
+	pair := mustPair("BTCUSD")
+
+	trades, err := rest.GetTrades(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cq.Trade{
+		Pair:      pair,
+		ID:        11,
+		Price:     "7000.01",
+		Size:      "0.25",
+		Side:      cq.Sell,
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 123000000, time.UTC),
+	}
+	if len(trades) != 2 || trades[0] != want {
+		t.Errorf("got %+v, want first trade %+v", trades, want)
+	}
+}
+
+func TestGetOlderTrades(t *testing.T) {
+	// trades 1 to 200 served newest first and till is inclusive like trades
+	// endpoint
+	rest, srv := newFakeREST(map[string]http.HandlerFunc{
+		"/public/trades/BTCUSD": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			till, err := strconv.Atoi(q.Get("till"))
+			if err != nil || q.Get("by") != "id" || q.Get("sort") != "DESC" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			entries := []TradeEntry{}
+			for id := till; id > 0 && len(entries) < limit; id-- {
+				entries = append(entries, TradeEntry{ID: float64(id), Price: "1", Quantity: "1", Side: "buy", Timestamp: "2020-01-02T03:04:05.000Z"})
+			}
+			json.NewEncoder(w).Encode(entries)
+		},
+	})
+	defer srv.Close()
+
+	trades, err := rest.GetOlderTrades(mustPair("BTCUSD"), cq.Trade{ID: 150}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 100 {
+		t.Fatalf("got %v trades, want 100", len(trades))
+	}
+	if trades[0].ID != 149 || trades[99].ID != 50 {
+		t.Errorf("got trades %v to %v, want 149 to 50", trades[0].ID, trades[99].ID)
+	}
+}
+
+func TestRESTErrors(t *testing.T) {
+	rest, srv := newFakeREST(nil)
+	defer srv.Close()
+	want := "hitbtc api error 2001: Symbol not found: Try get /api/2/public/symbol, to get list of all available symbols."
+
+	requests := map[string]func() error{
+		"GetQuotes": func() error {
+			_, err := rest.GetQuotes(mustPair("BTCUSD"))
+			return err
+		},
+		"GetTrades": func() error {
+			_, err := rest.GetTrades(mustPair("BTCUSD"))
+			return err
+		},
+		"GetOlderTrades": func() error {
+			_, err := rest.GetOlderTrades(mustPair("BTCUSD"), cq.Trade{ID: 10}, 10)
+			return err
+		},
+		"GetCandles": func() error {
+			_, err := rest.GetCandles(mustPair("BTCUSD"), 5, 10)
+			return err
+		},
+	}
+	for name, request := range requests {
+		if err := request(); err == nil || err.Error() != want {
+			t.Errorf("%v: got error %v, want %q", name, err, want)
+		}
+	}
+
+	if _, err := rest.GetCandles(mustPair("BTCUSD"), 2, 10); err == nil || err.Error() != "unsupported candle interval: 2 minutes" {
+		t.Errorf("got error %v, want unsupported interval", err)
+	}
+}
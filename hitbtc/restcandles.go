@@ -0,0 +1,225 @@
+package hitbtc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+const (
+	// M1 is a 1 minute candle period
+	M1 Period = "M1"
+	// M3 is a 3 minute candle period
+	M3 Period = "M3"
+	// M5 is a 5 minute candle period
+	M5 Period = "M5"
+	// M15 is a 15 minute candle period
+	M15 Period = "M15"
+	// M30 is a 30 minute candle period
+	M30 Period = "M30"
+	// H1 is a 1 hour candle period
+	H1 Period = "H1"
+	// H4 is a 4 hour candle period
+	H4 Period = "H4"
+	// D1 is a 1 day candle period
+	D1 Period = "D1"
+	// D7 is a 7 day candle period
+	D7 Period = "D7"
+	// Month1 is a 1 month candle period
+	Month1 Period = "1M"
+
+	// maxCandlesPage is the largest limit accepted by candles endpoint
+	maxCandlesPage = 1000
+)
+
+// Period is a candle period used by HitBTC APIs
+type Period string
+
+// periods maps candle interval in minutes to HitBTC period
+// Month is approximated as 30 days
+var periods = map[int]Period{
+	1:     M1,
+	3:     M3,
+	5:     M5,
+	15:    M15,
+	30:    M30,
+	60:    H1,
+	240:   H4,
+	1440:  D1,
+	10080: D7,
+	43200: Month1,
+}
+
+// PeriodFromInterval returns the HitBTC Period for candle interval in minutes
+func PeriodFromInterval(interval int) (Period, error) {
+	p, ok := periods[interval]
+	if !ok {
+		return "", fmt.Errorf("unsupported candle interval: %v minutes", interval)
+	}
+	return p, nil
+}
+
+// CandleEntry holds data for element of candles response array
+// https://api.hitbtc.com/#candles
+type CandleEntry struct {
+	Timestamp   string `json:"timestamp"`
+	Open        string `json:"open"`
+	Close       string `json:"close"`
+	Min         string `json:"min"`
+	Max         string `json:"max"`
+	Volume      string `json:"volume"`
+	VolumeQuote string `json:"volumeQuote"`
+}
+
+// CandlesQuery contains parameters for candles request
+// Zero values are omitted from request
+type CandlesQuery struct {
+	Period Period
+	// Limit is the total number of candles to return
+	// Requests for more than one page of candles are paged automatically
+	Limit int
+	From  time.Time
+	Till  time.Time
+	// Sort is "ASC" or "DESC" and sets order of returned candles
+	// Defaults to "ASC"
+	Sort string
+}
+
+// GetCandles performs http request to retrieve the most recent candles
+// for pair with interval given in minutes
+func (r REST) GetCandles(pair cq.Pair, interval int, limit int) ([]cq.CandleData, error) {
+	period, err := PeriodFromInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.QueryCandles(pair, CandlesQuery{
+		Period: period,
+		Limit:  limit,
+	})
+}
+
+// QueryCandles performs http request/s to retrieve candles matching query
+// Pages backwards from Till (or now) until Limit candles are returned or
+// there is no more history
+func (r REST) QueryCandles(pair cq.Pair, q CandlesQuery) ([]cq.CandleData, error) {
+	if q.Period == "" {
+		q.Period = M30
+	}
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	if q.Sort != "" && q.Sort != "ASC" && q.Sort != "DESC" {
+		return nil, fmt.Errorf("invalid sort order: %v", q.Sort)
+	}
+
+	candles := []cq.CandleData{}
+	seen := map[time.Time]struct{}{}
+	till := q.Till
+	for len(candles) < q.Limit {
+		pageSize := q.Limit - len(candles)
+		// till is inclusive so following pages start with candle already
+		// seen at boundary and need one extra candle
+		if len(candles) > 0 {
+			pageSize++
+		}
+		if pageSize > maxCandlesPage {
+			pageSize = maxCandlesPage
+		}
+
+		page, err := r.getCandlesPage(pair, q.Period, pageSize, q.From, till)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, c := range page {
+			if _, ok := seen[c.Timestamp]; ok {
+				continue
+			}
+			seen[c.Timestamp] = struct{}{}
+			candles = append(candles, c)
+			added++
+
+			if till.IsZero() || c.Timestamp.Before(till) {
+				till = c.Timestamp
+			}
+		}
+
+		if len(page) < pageSize || added == 0 {
+			break
+		}
+	}
+	// candles are collected newest first
+	if len(candles) > q.Limit {
+		candles = candles[:q.Limit]
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		if q.Sort == "DESC" {
+			return candles[i].Timestamp.After(candles[j].Timestamp)
+		}
+		return candles[i].Timestamp.Before(candles[j].Timestamp)
+	})
+
+	return candles, nil
+}
+
+// getCandlesPage retrieves a single page of candles, newest first
+func (r REST) getCandlesPage(pair cq.Pair, period Period, limit int, from time.Time, till time.Time) ([]cq.CandleData, error) {
+	params := url.Values{}
+	params.Set("period", string(period))
+	params.Set("sort", "DESC")
+	params.Set("limit", strconv.Itoa(limit))
+	if !from.IsZero() {
+		params.Set("from", from.UTC().Format(time.RFC3339Nano))
+	}
+	if !till.IsZero() {
+		params.Set("till", till.UTC().Format(time.RFC3339Nano))
+	}
+
+	api := r.url(fmt.Sprintf("/public/candles/%v?%v", r.NewSymbol(pair), params.Encode()))
+	entries := []CandleEntry{}
+	err := getJSON(api, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCandles(entries)
+}
+
+// newCandle converts CandleEntry instance to cq.CandleData instance
+func newCandle(e CandleEntry) (cq.CandleData, error) {
+	t, err := time.Parse(time.RFC3339, e.Timestamp)
+	if err != nil {
+		return cq.CandleData{}, errors.New("invalid candle timestamp: " + e.Timestamp)
+	}
+
+	return cq.CandleData{
+		Timestamp:   t,
+		Open:        e.Open,
+		Close:       e.Close,
+		Min:         e.Min,
+		Max:         e.Max,
+		Volume:      e.Volume,
+		VolumeQuote: e.VolumeQuote,
+	}, nil
+}
+
+// newCandles converts slice of CandleEntry to slice of cq.CandleData
+func newCandles(entries []CandleEntry) ([]cq.CandleData, error) {
+	candles := []cq.CandleData{}
+	for _, e := range entries {
+		c, err := newCandle(e)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+	return candles, nil
+}
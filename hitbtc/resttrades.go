@@ -1,10 +1,9 @@
 package hitbtc
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/3cb/cq-gui/cq"
@@ -21,49 +20,73 @@ type TradeEntry struct {
 }
 
 // GetTrades performs http request to retrieve 100 trades
-func GetTrades(pair cq.Pair) ([]cq.Trade, error) {
+func (r REST) GetTrades(pair cq.Pair) ([]cq.Trade, error) {
 	trades := []TradeEntry{}
 	t := []cq.Trade{}
 
-	api := fmt.Sprintf("https://api.hitbtc.com/api/2/public/trades/%v?sort=DESC", NewSymbol(pair))
-	resp, err := http.Get(api)
+	api := r.url(fmt.Sprintf("/public/trades/%v?sort=DESC", r.NewSymbol(pair)))
+	err := getJSON(api, &trades)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	for _, e := range trades {
+		trade := newTrade(e)
+		trade.Pair = pair
+		t = append(t, trade)
 	}
 
-	err = json.Unmarshal(bytes, &trades)
+	return t, nil
+}
+
+// GetOlderTrades performs http request to retrieve up to limit trades
+// older than trade, newest first
+func (r REST) GetOlderTrades(pair cq.Pair, before cq.Trade, limit int) ([]cq.Trade, error) {
+	params := url.Values{}
+	params.Set("sort", "DESC")
+	params.Set("by", "id")
+	params.Set("till", strconv.FormatInt(int64(before.ID), 10))
+	// till is inclusive so one extra trade is requested in place of before
+	params.Set("limit", strconv.Itoa(limit+1))
+
+	api := r.url(fmt.Sprintf("/public/trades/%v?%v", r.NewSymbol(pair), params.Encode()))
+	entries := []TradeEntry{}
+	err := getJSON(api, &entries)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, trade := range trades {
-		t = append(t, newTrade(trade))
+	t := []cq.Trade{}
+	for _, e := range entries {
+		if e.ID >= before.ID || len(t) == limit {
+			continue
+		}
+		trade := newTrade(e)
+		trade.Pair = pair
+		t = append(t, trade)
 	}
 
 	return t, nil
 }
 
 // newTrade converts TradesEntry instance to cq.Trade instance
-// converts timestamp to local timezone
+// Side is the taker's side
 func newTrade(t TradeEntry) cq.Trade {
 	return cq.Trade{
-		ID:    t.ID,
-		Price: t.Price,
-		Size:  t.Quantity,
-		Time:  localTime(t.Timestamp),
+		ID:        t.ID,
+		Price:     t.Price,
+		Size:      t.Quantity,
+		Side:      cq.NewSide(t.Side),
+		Timestamp: parseTime(t.Timestamp),
 	}
 }
 
-func localTime(t string) string {
+// parseTime parses RFC3339 timestamp
+// Returns zero time if timestamp is invalid
+func parseTime(t string) time.Time {
 	t2, err := time.Parse(time.RFC3339, t)
 	if err != nil {
-		return "-"
+		return time.Time{}
 	}
-	return t2.Local().Format("03:04:05")
+	return t2
 }
@@ -1,380 +1,501 @@
 package hitbtc
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
-
-	"github.com/gorilla/websocket"
 
 	"github.com/3cb/cq-gui/cq"
 )
 
-type WSCtlr struct {
-	sync.RWMutex
-	*websocket.Conn
+// bookDepth is the number of levels on each side of order book sent to main
+// event loop
+const bookDepth = 100
+
+// wsAPI is the url of HitBTC's websocket API
+const wsAPI = "wss://api.hitbtc.com/api/2/ws"
 
-	api        string
-	subCh      chan SubRequest
-	shutdownCh chan chan struct{}
+type WSCtlr struct {
+	// client owns connection and matches responses to requests
+	client *cq.WSClient
+	// rest resolves symbols of stream messages
+	rest REST
+
+	// fields below are only accessed by event loop
+	chans cq.StreamChans
+	// subs holds active subscriptions so they can be replayed after reconnect
+	// keys are created with subKey()
+	subs   map[string]SubscribeMsg
+	nextID int64
+	// books holds local order books keyed by symbol
+	// Book is missing until snapshot is received
+	books map[string]*cq.OrderBook
 }
 
 // SubscribeMsg contains info to subscribe to websocket data
+// ID is set by event loop so responses can be matched to requests
 type SubscribeMsg struct {
 	Method string            `json:"method"`
 	Params map[string]string `json:"params"`
-	ID     string            `json:"id"`
-}
-
-// SubRequest contains a subscribe message and an error channel to receive
-// error messages from websocket event loop
-type SubRequest struct {
-	Msg   SubscribeMsg
-	errCh chan error
-}
-
-// WSMsg contains data from websocket messages
-// Params has to be type asserted:
-type WSMsg struct {
-	VersionJSON string      `json:"jsonrpc"`
-	Method      string      `json:"method"`
-	Params      interface{} `json:"params"`
+	ID     int64             `json:"id"`
 }
 
 // NewWSCtlr returns an instance that is connected to websocket at
 // "wss://api.hitbtc.com/api/2/ws"
-func NewWSCtlr() (*WSCtlr, error) {
-	api := "wss://api.hitbtc.com/api/2/ws"
-
-	conn, resp, err := websocket.DefaultDialer.Dial(api, nil)
-	if resp.StatusCode != 101 || err != nil {
-		return nil, errors.New("unable to connect to hitbtc websocket api")
-	}
+// Symbols are resolved with rest's symbol metadata.
+func NewWSCtlr(rest REST) (*WSCtlr, error) {
+	return Connect(wsAPI, rest)
+}
 
+// Connect returns an instance that is connected to websocket at api
+// Symbols are resolved with rest's symbol metadata.
+func Connect(api string, rest REST) (*WSCtlr, error) {
 	ws := &WSCtlr{
-		Conn:       conn,
-		api:        api,
-		subCh:      make(chan SubRequest, 5),
-		shutdownCh: make(chan chan struct{}),
+		rest:  rest,
+		subs:  make(map[string]SubscribeMsg),
+		books: make(map[string]*cq.OrderBook),
+	}
+	client, err := cq.ConnectWS(cq.WSConfig{
+		Exchange: cq.HitBTC,
+		API:      api,
+	}, ws)
+	if err != nil {
+		return nil, err
 	}
+	ws.client = client
 
 	return ws, nil
 }
 
-// SubQuotes subscribes to quotes via websocket api
-func (ws *WSCtlr) SubQuotes(pairs ...cq.Pair) error {
-	ws.Lock()
-	defer ws.Lock()
-
-	if len(pairs) == 0 {
-		return errors.New("no symbols given")
-	}
-
-	err := ws.subQuotes(pairs...)
-	return err
+// subKey returns key used to track subscription in WSCtlr.subs
+// Subscribe and unsubscribe messages for the same stream return the same key
+func subKey(msg SubscribeMsg) string {
+	method := strings.TrimPrefix(msg.Method, "un")
+	return method + ":" + msg.Params["symbol"] + ":" + msg.Params["period"]
 }
 
-// subQuotes is a private method used to avoid deadlocks
-func (ws *WSCtlr) subQuotes(pairs ...cq.Pair) error {
-	if len(pairs) == 0 {
-		return errors.New("no symbols given")
-	}
+// Status returns channel that receives connection state changes
+func (ws *WSCtlr) Status() <-chan cq.ConnStatusMsg {
+	return ws.client.Status()
+}
 
-	symbols := []string{}
-	failedSubs := []string{}
-	for _, p := range pairs {
-		symbols = append(symbols, NewSymbol(p))
-	}
+// Errors returns channel that receives errors from malformed or unexpected
+// websocket messages
+// These errors do not interrupt streaming
+func (ws *WSCtlr) Errors() <-chan error {
+	return ws.client.Errors()
+}
 
-	for _, s := range symbols {
-		subTicker := &SubscribeMsg{
-			Method: "subscribeTicker",
-			Params: map[string]string{
-				"symbol": s,
-			},
-			ID: s,
-		}
-		subTrades := &SubscribeMsg{
-			Method: "subscribeTrades",
-			Params: map[string]string{
-				"symbol": s,
-			},
-			ID: s,
-		}
+// SubQuotes subscribes to quotes via websocket api
+// Trades are included so watchlist rows are updated on each trade
+func (ws *WSCtlr) SubQuotes(pairs ...cq.Pair) error {
+	return ws.requestSymbols("subscribe", []string{"subscribeTicker", "subscribeTrades"}, pairs)
+}
 
-		// write ticker sub to websocket
-		err := ws.Conn.WriteJSON(subTicker)
-		if err != nil {
-			failedSubs = append(failedSubs, s)
-			continue
-		}
+// UnsubQuotes unsubscribes from quotes and trades via websocket api
+func (ws *WSCtlr) UnsubQuotes(pairs ...cq.Pair) error {
+	return ws.requestSymbols("unsubscribe", []string{"unsubscribeTicker", "unsubscribeTrades"}, pairs)
+}
 
-		// write trades sub to websocket
-		err = ws.Conn.WriteJSON(subTrades)
-		if err != nil {
-			failedSubs = append(failedSubs, s)
-		}
-	}
+// SubTrades subscribes to trades via websocket api
+func (ws *WSCtlr) SubTrades(pairs ...cq.Pair) error {
+	return ws.requestSymbols("subscribe", []string{"subscribeTrades"}, pairs)
+}
 
-	if len(failedSubs) > 0 {
-		b := strings.Builder{}
-		b.WriteString("failed to subscribe to the following symbols: ")
-		for i, fail := range failedSubs {
-			b.WriteString(fail)
-			if i < len(failedSubs)-1 {
-				b.WriteString(", ")
-			}
-		}
-		println(b.String())
-		return errors.New(b.String())
-	}
+// UnsubTrades unsubscribes from trades via websocket api
+// Trades are shared with quotes so this also stops trade updates in watchlist
+func (ws *WSCtlr) UnsubTrades(pairs ...cq.Pair) error {
+	return ws.requestSymbols("unsubscribe", []string{"unsubscribeTrades"}, pairs)
+}
 
-	return nil
+// SubOrderBook subscribes to order book via websocket api
+// Server sends snapshot followed by updates
+func (ws *WSCtlr) SubOrderBook(pair cq.Pair) error {
+	return ws.requestSymbols("subscribe", []string{"subscribeOrderbook"}, []cq.Pair{pair})
 }
 
-func (ws *WSCtlr) UnsubQuotes(pairs ...cq.Pair) error {
-	ws.Lock()
-	defer ws.Unlock()
+// UnsubOrderBook unsubscribes from order book
+func (ws *WSCtlr) UnsubOrderBook(pair cq.Pair) error {
+	return ws.requestSymbols("unsubscribe", []string{"unsubscribeOrderbook"}, []cq.Pair{pair})
+}
 
+// requestSymbols sends request for each method and symbol to event loop
+// Remaining methods for a symbol are skipped after its first failure
+func (ws *WSCtlr) requestSymbols(verb string, methods []string, pairs []cq.Pair) error {
 	if len(pairs) == 0 {
 		return errors.New("no symbols given")
 	}
 
-	symbols := []string{}
 	failedSubs := []string{}
 	for _, p := range pairs {
-		symbols = append(symbols, NewSymbol(p))
-	}
-
-	for _, s := range symbols {
-		unsubTicker := &SubscribeMsg{
-			Method: "unsubscribeTicker",
-			Params: map[string]string{
-				"symbol": s,
-			},
-			ID: s,
-		}
-		unsubTrades := &SubscribeMsg{
-			Method: "unsubscribeTrades",
-			Params: map[string]string{
-				"symbol": s,
-			},
-			ID: s,
-		}
-
-		// write ticker unsub to websocket
-		err := ws.Conn.WriteJSON(unsubTicker)
-		if err != nil {
-			failedSubs = append(failedSubs, s)
-			continue
-		}
-
-		// write trades unsub to websocket
-		err = ws.Conn.WriteJSON(unsubTrades)
-		if err != nil {
-			failedSubs = append(failedSubs, s)
+		s := ws.rest.NewSymbol(p)
+		for _, method := range methods {
+			err := ws.request(SubscribeMsg{
+				Method: method,
+				Params: map[string]string{
+					"symbol": s,
+				},
+			})
+			if err != nil {
+				failedSubs = append(failedSubs, fmt.Sprintf("%v (%v)", s, err))
+				break
+			}
 		}
 	}
 
 	if len(failedSubs) > 0 {
-		b := strings.Builder{}
-		b.WriteString("failed to unsubscribe from the following symbols: ")
-		for i, fail := range failedSubs {
-			b.WriteString(fail)
-			if i < len(failedSubs)-1 {
-				b.WriteString(", ")
-			}
+		prep := "to"
+		if verb == "unsubscribe" {
+			prep = "from"
 		}
-		println(b.String())
-		return errors.New(b.String())
+		return fmt.Errorf("failed to %v %v the following symbols: %v", verb, prep, strings.Join(failedSubs, ", "))
 	}
 
 	return nil
 }
 
 func (ws *WSCtlr) SubCandles(pair cq.Pair, interval int, maxBars int) error {
+	period, err := PeriodFromInterval(interval)
+	if err != nil {
+		return err
+	}
 	params := map[string]string{
-		"symbol": NewSymbol(pair),
-		"period": "M" + strconv.FormatInt(int64(interval), 10),
+		"symbol": ws.rest.NewSymbol(pair),
+		"period": string(period),
 		"limit":  strconv.FormatInt(int64(maxBars), 10),
 	}
 
 	msg := SubscribeMsg{
 		Method: "subscribeCandles",
 		Params: params,
-		ID:     NewSymbol(pair),
-	}
-	errCh := make(chan error)
-
-	ws.subCh <- SubRequest{
-		Msg:   msg,
-		errCh: errCh,
 	}
-	err := <-errCh
 
-	return err
+	return ws.request(msg)
 }
 
 func (ws *WSCtlr) UnsubCandles(pair cq.Pair, interval int, maxBars int) error {
+	period, err := PeriodFromInterval(interval)
+	if err != nil {
+		return err
+	}
 	params := map[string]string{
-		"symbol": NewSymbol(pair),
-		"period": "M" + strconv.FormatInt(int64(interval), 10),
+		"symbol": ws.rest.NewSymbol(pair),
+		"period": string(period),
 		"limit":  strconv.FormatInt(int64(maxBars), 10),
 	}
 
 	unsubMsg := SubscribeMsg{
-		Method: "unsubcribeCandles",
+		Method: "unsubscribeCandles",
 		Params: params,
-		ID:     NewSymbol(pair),
 	}
 
-	errCh := make(chan error)
+	return ws.request(unsubMsg)
+}
+
+// request sends message to event loop to be written to websocket and waits
+// for server's response
+// Returns error from server if request was rejected
+func (ws *WSCtlr) request(msg SubscribeMsg) error {
+	return ws.client.Request(msg)
+}
+
+// Shutdown stops event loop and closes websocket connection
+func (ws *WSCtlr) Shutdown() error {
+	return ws.client.Shutdown()
+}
+
+// Stream connects to HitBTC websocket API to get streaming data
+// Dropped connections are reconnected and active subscriptions are replayed
+func (ws *WSCtlr) Stream(chans cq.StreamChans, pairs ...cq.Pair) error {
+	ws.chans = chans
+	err := ws.client.Stream()
+	if err != nil {
+		return err
+	}
 
-	ws.subCh <- SubRequest{
-		Msg:   unsubMsg,
-		errCh: errCh,
+	if len(pairs) > 0 {
+		return ws.SubQuotes(pairs...)
 	}
+	return nil
+}
 
-	err := <-errCh
+// Send assigns unique ID to message, writes it to websocket and adds it to
+// pending requests
+func (ws *WSCtlr) Send(req cq.WSRequest) error {
+	msg := req.Msg.(SubscribeMsg)
+	ws.nextID++
+	msg.ID = ws.nextID
 
-	return err
+	err := ws.client.Write(msg)
+	if err != nil {
+		return err
+	}
+	req.Msg = msg
+	ws.client.Pend(msg.ID, msg.Method+" "+msg.Params["symbol"], req)
+	return nil
 }
 
-func (ws *WSCtlr) Shutdown() error {
-	ws.Lock()
-	defer ws.Unlock()
+// resolve matches response to pending request
+// Successful subscriptions are tracked so they are replayed after reconnect
+func (ws *WSCtlr) resolve(msg WSMsg) {
+	req, ok := ws.client.Resolve(*msg.ID)
+	if !ok {
+		return
+	}
+	sub := req.Msg.(SubscribeMsg)
+
+	var err error
+	if msg.Error != nil {
+		err = msg.Error
+	}
 
-	wait := make(chan struct{})
-	ws.shutdownCh <- wait
-	<-wait
+	switch true {
+	case err == nil:
+		ws.track(sub)
+		if sub.Method == "unsubscribeOrderbook" {
+			delete(ws.books, sub.Params["symbol"])
+		}
+	case req.Replayed():
+		// replayed subscription was rejected so stop replaying it
+		delete(ws.subs, subKey(sub))
+		ws.client.ReportErr(fmt.Errorf("%v %v: %v", sub.Method, sub.Params["symbol"], err))
+	}
 
-	err := ws.Conn.Close()
-	return err
+	req.Reply(err)
 }
 
-// Stream connects to HitBTC websocket API to get streaming data
-func (ws *WSCtlr) Stream(routerCh chan<- cq.UpdateMsg, candleCh chan cq.CandleUpdMsg, historyRouterCh chan<- cq.Trade, pairs ...cq.Pair) error {
-	if len(pairs) > 0 {
-		err := ws.subQuotes(pairs...)
+// track records or removes subscription so it can be replayed after reconnect
+func (ws *WSCtlr) track(msg SubscribeMsg) {
+	if strings.HasPrefix(msg.Method, "unsubscribe") {
+		delete(ws.subs, subKey(msg))
+		return
+	}
+	ws.subs[subKey(msg)] = msg
+}
+
+// Resubscribe replays all active subscriptions on new connection followed by
+// requests made while disconnected
+// Returns requests that were not sent
+func (ws *WSCtlr) Resubscribe(queued []cq.WSRequest) ([]cq.WSRequest, error) {
+	for _, msg := range ws.subs {
+		err := ws.Send(cq.WSRequest{Msg: msg})
 		if err != nil {
-			return err
+			return queued, err
 		}
 	}
-
-	go func() {
-	EventLoop:
-		for {
-			var msg WSMsg
-			select {
-			case confirmStop := <-ws.shutdownCh:
-				confirmStop <- struct{}{}
-				break EventLoop
-			case subReq := <-ws.subCh:
-				err := ws.WriteJSON(subReq.Msg)
-				subReq.errCh <- err
-			default:
-				err := ws.Conn.ReadJSON(&msg)
-				if err != nil {
-					return
-				}
-
-				switch msg.Method {
-				case "ticker":
-					p := (msg.Params).(map[string]interface{})
-
-					q := cq.Quote{}
-					q.ID = newPair((p["symbol"]).(string))
-					q.Ask = (p["ask"]).(string)
-					q.Bid = (p["bid"]).(string)
-					q.Low = (p["low"]).(string)
-					q.High = (p["high"]).(string)
-					q.Open = (p["open"]).(string)
-					q.Volume = (p["volume"]).(string)
-					routerCh <- cq.UpdateMsg{
-						Quote: q,
-						Type:  cq.TickerUpd,
-					}
-				case "snapshotTrades":
-					p := (msg.Params).(map[string]interface{})
-					trades := (p["data"]).([]interface{})
-					for _, t := range trades {
-						historyRouterCh <- cq.Trade{
-							Pair:  newPair(p["symbol"].(string)),
-							ID:    t.(map[string]interface{})["id"].(float64),
-							Price: t.(map[string]interface{})["price"].(string),
-							Size:  t.(map[string]interface{})["quantity"].(string),
-							Time:  localTime(t.(map[string]interface{})["timestamp"].(string)),
-						}
-					}
-				case "updateTrades":
-					p := (msg.Params).(map[string]interface{})
-					data := (p["data"]).([]interface{})
-					u := (data[0]).(map[string]interface{})
-
-					q := cq.Quote{}
-					q.ID = newPair((p["symbol"]).(string))
-					q.Price = (u["price"]).(string)
-					q.Size = (u["quantity"]).(string)
-					routerCh <- cq.UpdateMsg{
-						Quote: q,
-						Type:  cq.TradeUpd,
-					}
-					historyRouterCh <- cq.Trade{
-						Pair:  newPair(p["symbol"].(string)),
-						ID:    (u["id"]).(float64),
-						Price: (u["price"]).(string),
-						Size:  (u["quantity"]).(string),
-						Time:  localTime((u["timestamp"]).(string)),
-					}
-				case "snapshotCandles":
-					p := (msg.Params).(map[string]interface{})
-					data := (p["data"]).([]interface{})
-
-					candles := []cq.CandleData{}
-					for _, d := range data {
-						t, _ := time.Parse(time.RFC3339, d.(map[string]interface{})["timestamp"].(string))
-						candles = append(candles, cq.CandleData{
-							Timestamp:   t,
-							Open:        d.(map[string]interface{})["open"].(string),
-							Close:       d.(map[string]interface{})["close"].(string),
-							Min:         d.(map[string]interface{})["min"].(string),
-							Max:         d.(map[string]interface{})["max"].(string),
-							Volume:      d.(map[string]interface{})["volume"].(string),
-							VolumeQuote: d.(map[string]interface{})["volumeQuote"].(string),
-						})
-					}
-					candleCh <- cq.CandleUpdMsg{
-						Type:    cq.CandleSnapshot,
-						Candles: candles,
-					}
-				case "updateCandles":
-					p := (msg.Params).(map[string]interface{})
-					data := (p["data"]).([]interface{})
-					u := (data[0]).(map[string]interface{})
-
-					candles := []cq.CandleData{}
-					t, _ := time.Parse(time.RFC3339, u["timestamp"].(string))
-					candles = append(candles, cq.CandleData{
-						Timestamp:   t,
-						Open:        u["open"].(string),
-						Close:       u["close"].(string),
-						Min:         u["min"].(string),
-						Max:         u["max"].(string),
-						Volume:      u["volume"].(string),
-						VolumeQuote: u["volumeQuote"].(string),
-					})
-					candleCh <- cq.CandleUpdMsg{
-						Type:    cq.CandleUpd,
-						Candles: candles,
-					}
-				default:
-					continue
-				}
-			}
+	for i, req := range queued {
+		err := ws.Send(req)
+		if err != nil {
+			return queued[i:], err
 		}
-	}()
+	}
+	return nil, nil
+}
+
+// Disconnected drops order books which are rebuilt from snapshots sent after
+// resubscribe
+func (ws *WSCtlr) Disconnected() {
+	ws.books = make(map[string]*cq.OrderBook)
+}
 
+// Decode parses websocket message
+func (ws *WSCtlr) Decode(b []byte) (interface{}, error) {
+	var msg WSMsg
+	err := json.Unmarshal(b, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("malformed websocket message: %v", err)
+	}
+	return msg, nil
+}
+
+// Handle answers pending requests and routes data to main event loop
+func (ws *WSCtlr) Handle(m interface{}) error {
+	msg := m.(WSMsg)
+	if msg.ID != nil {
+		ws.resolve(msg)
+		return nil
+	}
+	ws.handle(msg)
 	return nil
 }
+
+// handle routes data from websocket message to main event loop
+// Malformed messages are reported on error channel and dropped
+func (ws *WSCtlr) handle(msg WSMsg) {
+	if len(msg.Method) == 0 {
+		return
+	}
+	params, err := decodeParams(msg)
+	if err == errUnknownMethod {
+		return
+	}
+	if err != nil {
+		ws.client.ReportErr(err)
+		return
+	}
+
+	switch p := params.(type) {
+	case TickerParams:
+		pair, ok := ws.pair(p.Symbol)
+		if !ok {
+			return
+		}
+		ws.chans.Quotes <- cq.UpdateMsg{
+			Quote: cq.Quote{
+				ID:     pair,
+				Ask:    p.Ask,
+				Bid:    p.Bid,
+				Low:    p.Low,
+				High:   p.High,
+				Open:   p.Open,
+				Volume: p.Volume,
+			},
+			Type: cq.TickerUpd,
+		}
+	case snapshotTrades:
+		pair, ok := ws.pair(p.Symbol)
+		if !ok {
+			return
+		}
+		for _, t := range p.Data {
+			trade := newTrade(t)
+			trade.Pair = pair
+			ws.chans.Trades <- trade
+		}
+	case TradesParams:
+		if len(p.Data) == 0 {
+			return
+		}
+		pair, ok := ws.pair(p.Symbol)
+		if !ok {
+			return
+		}
+		for _, t := range p.Data {
+			trade := newTrade(t)
+			trade.Pair = pair
+			ws.chans.Trades <- trade
+		}
+
+		last := p.Data[len(p.Data)-1]
+		ws.chans.Quotes <- cq.UpdateMsg{
+			Quote: cq.Quote{
+				ID:    pair,
+				Price: last.Price,
+				Size:  last.Quantity,
+			},
+			Type: cq.TradeUpd,
+		}
+	case snapshotOrderbook:
+		book := cq.NewOrderBook()
+		book.Reset(p.Sequence, newLevels(p.Bid), newLevels(p.Ask))
+		ws.books[p.Symbol] = book
+		ws.sendBook(p.Symbol, book)
+	case OrderbookParams:
+		book, ok := ws.books[p.Symbol]
+		if !ok {
+			// waiting for snapshot
+			return
+		}
+		switch true {
+		case p.Sequence <= book.Sequence:
+			// already applied
+			return
+		case p.Sequence > book.Sequence+1:
+			ws.client.ReportErr(fmt.Errorf("orderbook %v: sequence gap %v to %v, resyncing", p.Symbol, book.Sequence, p.Sequence))
+			ws.resyncBook(p.Symbol)
+			return
+		}
+		book.Update(p.Sequence, newLevels(p.Bid), newLevels(p.Ask))
+		ws.sendBook(p.Symbol, book)
+	case snapshotCandles:
+		pair, ok := ws.pair(p.Symbol)
+		if !ok {
+			return
+		}
+		candles, err := newCandles(p.Data)
+		if err != nil {
+			ws.client.ReportErr(err)
+			return
+		}
+		ws.chans.Candles <- cq.CandleUpdMsg{
+			Type:    cq.CandleSnapshot,
+			Pair:    pair,
+			Candles: candles,
+		}
+	case CandlesParams:
+		pair, ok := ws.pair(p.Symbol)
+		if !ok {
+			return
+		}
+		candles, err := newCandles(p.Data)
+		if err != nil {
+			ws.client.ReportErr(err)
+			return
+		}
+		ws.chans.Candles <- cq.CandleUpdMsg{
+			Type:    cq.CandleUpd,
+			Pair:    pair,
+			Candles: candles,
+		}
+	}
+}
+
+// pair returns pair of symbol in stream message
+// Symbols that cannot be resolved are reported so their message is dropped
+func (ws *WSCtlr) pair(symbol string) (cq.Pair, bool) {
+	p, err := ws.rest.NewPair(symbol)
+	if err != nil {
+		ws.client.ReportErr(err)
+		return cq.Pair{}, false
+	}
+	return p, true
+}
+
+// sendBook sends top levels of order book to main event loop
+func (ws *WSCtlr) sendBook(symbol string, book *cq.OrderBook) {
+	if ws.chans.Book == nil {
+		return
+	}
+	pair, ok := ws.pair(symbol)
+	if !ok {
+		return
+	}
+	ws.chans.Book <- cq.BookUpdMsg{
+		Pair: pair,
+		Bids: book.Bids(bookDepth),
+		Asks: book.Asks(bookDepth),
+	}
+}
+
+// resyncBook drops local order book and subscribes again so server sends
+// a new snapshot
+// Updates are ignored until snapshot arrives
+func (ws *WSCtlr) resyncBook(symbol string) {
+	delete(ws.books, symbol)
+
+	err := ws.Send(cq.WSRequest{
+		Msg: SubscribeMsg{
+			Method: "subscribeOrderbook",
+			Params: map[string]string{
+				"symbol": symbol,
+			},
+		},
+	})
+	if err != nil {
+		ws.client.ReportErr(fmt.Errorf("orderbook %v: resync failed: %v", symbol, err))
+	}
+}
+
+// newLevels converts order book levels to cq.BookLevel
+func newLevels(levels []BookLevel) []cq.BookLevel {
+	result := make([]cq.BookLevel, 0, len(levels))
+	for _, l := range levels {
+		result = append(result, cq.BookLevel{
+			Price: l.Price,
+			Size:  l.Size,
+		})
+	}
+	return result
+}
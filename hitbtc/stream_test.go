@@ -0,0 +1,227 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/internal/fakeapi"
+)
+
+// ack answers request with id of msg
+func ack(msg SubscribeMsg) interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "result": true, "id": msg.ID}
+}
+
+// reject answers request with id of msg with HitBTC's unknown symbol error
+func reject(msg SubscribeMsg) interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"error": map[string]interface{}{
+			"code":        2001,
+			"message":     "Symbol not found",
+			"description": "Try get /api/2/public/symbol, to get list of all available symbols.",
+		},
+		"id": msg.ID,
+	}
+}
+
+// ackKnown acks requests for all symbols except BADUSD which is rejected
+func ackKnown(msg SubscribeMsg) interface{} {
+	if msg.Params["symbol"] == "BADUSD" {
+		return reject(msg)
+	}
+	return ack(msg)
+}
+
+// newFakeFeed returns feed standing in for HitBTC's websocket api which
+// answers requests with reply unless reply returns nil
+func newFakeFeed(reply func(SubscribeMsg) interface{}) *fakeapi.Feed {
+	return fakeapi.NewFeed(func(b []byte) interface{} {
+		msg := SubscribeMsg{}
+		json.Unmarshal(b, &msg)
+		return reply(msg)
+	})
+}
+
+// next returns next request received by feed
+func next(t *testing.T, f *fakeapi.Feed) SubscribeMsg {
+	t.Helper()
+	msg := SubscribeMsg{}
+	f.Next(t, &msg)
+	return msg
+}
+
+// startStream connects to feed and starts streaming quotes for pairs
+func startStream(t *testing.T, f *fakeapi.Feed, quotes chan cq.UpdateMsg, pairs ...cq.Pair) *WSCtlr {
+	t.Helper()
+	ws, err := Connect(f.WSURL(), REST{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Stream(cq.StreamChans{
+		Quotes: quotes,
+		Trades: make(chan cq.Trade, 10),
+	}, pairs...)
+	if err != nil {
+		ws.Shutdown()
+		t.Fatal(err)
+	}
+	return ws
+}
+
+// waitReconnecting drains status changes until connection is lost
+func waitReconnecting(t *testing.T, ws *WSCtlr) {
+	t.Helper()
+	for {
+		select {
+		case msg := <-ws.Status():
+			if msg.State == cq.Reconnecting {
+				return
+			}
+		case <-time.After(fakeapi.Wait):
+			t.Fatal("connection was not lost")
+		}
+	}
+}
+
+func TestResubscribe(t *testing.T) {
+	f := newFakeFeed(ack)
+	defer f.Close()
+	quotes := make(chan cq.UpdateMsg, 10)
+	ws := startStream(t, f, quotes, mustPair("BTCUSD"))
+	defer ws.Shutdown()
+
+	for _, want := range []string{"subscribeTicker", "subscribeTrades"} {
+		if msg := next(t, f); msg.Method != want || msg.Params["symbol"] != "BTCUSD" {
+			t.Fatalf("got %+v, want %v BTCUSD", msg, want)
+		}
+	}
+
+	f.Drop <- struct{}{}
+	waitReconnecting(t, ws)
+
+	replayed := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		msg := next(t, f)
+		replayed[msg.Method+":"+msg.Params["symbol"]] = true
+	}
+	if !replayed["subscribeTicker:BTCUSD"] || !replayed["subscribeTrades:BTCUSD"] {
+		t.Errorf("replayed %v, want ticker and trades of BTCUSD", replayed)
+	}
+
+	f.Send <- map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "ticker",
+		"params": map[string]interface{}{
+			"symbol": "BTCUSD",
+			"ask":    "7000.02",
+			"bid":    "7000.00",
+			"last":   "7000.01",
+			"open":   "6900.00",
+			"low":    "6800.00",
+			"high":   "7100.00",
+			"volume": "100",
+		},
+	}
+	select {
+	case upd := <-quotes:
+		if upd.Type != cq.TickerUpd || upd.Quote.ID != mustPair("BTCUSD") || upd.Quote.Ask != "7000.02" {
+			t.Errorf("got %+v, want BTCUSD ticker on new connection", upd)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("no ticker update after reconnect")
+	}
+}
+
+func TestErrorReachesCaller(t *testing.T) {
+	// BADUSD is answered by test after ETHBTC so responses arrive out of order
+	f := newFakeFeed(func(msg SubscribeMsg) interface{} {
+		if msg.Params["symbol"] == "BADUSD" {
+			return nil
+		}
+		return ack(msg)
+	})
+	defer f.Close()
+	ws := startStream(t, f, make(chan cq.UpdateMsg, 10))
+	defer ws.Shutdown()
+
+	bad := make(chan error, 1)
+	go func() {
+		bad <- ws.SubTrades(mustPair("BADUSD"))
+	}()
+	badMsg := next(t, f)
+
+	if err := ws.SubTrades(mustPair("ETHBTC")); err != nil {
+		t.Errorf("ETHBTC got error %v meant for another request", err)
+	}
+
+	f.Send <- reject(badMsg)
+	select {
+	case err := <-bad:
+		if err == nil || !strings.Contains(err.Error(), "BADUSD (Symbol not found") {
+			t.Errorf("got error %v, want server's rejection of BADUSD", err)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("BADUSD request was not answered")
+	}
+}
+
+func TestRequestWhileReconnecting(t *testing.T) {
+	f := newFakeFeed(ackKnown)
+	defer f.Close()
+	ws := startStream(t, f, make(chan cq.UpdateMsg, 10), mustPair("BTCUSD"))
+	defer ws.Shutdown()
+	next(t, f)
+	next(t, f)
+
+	f.Drop <- struct{}{}
+	waitReconnecting(t, ws)
+
+	// requests are sent after subscriptions are replayed and answered by server
+	if err := ws.SubTrades(mustPair("ETHBTC")); err != nil {
+		t.Errorf("unexpected error for request made while reconnecting: %v", err)
+	}
+	err := ws.SubTrades(mustPair("BADUSD"))
+	if err == nil || !strings.Contains(err.Error(), "Symbol not found") {
+		t.Errorf("got error %v, want server's rejection", err)
+	}
+
+	got := []string{}
+	for i := 0; i < 4; i++ {
+		msg := next(t, f)
+		got = append(got, msg.Method+":"+msg.Params["symbol"])
+	}
+	if got[2] != "subscribeTrades:ETHBTC" || got[3] != "subscribeTrades:BADUSD" {
+		t.Errorf("got requests %v, want replayed BTCUSD followed by ETHBTC and BADUSD", got)
+	}
+}
+
+func TestUnsplitSymbol(t *testing.T) {
+	f := newFakeFeed(ack)
+	defer f.Close()
+	quotes := make(chan cq.UpdateMsg, 10)
+	ws := startStream(t, f, quotes)
+	defer ws.Shutdown()
+
+	f.Send <- map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "ticker",
+		"params":  map[string]interface{}{"symbol": "AB", "ask": "1", "bid": "1"},
+	}
+	select {
+	case err := <-ws.Errors():
+		if !strings.Contains(err.Error(), `"AB"`) {
+			t.Errorf("got error %v, want unsplit symbol AB", err)
+		}
+	case <-time.After(fakeapi.Wait):
+		t.Fatal("unsplit symbol was not reported")
+	}
+	select {
+	case upd := <-quotes:
+		t.Errorf("got update %+v for unsplit symbol", upd)
+	default:
+	}
+}
@@ -0,0 +1,146 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WSMsg contains data from websocket messages
+// Notifications set Method and Params which is decoded into a typed struct
+// by decodeParams
+// Responses to requests set ID and either Result or Error
+type WSMsg struct {
+	VersionJSON string          `json:"jsonrpc"`
+	Method      string          `json:"method"`
+	Params      json.RawMessage `json:"params"`
+	ID          *int64          `json:"id"`
+	Result      json.RawMessage `json:"result"`
+	Error       *RPCError       `json:"error"`
+}
+
+// RPCError contains error returned by API in response to a request
+// https://api.hitbtc.com/#error-response
+type RPCError struct {
+	Code        int    `json:"code"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+}
+
+// Error returns error message with description if one is given
+func (e *RPCError) Error() string {
+	if len(e.Description) > 0 {
+		return fmt.Sprintf("%v: %v", e.Message, e.Description)
+	}
+	return e.Message
+}
+
+// TickerParams holds params of "ticker" notification
+// https://api.hitbtc.com/#subscribe-to-ticker
+type TickerParams struct {
+	Symbol      string `json:"symbol"`
+	Ask         string `json:"ask"`
+	Bid         string `json:"bid"`
+	Last        string `json:"last"`
+	Open        string `json:"open"`
+	Low         string `json:"low"`
+	High        string `json:"high"`
+	Volume      string `json:"volume"`
+	VolumeQuote string `json:"volumeQuote"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// TradesParams holds params of "snapshotTrades" and "updateTrades"
+// notifications
+// https://api.hitbtc.com/#subscribe-to-trades
+type TradesParams struct {
+	Symbol string       `json:"symbol"`
+	Data   []TradeEntry `json:"data"`
+}
+
+// CandlesParams holds params of "snapshotCandles" and "updateCandles"
+// notifications
+// https://api.hitbtc.com/#subscribe-to-candles
+type CandlesParams struct {
+	Symbol string        `json:"symbol"`
+	Period string        `json:"period"`
+	Data   []CandleEntry `json:"data"`
+}
+
+// BookLevel is a single price level of the order book
+type BookLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+// OrderbookParams holds params of "snapshotOrderbook" and "updateOrderbook"
+// notifications
+// https://api.hitbtc.com/#subscribe-to-orderbook
+type OrderbookParams struct {
+	Symbol   string      `json:"symbol"`
+	Sequence int64       `json:"sequence"`
+	Ask      []BookLevel `json:"ask"`
+	Bid      []BookLevel `json:"bid"`
+}
+
+// snapshotTrades, snapshotCandles and snapshotOrderbook wrap params so
+// snapshots can be told apart from updates after decoding
+type snapshotTrades struct{ TradesParams }
+type snapshotCandles struct{ CandlesParams }
+type snapshotOrderbook struct{ OrderbookParams }
+
+// errUnknownMethod is returned by decodeParams for notifications that are
+// not handled
+var errUnknownMethod = errors.New("unknown method")
+
+// decodeParams decodes params of notification into typed struct for its Method
+// Returned value is one of TickerParams, TradesParams, snapshotTrades,
+// CandlesParams, snapshotCandles, OrderbookParams or snapshotOrderbook
+func decodeParams(msg WSMsg) (interface{}, error) {
+	switch msg.Method {
+	case "ticker":
+		p := TickerParams{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "snapshotTrades":
+		p := snapshotTrades{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "updateTrades":
+		p := TradesParams{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "snapshotCandles":
+		p := snapshotCandles{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "updateCandles":
+		p := CandlesParams{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "snapshotOrderbook":
+		p := snapshotOrderbook{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	case "updateOrderbook":
+		p := OrderbookParams{}
+		err := unmarshalParams(msg, &p, &p.Symbol)
+		return p, err
+	}
+	return nil, errUnknownMethod
+}
+
+// unmarshalParams decodes params into v and checks that symbol was set
+func unmarshalParams(msg WSMsg, v interface{}, symbol *string) error {
+	if len(msg.Params) == 0 {
+		return fmt.Errorf("malformed %v message: missing params", msg.Method)
+	}
+	err := json.Unmarshal(msg.Params, v)
+	if err != nil {
+		return fmt.Errorf("malformed %v message: %v", msg.Method, err)
+	}
+	if len(*symbol) == 0 {
+		return fmt.Errorf("malformed %v message: missing symbol", msg.Method)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package hitbtc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// quoteSuffixes lists quote currencies used to split symbols that are not
+// in registry
+// Longer currencies come first so USDT is matched before USD.  Stablecoins
+// ending in USD such as TUSD are left out so USDTUSD splits as USDT/USD.
+var quoteSuffixes = []string{
+	"USDT", "USDC", "EURS",
+	"DAI", "PAX", "BTC", "ETH", "USD", "EUR", "EOS", "TRX",
+}
+
+// symbolRegistry maps HitBTC symbols to pairs using base and quote
+// currencies from symbol metadata
+// Nil registry holds no symbols.
+type symbolRegistry struct {
+	sync.RWMutex
+
+	pairs   map[string]cq.Pair
+	symbols map[cq.Pair]string
+}
+
+// newSymbolRegistry returns registry without symbols
+func newSymbolRegistry() *symbolRegistry {
+	return &symbolRegistry{
+		pairs:   map[string]cq.Pair{},
+		symbols: map[cq.Pair]string{},
+	}
+}
+
+// add registers symbol for pair
+func (r *symbolRegistry) add(symbol string, p cq.Pair) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+
+	r.pairs[symbol] = p
+	r.symbols[p] = symbol
+}
+
+// pair returns registered pair of symbol
+func (r *symbolRegistry) pair(symbol string) (cq.Pair, bool) {
+	if r == nil {
+		return cq.Pair{}, false
+	}
+	r.RLock()
+	defer r.RUnlock()
+
+	p, ok := r.pairs[symbol]
+	return p, ok
+}
+
+// symbol returns registered symbol of pair
+func (r *symbolRegistry) symbol(p cq.Pair) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	r.RLock()
+	defer r.RUnlock()
+
+	s, ok := r.symbols[p]
+	return s, ok
+}
+
+// splitSymbol returns pair of symbol by matching known quote currencies at
+// end of symbol
+// Symbols without a known quote currency are split after 3 letters if both
+// currencies have at least 3 letters.  Other symbols return an error.
+func splitSymbol(s string) (cq.Pair, error) {
+	for _, q := range quoteSuffixes {
+		if len(s) > len(q) && strings.HasSuffix(s, q) {
+			return cq.NewMarketPair(cq.HitBTC, s[:len(s)-len(q)], q, cq.Spot), nil
+		}
+	}
+	if len(s) < 6 {
+		return cq.Pair{}, fmt.Errorf("unable to split hitbtc symbol %q into currencies", s)
+	}
+	return cq.NewMarketPair(cq.HitBTC, s[:3], s[3:], cq.Spot), nil
+}
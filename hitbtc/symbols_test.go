@@ -0,0 +1,251 @@
+package hitbtc
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// record replaces symbols fixture with response of HitBTC's api
+// go test ./hitbtc -run TestSymbolRoundTrip -record
+// Tests only assume the fixture lists symbols of default watchlist and the
+// symbols named in TestSymbolCurrencies.
+var record = flag.Bool("record", false, "record testdata/symbols.json from HitBTC api")
+
+// mustPair returns pair of symbol split by NewPair and panics if it cannot be
+// split
+func mustPair(s string) cq.Pair {
+	p, err := NewPair(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// loadSymbols returns symbol metadata fixture, REST client with its symbols
+// registered from server serving fixture and instruments it returned
+func loadSymbols(t *testing.T) ([]SymbolsResp, REST, []cq.Instrument) {
+	t.Helper()
+	if *record {
+		recordSymbols(t)
+	}
+	body, err := ioutil.ReadFile("testdata/symbols.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := []SymbolsResp{}
+	err = json.Unmarshal(body, &symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/public/symbol" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	rest := NewREST()
+	rest.API = srv.URL
+	instruments, err := rest.GetInstruments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instruments) != len(symbols) {
+		t.Fatalf("got %v instruments, want %v", len(instruments), len(symbols))
+	}
+	for i, s := range symbols {
+		want := cq.NewMarketPair(cq.HitBTC, s.BaseCurrency, s.QuoteCurrency, cq.Spot)
+		if instruments[i].Pair != want {
+			t.Errorf("instrument %v pair = %v, want %v", s.ID, instruments[i].Pair.Key(), want.Key())
+		}
+	}
+	return symbols, rest, instruments
+}
+
+// recordSymbols writes response of HitBTC's symbol endpoint to fixture
+func recordSymbols(t *testing.T) {
+	t.Helper()
+	resp, err := http.Get(restAPI + "/public/symbol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("symbols request failed: %v", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile("testdata/symbols.json", body, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSymbolRoundTrip(t *testing.T) {
+	symbols, rest, _ := loadSymbols(t)
+
+	for _, s := range symbols {
+		t.Run(s.ID, func(t *testing.T) {
+			want := cq.NewMarketPair(cq.HitBTC, s.BaseCurrency, s.QuoteCurrency, cq.Spot)
+			if p, ok := rest.symbols.pair(s.ID); !ok || p != want {
+				t.Errorf("registry pair of %v = %v, want %v", s.ID, p.Key(), want.Key())
+			}
+			p, err := rest.NewPair(s.ID)
+			if err != nil || p != want {
+				t.Errorf("NewPair(%v) = %v, want %v", s.ID, p.Key(), want.Key())
+			}
+			if got := rest.NewSymbol(p); got != s.ID {
+				t.Errorf("NewSymbol(%v) = %v, want %v", p.Key(), got, s.ID)
+			}
+		})
+	}
+}
+
+func TestDefaultSymbols(t *testing.T) {
+	_, rest, _ := loadSymbols(t)
+
+	for _, s := range defaultSymbols {
+		p, ok := rest.symbols.pair(s)
+		if !ok {
+			t.Errorf("%v missing from symbol metadata", s)
+			continue
+		}
+		if got := rest.NewSymbol(p); got != s {
+			t.Errorf("NewSymbol(%v) = %v, want %v", p.Key(), got, s)
+		}
+	}
+}
+
+func TestSymbolCurrencies(t *testing.T) {
+	_, rest, _ := loadSymbols(t)
+
+	tests := []struct {
+		symbol string
+		base   string
+		quote  string
+	}{
+		{"USDTUSD", "USDT", "USD"},
+		{"DOGEUSD", "DOGE", "USD"},
+		{"DOGEBTC", "DOGE", "BTC"},
+		{"EOSDAIUSD", "EOSDAI", "USD"},
+		{"EOSDAI", "EOS", "DAI"},
+		// quote currency ending in USD is only split correctly from metadata
+		{"BTCTUSD", "BTC", "TUSD"},
+		{"XRPUSDT", "XRP", "USDT"},
+		{"IOTAUSD", "IOTA", "USD"},
+		{"PAXGBTC", "PAXG", "BTC"},
+		{"MATICETH", "MATIC", "ETH"},
+		{"BCHABCUSD", "BCHABC", "USD"},
+	}
+	for _, test := range tests {
+		p, err := rest.NewPair(test.symbol)
+		if err != nil {
+			t.Errorf("NewPair(%v): %v", test.symbol, err)
+			continue
+		}
+		if p.BaseCurrency() != test.base || p.QuoteCurrency() != test.quote {
+			t.Errorf("NewPair(%v) = %v/%v, want %v/%v", test.symbol, p.BaseCurrency(), p.QuoteCurrency(), test.base, test.quote)
+		}
+	}
+}
+
+func TestInstrumentRules(t *testing.T) {
+	_, rest, instruments := loadSymbols(t)
+
+	for _, i := range instruments {
+		if p, _ := rest.NewPair("DOGEBTC"); i.Pair != p {
+			continue
+		}
+		if places, _ := i.PricePlaces(); places != 11 {
+			t.Errorf("price places = %v, want 11", places)
+		}
+		if places, ok := i.SizePlaces(); !ok || places != 0 {
+			t.Errorf("size places = %v, want 0", places)
+		}
+		if i.FeeCurrency != "BTC" {
+			t.Errorf("fee currency = %v, want BTC", i.FeeCurrency)
+		}
+		return
+	}
+	t.Error("DOGEBTC instrument missing")
+}
+
+func TestSplitSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		base   string
+		quote  string
+	}{
+		{"BTCUSD", "BTC", "USD"},
+		{"USDTUSD", "USDT", "USD"},
+		{"XRPUSDT", "XRP", "USDT"},
+		{"DOGEUSDC", "DOGE", "USDC"},
+		{"EOSDAIUSD", "EOSDAI", "USD"},
+		{"BCHABCEURS", "BCHABC", "EURS"},
+		{"PAXGBTC", "PAXG", "BTC"},
+		{"BTCPAX", "BTC", "PAX"},
+		{"EOSTRX", "EOS", "TRX"},
+		// stablecoins ending in USD are left out of suffixes
+		{"BTCTUSD", "BTCT", "USD"},
+		// symbols without known quote split after 3 letters
+		{"XMRXYZ", "XMR", "XYZ"},
+		{"XMRXYZW", "XMR", "XYZW"},
+	}
+	for _, test := range tests {
+		p, err := splitSymbol(test.symbol)
+		if err != nil {
+			t.Errorf("splitSymbol(%v): %v", test.symbol, err)
+			continue
+		}
+		if p.BaseCurrency() != test.base || p.QuoteCurrency() != test.quote {
+			t.Errorf("splitSymbol(%v) = %v/%v, want %v/%v", test.symbol, p.BaseCurrency(), p.QuoteCurrency(), test.base, test.quote)
+		}
+		if p.Exchange() != cq.HitBTC {
+			t.Errorf("splitSymbol(%v) exchange = %v, want HitBTC", test.symbol, p.Exchange())
+		}
+	}
+}
+
+func TestSplitSymbolError(t *testing.T) {
+	// currencies would be empty or shorter than 3 letters
+	for _, s := range []string{"", "AB", "USD", "USDT", "ABCDE"} {
+		p, err := splitSymbol(s)
+		if err == nil {
+			t.Errorf("splitSymbol(%q) = %v, want error", s, p.Key())
+		}
+		if _, err := NewREST().NewPair(s); err == nil {
+			t.Errorf("REST.NewPair(%q) returned no error", s)
+		}
+	}
+}
+
+func TestSymbolFallback(t *testing.T) {
+	// REST without registry and symbols missing from metadata are split
+	// XMRXYZ is not listed by HitBTC so it stays missing from recordings
+	_, loaded, _ := loadSymbols(t)
+	for _, rest := range []REST{{}, NewREST(), loaded} {
+		p, err := rest.NewPair("XMRXYZ")
+		if err != nil || p != mustPair("XMRXYZ") {
+			t.Errorf("NewPair(XMRXYZ) = %v, want split symbol", p.Key())
+		}
+		if s := rest.NewSymbol(p); s != "XMRXYZ" {
+			t.Errorf("NewSymbol(%v) = %v, want XMRXYZ", p.Key(), s)
+		}
+	}
+
+	// registries of separate REST values are independent
+	if p, _ := NewREST().NewPair("BTCTUSD"); p.QuoteCurrency() != "USD" {
+		t.Errorf("new registry resolved BTCTUSD to %v", p.Key())
+	}
+}
@@ -0,0 +1,180 @@
+// Package fakeapi provides fake REST and websocket servers standing in for
+// exchange apis in adapter tests
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// Wait limits time tests wait for a message
+const Wait = 2 * time.Second
+
+// NewREST returns server which serves routes by path
+// Other paths are answered with status 404 and notFound as body.  Server
+// must be closed by caller.
+func NewREST(routes map[string]http.HandlerFunc, notFound string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(notFound))
+			return
+		}
+		h(w, r)
+	}))
+}
+
+// Respond returns handler that writes body
+func Respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}
+}
+
+// Feed is a websocket server standing in for an exchange's websocket api
+// Each message from client is sent to Received and answered with reply
+// unless reply returns nil.  Values sent to Send are written as JSON and
+// strings as is.  Sending to Drop closes current connection.
+type Feed struct {
+	*httptest.Server
+	Received chan []byte
+	Send     chan interface{}
+	Drop     chan struct{}
+}
+
+// NewFeed starts feed which answers messages with reply
+// Feed must be closed by caller
+func NewFeed(reply func(msg []byte) interface{}) *Feed {
+	f := &Feed{
+		Received: make(chan []byte, 20),
+		Send:     make(chan interface{}, 20),
+		Drop:     make(chan struct{}),
+	}
+	upgrader := websocket.Upgrader{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case msg := <-f.Send:
+					if s, ok := msg.(string); ok {
+						conn.WriteMessage(websocket.TextMessage, []byte(s))
+						continue
+					}
+					conn.WriteJSON(msg)
+				case <-f.Drop:
+					conn.Close()
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		for {
+			_, b, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			f.Received <- b
+			if resp := reply(b); resp != nil {
+				f.Send <- resp
+			}
+		}
+	}))
+	return f
+}
+
+// WSURL returns websocket url of feed
+func (f *Feed) WSURL() string {
+	return "ws" + strings.TrimPrefix(f.URL, "http")
+}
+
+// Next decodes next message received from client into v
+func (f *Feed) Next(t *testing.T, v interface{}) {
+	t.Helper()
+	select {
+	case b := <-f.Received:
+		if err := json.Unmarshal(b, v); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(Wait):
+		t.Fatal("no request received")
+	}
+}
+
+// Chans holds buffered channels passed to Stream
+type Chans struct {
+	Quotes  chan cq.UpdateMsg
+	Candles chan cq.CandleUpdMsg
+	Trades  chan cq.Trade
+}
+
+// NewChans returns channels with room for a few messages each
+func NewChans() Chans {
+	return Chans{
+		Quotes:  make(chan cq.UpdateMsg, 10),
+		Candles: make(chan cq.CandleUpdMsg, 10),
+		Trades:  make(chan cq.Trade, 10),
+	}
+}
+
+// Stream returns channels as passed to Stream
+func (c Chans) Stream() cq.StreamChans {
+	return cq.StreamChans{
+		Quotes:  c.Quotes,
+		Candles: c.Candles,
+		Trades:  c.Trades,
+	}
+}
+
+// Quote returns next quote update
+func (c Chans) Quote(t *testing.T) cq.UpdateMsg {
+	t.Helper()
+	select {
+	case upd := <-c.Quotes:
+		return upd
+	case <-time.After(Wait):
+		t.Fatal("no quote update")
+	}
+	return cq.UpdateMsg{}
+}
+
+// Trade returns next trade
+func (c Chans) Trade(t *testing.T) cq.Trade {
+	t.Helper()
+	select {
+	case trade := <-c.Trades:
+		return trade
+	case <-time.After(Wait):
+		t.Fatal("no trade")
+	}
+	return cq.Trade{}
+}
+
+// CandleUpd returns next candle update
+func (c Chans) CandleUpd(t *testing.T) cq.CandleUpdMsg {
+	t.Helper()
+	select {
+	case upd := <-c.Candles:
+		return upd
+	case <-time.After(Wait):
+		t.Fatal("no candle update")
+	}
+	return cq.CandleUpdMsg{}
+}
@@ -1,103 +1,531 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"fyne.io/fyne"
 	"fyne.io/fyne/app"
 	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
 
 	"github.com/3cb/cq-gui/cq"
-	"github.com/3cb/cq-gui/hitbtc"
 )
 
 func main() {
-	app := app.New()
-	w := app.NewWindow("Crypto Quotes")
-	w.Resize(fyne.NewSize(1500, 1000))
+	a := app.New()
+
+	// saving is disabled if config can't be read so it isn't overwritten
+	path, cfgErr := cq.ConfigPath()
+	if cfgErr != nil {
+		cfgErr = fmt.Errorf("unable to find config directory: %v", cfgErr)
+		fmt.Fprintln(os.Stderr, cfgErr)
+	}
+	cfg := cq.DefaultConfig()
+	if path != "" {
+		var err error
+		cfg, err = cq.LoadConfig(path)
+		if err != nil {
+			cfgErr = fmt.Errorf("unable to load config: %v", err)
+			fmt.Fprintln(os.Stderr, cfgErr)
+			cfg, path = cq.DefaultConfig(), ""
+		}
+	}
+	if _, ok := registry[cfg.Exchange]; !ok {
+		cfg.Exchange = cq.DefaultConfig().Exchange
+	}
+	setTheme(a, cfg.Theme)
+
+	w := a.NewWindow("Crypto Quotes")
+	w.Resize(fyne.NewSize(cfg.Window.Width, cfg.Window.Height))
 	w.CenterOnScreen()
 
-	// create exchange with initial state set
-	e, err := hitbtc.New()
+	// start streaming from last used exchange
+	s, err := startSession(cfg.Exchange, cfg)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to start %v: %v\n", cfg.Exchange, err)
 		os.Exit(1)
 	}
 
-	// get initial quotes from rest api
-	initQuotes, err := hitbtc.GetQuotes(e.GetWatchedPairs()...)
+	u := newUI(a, w, cfg, path, cfgErr, s)
+	u.show()
+	s.run(u.status, u.lastErr, u.onAlert)
+	w.SetOnClosed(u.saveWindow)
+
+	w.ShowAndRun()
+}
+
+// setTheme applies theme from config
+func setTheme(a fyne.App, name string) {
+	switch name {
+	case cq.LightTheme:
+		a.Settings().SetTheme(theme.LightTheme())
+	default:
+		a.Settings().SetTheme(theme.DarkTheme())
+	}
+}
+
+// ui holds window controls shared by all sessions
+type ui struct {
+	sync.Mutex
+
+	app      fyne.App
+	w        fyne.Window
+	cfg      cq.Config
+	cfgPath  string
+	selector *widget.Select
+	add      *widget.Button
+	theme    *widget.Select
+	grouping *widget.Select
+	colors   *widget.Select
+	filter   *widget.Button
+	alerts   *widget.Button
+	holdings *widget.Button
+	// reference sets currency of watchlist's reference columns
+	reference *widget.Select
+	// aggregate sets window in which history merges trades
+	aggregate *widget.Select
+	// bookGrouping is applied to order book of each session
+	bookGrouping int
+	status       *widget.Label
+	lastErr      *widget.Label
+	// unsaved tells user why settings are not saved
+	unsaved *widget.Label
+	current *session
+}
+
+// newUI creates window controls with exchange selector set to session's exchange
+// Settings are saved to cfgPath unless it is empty.  cfgErr is shown as the
+// reason settings are not saved.
+func newUI(a fyne.App, w fyne.Window, cfg cq.Config, cfgPath string, cfgErr error, s *session) *ui {
+	u := &ui{
+		app:          a,
+		w:            w,
+		cfg:          cfg,
+		cfgPath:      cfgPath,
+		bookGrouping: 1,
+		status:       widget.NewLabel(""),
+		lastErr:      widget.NewLabel(""),
+		unsaved:      widget.NewLabel(""),
+		current:      s,
+	}
+	if cfgPath == "" {
+		u.unsaved.SetText(fmt.Sprintf("settings will not be saved (%v)", cfgErr))
+	}
+
+	names := []string{}
+	for _, id := range exchangeIDs {
+		names = append(names, id.String())
+	}
+	u.selector = widget.NewSelect(names, func(name string) {
+		for _, id := range exchangeIDs {
+			if id.String() == name {
+				go u.switchExchange(id)
+			}
+		}
+	})
+	u.selector.Selected = s.id.String()
+	u.add = widget.NewButton("Add Pair", func() {
+		go u.showPicker()
+	})
+	u.theme = widget.NewSelect([]string{cq.DarkTheme, cq.LightTheme}, func(name string) {
+		go u.setTheme(name)
+	})
+	u.theme.Selected = cfg.Theme
+	groupings := []string{}
+	for _, g := range cq.BookGroupings {
+		groupings = append(groupings, fmt.Sprintf("%dx", g))
+	}
+	u.grouping = widget.NewSelect(groupings, func(name string) {
+		for i, g := range groupings {
+			if g == name {
+				go u.setGrouping(cq.BookGroupings[i])
+			}
+		}
+	})
+	u.grouping.Selected = groupings[0]
+	modes := []cq.ColorMode{cq.ColorBySide, cq.ColorByTick}
+	u.colors = widget.NewSelect([]string{modes[0].String(), modes[1].String()}, func(name string) {
+		for _, m := range modes {
+			if m.String() == name {
+				go u.setColorMode(m)
+			}
+		}
+	})
+	u.colors.Selected = cfg.History.ColorMode.String()
+	windows := []int{0, 250, 1000, 5000}
+	names = []string{}
+	for _, ms := range windows {
+		names = append(names, aggregateName(ms))
+	}
+	u.aggregate = widget.NewSelect(names, func(name string) {
+		for _, ms := range windows {
+			if aggregateName(ms) == name {
+				go u.setAggregation(ms)
+			}
+		}
+	})
+	u.aggregate.Selected = aggregateName(cfg.History.Aggregate)
+	u.filter = widget.NewButton("Trade Filter", func() {
+		go u.showFilter()
+	})
+	u.alerts = widget.NewButton("Alerts", func() {
+		go u.showAlerts()
+	})
+	u.reference = widget.NewSelect(referenceCurrencies, func(name string) {
+		go u.setReference(name)
+	})
+	u.reference.Selected = referenceName(cfg.Reference)
+	u.holdings = widget.NewButton("Portfolio", func() {
+		go u.showPortfolio()
+	})
+
+	return u
+}
+
+// setTheme applies theme and saves it
+func (u *ui) setTheme(name string) {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.cfg.Theme == name {
+		return
+	}
+	setTheme(u.app, name)
+	u.cfg.Theme = name
+	u.save()
+}
+
+// setGrouping sets price grouping of order book
+func (u *ui) setGrouping(grouping int) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.bookGrouping = grouping
+	u.current.book.SetGrouping(grouping)
+}
+
+// setColorMode sets how history colors trades and saves it
+func (u *ui) setColorMode(mode cq.ColorMode) {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.cfg.History.ColorMode == mode {
+		return
+	}
+	err := u.current.setColorMode(mode)
 	if err != nil {
-		os.Exit(1)
+		u.lastErr.SetText(err.Error())
+		return
 	}
-	for _, q := range initQuotes {
-		e.UpdateQuote(cq.UpdateMsg{
-			Quote: q,
-			Type:  cq.InitUpd,
-		})
+	u.cfg.History.ColorMode = mode
+	u.save()
+}
+
+// aggregateName returns name of aggregation window shown in selector
+func aggregateName(ms int) string {
+	if ms <= 0 {
+		return "off"
 	}
+	return time.Duration(ms * int(time.Millisecond)).String()
+}
 
-	// set selected Pair
-	selectedPair := e.GetWatchedPairs()[0]
+// setAggregation sets window in which history merges trades and saves it
+func (u *ui) setAggregation(ms int) {
+	u.Lock()
+	defer u.Unlock()
 
-	// get initial trades from rest api
-	initTrades, err := hitbtc.GetTrades(selectedPair)
+	if u.cfg.History.Aggregate == ms {
+		return
+	}
+	err := u.current.setAggregation(ms)
 	if err != nil {
-		os.Exit(1)
+		u.lastErr.SetText(err.Error())
+		return
 	}
-	history := cq.NewHistory(selectedPair, initTrades)
+	u.cfg.History.Aggregate = ms
+	u.save()
+}
+
+// saveWindow saves window size when window is closed
+func (u *ui) saveWindow() {
+	u.Lock()
+	defer u.Unlock()
+
+	size := u.w.Canvas().Size()
+	u.cfg.Window = cq.WindowCfg{
+		Width:  size.Width,
+		Height: size.Height,
+	}
+	u.save()
+}
 
-	// launch streaming
-	//
-	// quote router
-	router := cq.StartRouter(e.GetWatchedPairs())
-	toRouter, fromRouter := router.GetQuoteIn(), router.GetQuoteOut()
-	// candle channel
-	candleCh := make(chan cq.CandleUpdMsg)
-	// history router
-	histRouter := cq.StartHistoryRouter(selectedPair, initTrades[0].ID)
-	historyIn, historyOut := histRouter.GetChannels()
+// save records current session's settings and writes config file
+// Settings are only kept in memory if saving is disabled so sessions started
+// later still restore them
+// Must be called with lock held
+func (u *ui) save() {
+	u.cfg.Exchange = u.current.id
+	u.cfg.Exchanges[u.current.id.String()] = u.current.settings()
+	if u.cfgPath == "" {
+		return
+	}
 
-	ws, err := hitbtc.NewWSCtlr()
+	err := cq.SaveConfig(u.cfgPath, u.cfg)
 	if err != nil {
-		os.Exit(1)
+		u.lastErr.SetText(fmt.Sprintf("unable to save settings: %v", err))
+	}
+}
+
+// switchExchange starts session for exchange and stops current session
+// Current session keeps streaming if new session fails to start
+func (u *ui) switchExchange(id cq.ExchangeID) {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.current.id == id {
+		return
+	}
+
+	u.status.SetText(fmt.Sprintf("%v: %v", id, cq.Connecting))
+	u.lastErr.SetText("")
+	next, err := startSession(id, u.cfg)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to switch to %v: %v", id, err))
+		u.selector.SetSelected(u.current.id.String())
+		return
+	}
+
+	u.current.stop()
+	u.current = next
+	next.book.SetGrouping(u.bookGrouping)
+	u.show()
+	next.run(u.status, u.lastErr, u.onAlert)
+	u.save()
+}
+
+// selectPair retargets current session's history and chart to pair
+func (u *ui) selectPair(pair cq.Pair) {
+	u.Lock()
+	defer u.Unlock()
+
+	err := u.current.selectPair(pair)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to select %v: %v", pair, err))
 	}
+	u.save()
+	// history widget is replaced once new pair is selected
+	u.show()
+}
 
-	// create chart
-	candles, err := hitbtc.GetCandles(selectedPair, 5)
-	cfg := cq.ChartCfg{
-		MaxBars:  100,
-		Interval: 5,
+// loadOlder shows older trades at bottom of current session's history
+func (u *ui) loadOlder() {
+	u.Lock()
+	defer u.Unlock()
+
+	err := u.current.loadOlder()
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to load older trades: %v", err))
 	}
-	chart := cq.NewChart(cfg, selectedPair, candles)
+}
+
+// showFilter shows trade filter dialog for current session's selected pair
+func (u *ui) showFilter() {
+	u.Lock()
+	pair := u.current.selectedPair
+	filter := u.current.filters[pair]
+	u.Unlock()
+
+	showFilterDialog(u.w, pair, filter, func(f cq.TradeFilter) {
+		go u.setFilter(pair, f)
+	})
+}
 
-	ws.Stream(toRouter, candleCh, historyIn, e.GetWatchedPairs()...)
-	err = ws.SubCandles(selectedPair, cfg.Interval, cfg.MaxBars)
+// setFilter sets trade filter of pair and saves it
+// Filter is dropped if another pair was selected while dialog was open
+func (u *ui) setFilter(pair cq.Pair, filter cq.TradeFilter) {
+	u.Lock()
+	defer u.Unlock()
+
+	if u.current.selectedPair != pair {
+		u.lastErr.SetText(fmt.Sprintf("unable to set filter: %v is no longer selected", pair))
+		return
+	}
+	err := u.current.setFilter(filter)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to set filter: %v", err))
+		return
+	}
+	u.save()
+}
 
+// onAlert shows desktop notification for alert and saves its fired state
+// Called from session event loop so config is saved in its own goroutine
+func (u *ui) onAlert(a cq.Alert) {
+	u.app.SendNotification(fyne.NewNotification("Alert", a.String()))
 	go func() {
-		for {
-			select {
-			case upd := <-candleCh:
-				switch upd.Type {
-				case cq.CandleSnapshot:
-					chart = cq.NewChart(cfg, selectedPair, upd.Candles)
-				case cq.CandleUpd:
-					chart.Update(upd.Candles)
-				}
-			case upd := <-historyOut:
-				switch upd.Type {
-				case cq.HistoryUpd:
-					history.Add(upd.Trade)
-				case cq.HistoryHighlightUpd:
-					history.RemoveHighlight(upd.Trade)
-				}
-			case upd := <-fromRouter:
-				e.UpdateQuote(upd)
-			}
-		}
+		u.Lock()
+		defer u.Unlock()
+		u.save()
 	}()
+}
 
-	container := fyne.NewContainerWithLayout(layout.NewHBoxLayout(), e.GetWatchlist(), layout.NewSpacer(), history)
+// showAlerts shows alerts panel for current session's watched pairs
+func (u *ui) showAlerts() {
+	u.Lock()
+	s := u.current
+	pairs := s.exchange.GetWatchedPairs()
+	u.Unlock()
 
-	w.SetContent(container)
+	showAlertsPanel(u.w, pairs, alertActions{
+		list: s.alerts.Alerts,
+		add: func(a cq.Alert) error {
+			s.alerts.Add(a)
+			return u.saveAlerts(s)
+		},
+		set: func(id int, change func(*cq.Alert)) error {
+			if err := s.alerts.Update(id, change); err != nil {
+				return err
+			}
+			return u.saveAlerts(s)
+		},
+		remove: func(id int) error {
+			s.alerts.Remove(id)
+			return u.saveAlerts(s)
+		},
+	})
+}
 
-	w.ShowAndRun()
+// saveAlerts saves settings after alerts of session s are changed
+// Alerts of a stopped session are not saved
+func (u *ui) saveAlerts(s *session) error {
+	u.Lock()
+	defer u.Unlock()
+
+	if s != u.current {
+		return fmt.Errorf("%v is no longer streaming", s.id)
+	}
+	u.save()
+	return nil
+}
+
+// referenceCurrencies lists currencies offered for watchlist's reference
+// columns with "off" to hide them
+var referenceCurrencies = []string{"off", "USD", "EUR", "USDT", "BTC", "ETH"}
+
+// referenceName returns name of reference currency shown in selector
+func referenceName(ref string) string {
+	if ref == "" {
+		return referenceCurrencies[0]
+	}
+	return ref
+}
+
+// setReference sets currency of watchlist's reference columns and saves it
+func (u *ui) setReference(name string) {
+	u.Lock()
+	defer u.Unlock()
+
+	ref := name
+	if name == referenceCurrencies[0] {
+		ref = ""
+	}
+	if u.cfg.Reference == ref {
+		return
+	}
+	u.cfg.Reference = ref
+	u.save()
+	err := u.current.setReference(ref)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to convert to %v: %v", name, err))
+	}
+	// watchlist is rebuilt with reference columns
+	u.show()
+}
+
+// showPortfolio shows portfolio editor
+func (u *ui) showPortfolio() {
+	u.Lock()
+	cfg := u.cfg.Portfolio
+	u.Unlock()
+
+	showPortfolioEditor(u.w, cfg, func(c cq.PortfolioCfg) {
+		go u.setPortfolio(c)
+	})
+}
+
+// setPortfolio sets holdings shown in portfolio panel and saves them
+func (u *ui) setPortfolio(cfg cq.PortfolioCfg) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.cfg.Portfolio = cfg
+	u.save()
+	err := u.current.setPortfolio(cfg)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to value portfolio: %v", err))
+	}
+}
+
+// showPicker shows pair picker starting with pairs available on current
+// exchange
+// Pairs of other exchanges are added to current watchlist with their own feed
+func (u *ui) showPicker() {
+	u.Lock()
+	s := u.current
+	u.Unlock()
+
+	showPairPicker(u.w, s.id, s.availablePairs, func(p cq.Pair) {
+		go u.addPair(p)
+	})
+}
+
+// addPair adds pair to current session's watchlist
+func (u *ui) addPair(pair cq.Pair) {
+	u.Lock()
+	defer u.Unlock()
+
+	err := u.current.addPair(pair)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to add %v: %v", pair, err))
+		return
+	}
+	u.save()
+}
+
+// removePair removes pair from current session's watchlist
+func (u *ui) removePair(pair cq.Pair) {
+	u.Lock()
+	defer u.Unlock()
+
+	err := u.current.removePair(pair)
+	if err != nil {
+		u.lastErr.SetText(fmt.Sprintf("unable to remove %v: %v", pair, err))
+		return
+	}
+	u.save()
+}
+
+// show lays out current session's widgets in window
+func (u *ui) show() {
+	s := u.current
+	top := widget.NewHBox(u.selector, u.add, widget.NewLabel("Book grouping"), u.grouping, widget.NewLabel("Trade colors"), u.colors, widget.NewLabel("Aggregate"), u.aggregate, u.filter, u.alerts, u.holdings, widget.NewLabel("Reference"), u.reference, u.status, layout.NewSpacer(), u.lastErr, u.unsaved, u.theme)
+	watchlist := s.exchange.GetWatchlist()
+	watchlist.OnSelected = func(p cq.Pair) {
+		go u.selectPair(p)
+	}
+	watchlist.OnRemoved = func(p cq.Pair) {
+		go u.removePair(p)
+	}
+	s.history.OnScrolledToBottom = func() {
+		go u.loadOlder()
+	}
+	right := fyne.NewContainerWithLayout(layout.NewHBoxLayout(), s.book, s.history)
+	left := fyne.NewContainerWithLayout(layout.NewBorderLayout(nil, s.portfolio, nil, nil), s.portfolio, watchlist)
+	container := fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, left, right), top, left, right, s.chart)
+
+	u.w.SetContent(container)
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sync"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/canvas"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/theme"
+	"fyne.io/fyne/widget"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// maxPickerResults limits number of pairs listed in picker
+const maxPickerResults = 100
+
+// showPairPicker shows dialog listing pairs of exchange filtered by base or
+// quote currency
+// Pairs of exchange chosen in selector are loaded with pairsOf starting with
+// exchange id.  onPick is called with pair chosen by user and dialog is
+// closed.
+func showPairPicker(w fyne.Window, id cq.ExchangeID, pairsOf func(cq.ExchangeID) ([]cq.Pair, error), onPick func(cq.Pair)) {
+	var d dialog.Dialog
+	// mu guards pairs and generation which counts loads so results of a
+	// load are dropped once another exchange has been chosen
+	var mu sync.Mutex
+	var pairs []cq.Pair
+	generation := 0
+
+	results := widget.NewVBox()
+	search := widget.NewEntry()
+	// showResults must be called with mu held
+	showResults := func(query string) {
+		results.Children = nil
+		for i, p := range cq.FilterPairs(pairs, query) {
+			if i == maxPickerResults {
+				results.Append(widget.NewLabel("..."))
+				break
+			}
+			pair := p
+			results.Append(widget.NewButton(pair.String(), func() {
+				d.Hide()
+				onPick(pair)
+			}))
+		}
+		results.Refresh()
+	}
+
+	search.SetPlaceHolder("Search base or quote currency, e.g. ETH or ETH/BTC")
+	search.OnChanged = func(query string) {
+		mu.Lock()
+		defer mu.Unlock()
+		showResults(query)
+	}
+
+	// pairs are loaded in background since exchanges that are not streamed
+	// are queried with rest api
+	load := func(id cq.ExchangeID) {
+		mu.Lock()
+		generation++
+		gen := generation
+		pairs = nil
+		results.Children = []fyne.CanvasObject{widget.NewLabel("Loading...")}
+		results.Refresh()
+		mu.Unlock()
+
+		go func() {
+			loaded, err := pairsOf(id)
+			mu.Lock()
+			defer mu.Unlock()
+			if gen != generation {
+				return
+			}
+			if err != nil {
+				results.Children = []fyne.CanvasObject{widget.NewLabel(err.Error())}
+				results.Refresh()
+				return
+			}
+			pairs = loaded
+			showResults(search.Text)
+		}()
+	}
+	names := []string{}
+	for _, e := range exchangeIDs {
+		names = append(names, e.String())
+	}
+	exchanges := widget.NewSelect(names, func(name string) {
+		for _, e := range exchangeIDs {
+			if e.String() == name {
+				load(e)
+			}
+		}
+	})
+	exchanges.Selected = id.String()
+	load(id)
+	top := widget.NewVBox(exchanges, search)
+
+	// fix size of scrolling results
+	bg := canvas.NewRectangle(theme.BackgroundColor())
+	bg.SetMinSize(fyne.NewSize(300, 400))
+	scroll := widget.NewScrollContainer(results)
+	content := fyne.NewContainerWithLayout(layout.NewBorderLayout(top, nil, nil, nil),
+		top, fyne.NewContainerWithLayout(layout.NewMaxLayout(), bg, scroll))
+
+	d = dialog.NewCustom("Add Pair", "Cancel", content, w)
+	d.Show()
+}
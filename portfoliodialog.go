@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne"
+	"fyne.io/fyne/dialog"
+	"fyne.io/fyne/layout"
+	"fyne.io/fyne/widget"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// showPortfolioEditor shows dialog to edit holdings and home currency
+// onSave is called with edited portfolio
+// Cost of new holdings is paid in home currency
+func showPortfolioEditor(w fyne.Window, cfg cq.PortfolioCfg, onSave func(cq.PortfolioCfg)) {
+	holdings := append([]cq.Holding{}, cfg.Holdings...)
+	errLabel := widget.NewLabel("")
+
+	home := widget.NewEntry()
+	home.SetText(cfg.Home)
+
+	list := widget.NewVBox()
+	var refresh func()
+	refresh = func() {
+		list.Children = nil
+		if len(holdings) == 0 {
+			list.Append(widget.NewLabel("No holdings"))
+		}
+		for i, h := range holdings {
+			i := i
+			desc := fmt.Sprintf("%v %v, cost %v %v", h.Amount, h.Currency, h.Cost, h.CostCurrency)
+			list.Append(widget.NewHBox(widget.NewLabel(desc), layout.NewSpacer(), widget.NewButton("Remove", func() {
+				holdings = append(holdings[:i], holdings[i+1:]...)
+				refresh()
+			})))
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	currency := widget.NewEntry()
+	currency.SetPlaceHolder("currency, e.g. ETH")
+	amount := widget.NewEntry()
+	amount.SetPlaceHolder("amount")
+	cost := widget.NewEntry()
+	cost.SetPlaceHolder("total cost in home currency")
+	add := widget.NewButton("Add Holding", func() {
+		h, err := newHolding(currency.Text, amount.Text, cost.Text, home.Text)
+		if err != nil {
+			errLabel.SetText(err.Error())
+			return
+		}
+		errLabel.SetText("")
+		holdings = append(holdings, h)
+		currency.SetText("")
+		amount.SetText("")
+		cost.SetText("")
+		refresh()
+	})
+
+	content := widget.NewVBox(
+		widget.NewForm(&widget.FormItem{Text: "Home currency", Widget: home}),
+		list,
+		widget.NewHBox(currency, amount, cost, add),
+		errLabel,
+	)
+
+	dialog.ShowCustomConfirm("Portfolio", "Save", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		c := strings.ToUpper(strings.TrimSpace(home.Text))
+		if c == "" {
+			dialog.ShowError(fmt.Errorf("home currency is required"), w)
+			return
+		}
+		onSave(cq.PortfolioCfg{Home: c, Holdings: holdings})
+	}, w)
+}
+
+// newHolding returns holding from values entered in portfolio editor
+func newHolding(currency, amount, cost, home string) (cq.Holding, error) {
+	h := cq.Holding{
+		Currency:     strings.ToUpper(strings.TrimSpace(currency)),
+		CostCurrency: strings.ToUpper(strings.TrimSpace(home)),
+	}
+	if h.Currency == "" {
+		return h, fmt.Errorf("currency is required")
+	}
+	if h.CostCurrency == "" {
+		return h, fmt.Errorf("home currency is required")
+	}
+
+	var err error
+	h.Amount, err = cq.ParseDecimal(amount)
+	if err != nil || h.Amount.Sign() <= 0 {
+		return h, fmt.Errorf("invalid amount: %v", amount)
+	}
+	if c := strings.TrimSpace(cost); c != "" {
+		h.Cost, err = cq.ParseDecimal(c)
+		if err != nil || h.Cost.Sign() < 0 {
+			return h, fmt.Errorf("invalid cost: %v", cost)
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/3cb/cq-gui/bitfinex"
+	"github.com/3cb/cq-gui/coinbase"
+	"github.com/3cb/cq-gui/cq"
+	"github.com/3cb/cq-gui/hitbtc"
+)
+
+// adapter links an exchange package to the main event loop
+type adapter struct {
+	newExchange func() (cq.Exchange, error)
+	newStreamer func() (cq.Streamer, error)
+	rest        cq.MarketData
+}
+
+// exchangeIDs lists available exchanges in the order shown in selector
+var exchangeIDs = []cq.ExchangeID{cq.HitBTC, cq.Coinbase, cq.Bitfinex}
+
+// hitbtcREST shares HitBTC's symbol metadata between its exchange,
+// streamer and rest api
+var hitbtcREST = hitbtc.NewREST()
+
+// coinbaseREST shares Coinbase Pro's rate limit between its exchange,
+// streamer and rest api
+var coinbaseREST = coinbase.NewREST()
+
+// bitfinexREST is used by Bitfinex's exchange and rest api
+var bitfinexREST = bitfinex.REST{}
+
+// registry holds adapters for all available exchanges
+var registry = map[cq.ExchangeID]adapter{
+	cq.HitBTC: {
+		newExchange: func() (cq.Exchange, error) { return hitbtc.New(hitbtcREST) },
+		newStreamer: func() (cq.Streamer, error) { return hitbtc.NewWSCtlr(hitbtcREST) },
+		rest:        hitbtcREST,
+	},
+	cq.Coinbase: {
+		newExchange: func() (cq.Exchange, error) { return coinbase.New(coinbaseREST) },
+		newStreamer: func() (cq.Streamer, error) { return coinbase.NewWSCtlr(coinbaseREST) },
+		rest:        coinbaseREST,
+	},
+	cq.Bitfinex: {
+		newExchange: func() (cq.Exchange, error) { return bitfinex.New(bitfinexREST) },
+		newStreamer: func() (cq.Streamer, error) { return bitfinex.NewWSCtlr() },
+		rest:        bitfinexREST,
+	},
+}
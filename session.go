@@ -0,0 +1,825 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/widget"
+
+	"github.com/3cb/cq-gui/cq"
+)
+
+// olderPage is number of trades shown each time older trades are loaded
+const olderPage = 50
+
+// ratesInterval is how often prices of pairs used for conversion that are
+// not streamed are requested
+const ratesInterval = time.Minute
+
+// session holds widgets and streaming state for a single exchange
+// Switching exchanges starts a new session and stops the old one
+// Watchlist may hold pairs of other exchanges which are streamed by their
+// own feeds into the same routers and event loop.
+type session struct {
+	id cq.ExchangeID
+	// exchange owns watchlist
+	exchange cq.Exchange
+	// feeds are keyed by exchange and only changed from event loop
+	feeds map[cq.ExchangeID]*feed
+	// feedErr holds error of feed that failed to start with session
+	feedErr error
+	// unavailable holds saved watchlist pairs of exchanges whose feed failed
+	// to start so they are saved again
+	unavailable []cq.Pair
+	chans       cq.StreamChans
+	// statusCh and errCh merge connection status and errors of all feeds
+	statusCh chan cq.ConnStatusMsg
+	errCh    chan error
+	// states holds latest connection status of each feed shown in status
+	states map[cq.ExchangeID]cq.ConnStatusMsg
+	status *widget.Label
+
+	router     *cq.Router
+	histRouter *cq.HistoryRouter
+	bookRouter *cq.BookRouter
+	candleCh   chan cq.CandleUpdMsg
+
+	cfg          cq.ChartCfg
+	histCfg      cq.HistoryCfg
+	filters      map[cq.Pair]cq.TradeFilter
+	alerts       *cq.AlertEngine
+	selectedPair cq.Pair
+	history      *cq.History
+	chart        *cq.Chart
+	book         *cq.Book
+
+	rates     *cq.Rates
+	portfolio *cq.Portfolio
+	// reference is the currency of watchlist's reference columns
+	reference string
+	// polled holds pairs that are not streamed but whose prices are
+	// requested to convert currencies
+	polled map[cq.Pair]struct{}
+
+	// calls passes functions to be run by event loop
+	// Widgets and selectedPair are only changed from event loop
+	calls chan func()
+	// done stops session event loop and stopped confirms it has exited
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// startSession loads initial data from exchange's rest api and starts
+// streaming data from websocket api
+// Watchlist and selected pair are restored from saved settings
+func startSession(id cq.ExchangeID, config cq.Config) (_ *session, err error) {
+	cfg, saved := config.Chart, config.Exchanges[id.String()]
+	s := &session{
+		id:       id,
+		feeds:    map[cq.ExchangeID]*feed{},
+		statusCh: make(chan cq.ConnStatusMsg),
+		states:   map[cq.ExchangeID]cq.ConnStatusMsg{},
+		errCh:    make(chan error),
+		cfg:      cfg,
+		histCfg:  config.History,
+		filters:  map[cq.Pair]cq.TradeFilter{},
+		candleCh: make(chan cq.CandleUpdMsg),
+		calls:    make(chan func()),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	// connected feeds are shut down if session fails to start
+	defer func() {
+		if err != nil {
+			s.closeFeeds()
+		}
+	}()
+
+	// create exchange with initial state set
+	f, err := newFeed(id)
+	if err != nil {
+		return nil, err
+	}
+	s.feeds[id] = f
+	e := f.exchange
+	s.exchange = e
+	for p, f := range saved.Filters {
+		s.filters[p] = f
+	}
+	s.alerts = cq.NewAlertEngine(saved.Alerts)
+	s.rates = cq.NewRates()
+	s.rates.SetPairs(e.GetAvailablePairs())
+	s.portfolio = cq.NewPortfolio(config.Portfolio, s.rates)
+	s.polled = map[cq.Pair]struct{}{}
+
+	// pairs of other exchanges are not watched if their feed fails to start
+	// but kept in settings
+	for other, pairs := range byExchange(saved.Watchlist) {
+		if _, ok := s.feeds[other]; ok {
+			continue
+		}
+		f, err := newFeed(other)
+		if err != nil {
+			s.feedErr = fmt.Errorf("unable to stream %v: %v", other, err)
+			s.unavailable = append(s.unavailable, pairs...)
+			continue
+		}
+		s.feeds[other] = f
+	}
+	if watchlist := s.available(saved.Watchlist); len(watchlist) > 0 {
+		e.SetWatchlist(watchlist...)
+	}
+	for _, p := range e.GetWatchedPairs() {
+		e.GetWatchlist().SetInstrument(s.feeds[p.Exchange()].exchange.GetInstrument(p))
+	}
+
+	// get initial quotes from rest api
+	for other, watched := range byExchange(e.GetWatchedPairs()) {
+		initQuotes, err := s.feeds[other].rest.GetQuotes(watched...)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range initQuotes {
+			upd := cq.UpdateMsg{
+				Quote: q,
+				Type:  cq.InitUpd,
+			}
+			e.UpdateQuote(upd)
+			s.rates.Update(upd)
+		}
+	}
+
+	// set selected Pair
+	pairs := e.GetWatchedPairs()
+	if len(pairs) == 0 {
+		return nil, errors.New("watchlist is empty")
+	}
+	s.selectedPair = pairs[0]
+	if saved.Selected != nil && len(s.available([]cq.Pair{*saved.Selected})) > 0 {
+		s.selectedPair = *saved.Selected
+	}
+	e.GetWatchlist().SetSelected(s.selectedPair)
+	if config.Reference != "" {
+		s.reference = config.Reference
+		e.GetWatchlist().SetReference(s.reference, s.rates)
+	}
+	selected := s.feeds[s.selectedPair.Exchange()]
+
+	// get initial trades from rest api
+	initTrades, err := selected.rest.GetTrades(s.selectedPair)
+	if err != nil {
+		return nil, err
+	}
+	if len(initTrades) == 0 {
+		return nil, fmt.Errorf("no trades for %v", s.selectedPair)
+	}
+	s.history = cq.NewHistory(s.selectedPair, initTrades, s.histCfg, s.filters[s.selectedPair])
+	s.history.SetInstrument(selected.exchange.GetInstrument(s.selectedPair))
+
+	// create chart
+	candles, err := selected.rest.GetCandles(s.selectedPair, cfg.Interval, cfg.MaxBars)
+	if err != nil {
+		return nil, err
+	}
+	s.chart = cq.NewChart(cfg, s.selectedPair, candles)
+	s.chart.SetInstrument(selected.exchange.GetInstrument(s.selectedPair))
+	s.book = cq.NewBook(s.selectedPair)
+	s.book.SetInstrument(selected.exchange.GetInstrument(s.selectedPair))
+
+	// launch streaming
+	//
+	// quote router
+	s.router = cq.StartRouter(pairs)
+	// history router
+	s.histRouter = cq.StartHistoryRouter(s.selectedPair, initTrades[0].ID, s.filters[s.selectedPair])
+	s.histRouter.SetAggregation(s.histCfg.AggregateWindow())
+	// order book router
+	s.bookRouter = cq.StartBookRouter(s.selectedPair)
+
+	historyIn, _ := s.histRouter.GetChannels()
+	bookIn, _ := s.bookRouter.GetChannels()
+	s.chans = cq.StreamChans{
+		Quotes:  s.router.GetQuoteIn(),
+		Candles: s.candleCh,
+		Trades:  historyIn,
+		Book:    bookIn,
+	}
+
+	return s, nil
+}
+
+// run launches session event loop and subscribes to streaming data
+// Connection status and errors are shown in labels
+// onAlert is called from event loop with each alert that fires and must not
+// block
+func (s *session) run(status *widget.Label, lastErr *widget.Label, onAlert func(cq.Alert)) {
+	fromRouter := s.router.GetQuoteOut()
+	_, historyOut := s.histRouter.GetChannels()
+	_, bookOut := s.bookRouter.GetChannels()
+	s.status = status
+
+	go func() {
+		defer close(s.stopped)
+		for {
+			select {
+			case <-s.done:
+				return
+			case f := <-s.calls:
+				f()
+			case upd := <-s.candleCh:
+				// drop updates queued before selected pair changed
+				if upd.Pair != s.selectedPair {
+					continue
+				}
+				switch upd.Type {
+				case cq.CandleSnapshot:
+					s.chart.SetCandles(upd.Candles)
+				case cq.CandleUpd:
+					s.chart.Update(upd.Candles)
+				}
+			case upd := <-historyOut:
+				if upd.Trade.Pair != s.history.Pair {
+					continue
+				}
+				switch upd.Type {
+				case cq.HistoryUpd:
+					s.history.Add(upd.Trade)
+				case cq.HistoryHighlightUpd:
+					s.history.RemoveHighlight(upd.Trade)
+				}
+			case upd := <-bookOut:
+				if upd.Pair == s.book.GetPair() {
+					s.book.Update(upd)
+				}
+			case upd := <-fromRouter:
+				s.exchange.UpdateQuote(upd)
+				for _, a := range s.alerts.Check(upd) {
+					onAlert(a)
+				}
+				if s.rates.Update(upd) && s.portfolio.Uses(upd.Quote.ID) {
+					s.portfolio.Refresh()
+				}
+			case msg := <-s.statusCh:
+				if _, ok := s.feeds[msg.ExchangeID]; !ok {
+					continue
+				}
+				s.states[msg.ExchangeID] = msg
+				status.SetText(statusText(s.states))
+			case err := <-s.errCh:
+				lastErr.SetText(err.Error())
+			}
+		}
+	}()
+
+	if s.feedErr != nil {
+		lastErr.SetText(s.feedErr.Error())
+	}
+	watched := byExchange(s.exchange.GetWatchedPairs())
+	for id, f := range s.feeds {
+		go f.forward(s.statusCh, s.errCh, s.done)
+		err := f.ws.Stream(s.chans, watched[id]...)
+		if err != nil {
+			lastErr.SetText(err.Error())
+		}
+	}
+
+	ws := s.feeds[s.selectedPair.Exchange()].ws
+	if !s.isWatched(s.selectedPair) {
+		err := ws.SubTrades(s.selectedPair)
+		if err != nil {
+			lastErr.SetText(err.Error())
+		}
+	}
+	err := ws.SubCandles(s.selectedPair, s.cfg.Interval, s.cfg.MaxBars)
+	if err != nil {
+		lastErr.SetText(err.Error())
+	}
+	err = ws.SubOrderBook(s.selectedPair)
+	if err != nil && err != cq.ErrNotSupported {
+		lastErr.SetText(err.Error())
+	}
+
+	go s.pollRates(lastErr)
+}
+
+// pollRates requests prices of pairs used for conversion that are not
+// streamed until session is stopped
+func (s *session) pollRates(lastErr *widget.Label) {
+	ticker := time.NewTicker(ratesInterval)
+	defer ticker.Stop()
+
+	for {
+		err := s.refreshRates()
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if err != nil {
+			lastErr.SetText(fmt.Sprintf("unable to update conversion rates: %v", err))
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshRates requests prices from rest api for pairs that are needed to
+// convert held currencies to home currency and watched currencies to
+// reference currency but are not streamed
+func (s *session) refreshRates() error {
+	var pairs []cq.Pair
+	feeds := map[cq.ExchangeID]*feed{}
+	err := s.call(func() {
+		pairs = s.unstreamedRates()
+		for id, f := range s.feeds {
+			feeds[id] = f
+		}
+	})
+	if err != nil || len(pairs) == 0 {
+		return err
+	}
+
+	quotes := []cq.Quote{}
+	for id, unstreamed := range byExchange(pairs) {
+		f, ok := feeds[id]
+		if !ok {
+			continue
+		}
+		q, err := f.rest.GetQuotes(unstreamed...)
+		if err != nil {
+			return err
+		}
+		quotes = append(quotes, q...)
+	}
+	return s.call(func() {
+		for _, q := range quotes {
+			s.rates.Update(cq.UpdateMsg{
+				Quote: q,
+				Type:  cq.InitUpd,
+			})
+			s.polled[q.ID] = struct{}{}
+		}
+		s.portfolio.Refresh()
+		s.exchange.GetWatchlist().RefreshReference()
+	})
+}
+
+// unstreamedRates returns pairs on conversion paths that are not priced or
+// were priced by rest api and are still not streamed
+// Must be called from event loop
+func (s *session) unstreamedRates() []cq.Pair {
+	currencies, home := s.portfolio.Currencies()
+	pairs := s.rates.Missing(currencies, home)
+	if s.reference != "" {
+		watched := []string{}
+		for _, p := range s.exchange.GetWatchedPairs() {
+			watched = append(watched, p.BaseCurrency(), p.QuoteCurrency())
+		}
+		pairs = append(pairs, s.rates.Missing(watched, s.reference)...)
+	}
+	for p := range s.polled {
+		pairs = append(pairs, p)
+	}
+
+	seen := map[cq.Pair]struct{}{}
+	result := []cq.Pair{}
+	for _, p := range pairs {
+		if _, ok := seen[p]; ok || s.isWatched(p) {
+			continue
+		}
+		seen[p] = struct{}{}
+		result = append(result, p)
+	}
+	return result
+}
+
+// settings returns session's watchlist and selected pair to be saved
+// Pairs of exchanges that could not be streamed are saved after watched
+// pairs
+func (s *session) settings() cq.ExchangeCfg {
+	selected := s.selectedPair
+	return cq.ExchangeCfg{
+		Watchlist: append(s.exchange.GetWatchedPairs(), s.unavailable...),
+		Selected:  &selected,
+		Filters:   s.filters,
+		Alerts:    s.alerts.Alerts(),
+	}
+}
+
+// available returns pairs that are traded on exchanges of session's feeds
+// Saved pairs may have been delisted since they were saved
+func (s *session) available(pairs []cq.Pair) []cq.Pair {
+	traded := map[cq.Pair]struct{}{}
+	for _, f := range s.feeds {
+		for _, p := range f.exchange.GetAvailablePairs() {
+			traded[p] = struct{}{}
+		}
+	}
+
+	result := []cq.Pair{}
+	for _, p := range pairs {
+		if _, ok := traded[p]; ok {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// selectPair retargets history and chart to pair
+// Trades are loaded and subscribed before anything is changed so a failed
+// request leaves current selection in place
+// Must not be called concurrently with itself or stop
+func (s *session) selectPair(pair cq.Pair) error {
+	if pair == s.selectedPair {
+		return nil
+	}
+
+	f, err := s.feed(pair)
+	if err != nil {
+		return err
+	}
+	trades, err := f.rest.GetTrades(pair)
+	if err != nil {
+		return err
+	}
+	if len(trades) == 0 {
+		return fmt.Errorf("no trades for %v", pair)
+	}
+
+	// trades for pairs outside watchlist are subscribed separately
+	if !s.isWatched(pair) {
+		err = f.ws.SubTrades(pair)
+		if err != nil {
+			return err
+		}
+	}
+
+	old := s.selectedPair
+	oldWS := s.feeds[old.Exchange()].ws
+	history := cq.NewHistory(pair, trades, s.histCfg, s.filters[pair])
+	history.SetInstrument(f.exchange.GetInstrument(pair))
+	err = s.call(func() {
+		s.selectedPair = pair
+		s.history = history
+		s.chart.SetPair(pair, nil)
+		s.chart.SetInstrument(f.exchange.GetInstrument(pair))
+		s.book.SetPair(pair)
+		s.book.SetInstrument(f.exchange.GetInstrument(pair))
+		s.exchange.GetWatchlist().SetSelected(pair)
+	})
+	if err != nil {
+		// session is stopped so error from dropping trades is not reported
+		if !s.isWatched(pair) {
+			f.ws.UnsubTrades(pair)
+		}
+		return err
+	}
+	s.histRouter.SetPair(pair, trades[0].ID, s.filters[pair])
+	s.bookRouter.SetPair(pair)
+
+	err = f.ws.SubCandles(pair, s.cfg.Interval, s.cfg.MaxBars)
+	if err != nil {
+		return err
+	}
+	err = f.ws.SubOrderBook(pair)
+	if err != nil && err != cq.ErrNotSupported {
+		return err
+	}
+
+	// streams of previous pair are only dropped once pair is swapped so a
+	// failed selection leaves chart and book streaming
+	// Updates still queued for previous pair are dropped by event loop.
+	err = oldWS.UnsubCandles(old, s.cfg.Interval, s.cfg.MaxBars)
+	if err != nil {
+		return err
+	}
+	err = oldWS.UnsubOrderBook(old)
+	if err != nil && err != cq.ErrNotSupported {
+		return err
+	}
+	if !s.isWatched(old) {
+		err = oldWS.UnsubTrades(old)
+		if err != nil {
+			return err
+		}
+	}
+	return s.closeUnused(old.Exchange())
+}
+
+// loadOlder shows a page of older trades at bottom of history
+// Buffered trades are shown first and rest api is only queried once buffer
+// is empty
+// Must not be called concurrently with selectPair
+func (s *session) loadOlder() error {
+	var history *cq.History
+	var oldest cq.Trade
+	var ok bool
+	shown := 0
+	err := s.call(func() {
+		history = s.history
+		shown = history.ShowOlder(olderPage)
+		oldest, ok = history.Oldest()
+	})
+	if err != nil || shown > 0 || !ok {
+		return err
+	}
+
+	f, err := s.feed(history.Pair)
+	if err != nil {
+		return err
+	}
+	trades, err := f.rest.GetOlderTrades(history.Pair, oldest, olderPage)
+	if err != nil {
+		return err
+	}
+	if len(trades) == 0 {
+		return fmt.Errorf("no trades older than %v for %v", oldest.ID, history.Pair)
+	}
+	return s.call(func() {
+		history.AppendOlder(trades)
+	})
+}
+
+// addPair adds pair to watchlist with quote from rest api and subscribes
+// to streaming quotes
+// Feed is started for pair's exchange if it is not streamed yet and closed
+// again if pair can't be added
+// Must not be called concurrently with other session methods
+func (s *session) addPair(pair cq.Pair) error {
+	if s.isWatched(pair) {
+		return fmt.Errorf("%v is already in watchlist", pair)
+	}
+
+	f, ok := s.feeds[pair.Exchange()]
+	closeNew := func(err error) error {
+		if !ok {
+			s.closeUnused(pair.Exchange())
+		}
+		return err
+	}
+	if !ok {
+		var err error
+		f, err = s.addFeed(pair.Exchange())
+		if err != nil {
+			return closeNew(err)
+		}
+	}
+	quotes, err := f.rest.GetQuotes(pair)
+	if err != nil {
+		return closeNew(err)
+	}
+
+	err = s.call(func() {
+		s.unavailable = removed(s.unavailable, pair)
+		s.exchange.AddWatchedPair(pair)
+		s.exchange.GetWatchlist().SetInstrument(f.exchange.GetInstrument(pair))
+		for _, q := range quotes {
+			upd := cq.UpdateMsg{
+				Quote: q,
+				Type:  cq.InitUpd,
+			}
+			s.exchange.UpdateQuote(upd)
+			if s.rates.Update(upd) && s.portfolio.Uses(pair) {
+				s.portfolio.Refresh()
+			}
+		}
+	})
+	if err != nil {
+		return closeNew(err)
+	}
+	s.router.AddPair(pair)
+
+	return f.ws.SubQuotes(pair)
+}
+
+// addFeed starts streaming from exchange id into session's routers
+// Must not be called concurrently with other session methods
+func (s *session) addFeed(id cq.ExchangeID) (*feed, error) {
+	f, err := newFeed(id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.call(func() {
+		s.feeds[id] = f
+	})
+	if err != nil {
+		f.ws.Shutdown()
+		return nil, err
+	}
+
+	go f.forward(s.statusCh, s.errCh, s.done)
+	return f, f.ws.Stream(s.chans)
+}
+
+// feed returns feed of exchange pair trades on
+func (s *session) feed(pair cq.Pair) (*feed, error) {
+	f, ok := s.feeds[pair.Exchange()]
+	if !ok {
+		return nil, fmt.Errorf("%v is not streamed", pair.Exchange())
+	}
+	return f, nil
+}
+
+// availablePairs returns pairs traded on exchange id
+// Pairs of exchanges that are not streamed are requested from rest api
+func (s *session) availablePairs(id cq.ExchangeID) ([]cq.Pair, error) {
+	var f *feed
+	err := s.call(func() {
+		f = s.feeds[id]
+	})
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		return f.exchange.GetAvailablePairs(), nil
+	}
+
+	a, ok := registry[id]
+	if !ok {
+		return nil, fmt.Errorf("exchange not available: %v", id)
+	}
+	return a.rest.GetPairs()
+}
+
+// removePair removes pair from watchlist and unsubscribes from streaming
+// quotes
+// Trades are kept for selected pair so history keeps updating.  Feed of
+// another exchange is shut down once none of its pairs is watched or
+// selected.
+// Must not be called concurrently with other session methods
+func (s *session) removePair(pair cq.Pair) error {
+	if !s.isWatched(pair) {
+		return fmt.Errorf("%v is not in watchlist", pair)
+	}
+
+	f, err := s.feed(pair)
+	if err != nil {
+		return err
+	}
+	err = f.ws.UnsubQuotes(pair)
+	if err != nil {
+		return err
+	}
+	s.router.RemovePair(pair)
+
+	err = s.call(func() {
+		s.exchange.RemoveWatchedPair(pair)
+	})
+	if err != nil {
+		return err
+	}
+
+	if pair == s.selectedPair {
+		return f.ws.SubTrades(pair)
+	}
+	return s.closeUnused(pair.Exchange())
+}
+
+// closeUnused shuts down feed of exchange id if it is not session's own
+// exchange and none of its pairs is watched or selected
+// Must not be called concurrently with other session methods
+func (s *session) closeUnused(id cq.ExchangeID) error {
+	if id == s.id || s.selectedPair.Exchange() == id {
+		return nil
+	}
+	if _, ok := byExchange(s.exchange.GetWatchedPairs())[id]; ok {
+		return nil
+	}
+
+	var f *feed
+	err := s.call(func() {
+		f = s.feeds[id]
+		delete(s.feeds, id)
+		delete(s.states, id)
+		if s.status != nil {
+			s.status.SetText(statusText(s.states))
+		}
+	})
+	if err != nil || f == nil {
+		return err
+	}
+	return f.close()
+}
+
+// removed returns pairs without pair
+func removed(pairs []cq.Pair, pair cq.Pair) []cq.Pair {
+	result := []cq.Pair{}
+	for _, p := range pairs {
+		if p != pair {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// setColorMode changes how history colors trades
+func (s *session) setColorMode(mode cq.ColorMode) error {
+	return s.call(func() {
+		s.histCfg.ColorMode = mode
+		s.history.SetColorMode(mode)
+	})
+}
+
+// setAggregation sets window in milliseconds in which history merges trades
+// Zero disables aggregation
+func (s *session) setAggregation(ms int) error {
+	var window time.Duration
+	err := s.call(func() {
+		s.histCfg.Aggregate = ms
+		window = s.histCfg.AggregateWindow()
+		s.history.SetAggregation(window)
+	})
+	if err != nil {
+		return err
+	}
+	s.histRouter.SetAggregation(window)
+	return nil
+}
+
+// setFilter sets trade filter of selected pair
+// Zero filter is removed from settings
+func (s *session) setFilter(filter cq.TradeFilter) error {
+	err := s.call(func() {
+		if filter.IsZero() {
+			delete(s.filters, s.selectedPair)
+		} else {
+			s.filters[s.selectedPair] = filter
+		}
+		s.history.SetFilter(filter)
+	})
+	if err != nil {
+		return err
+	}
+	s.histRouter.SetFilter(filter)
+	return nil
+}
+
+// setPortfolio replaces holdings valued in portfolio panel and requests
+// prices needed to value them
+func (s *session) setPortfolio(cfg cq.PortfolioCfg) error {
+	s.portfolio.SetHoldings(cfg)
+	return s.refreshRates()
+}
+
+// setReference shows watchlist prices and volumes converted to reference
+// currency
+// Empty reference hides reference columns
+func (s *session) setReference(ref string) error {
+	err := s.call(func() {
+		s.reference = ref
+		s.exchange.GetWatchlist().SetReference(ref, s.rates)
+	})
+	if err != nil {
+		return err
+	}
+	return s.refreshRates()
+}
+
+// isWatched reports whether pair is in watchlist
+func (s *session) isWatched(pair cq.Pair) bool {
+	for _, p := range s.exchange.GetWatchedPairs() {
+		if p == pair {
+			return true
+		}
+	}
+	return false
+}
+
+// call runs f in event loop and waits for it to return
+func (s *session) call(f func()) error {
+	done := make(chan struct{})
+	select {
+	case s.calls <- func() {
+		f()
+		close(done)
+	}:
+		<-done
+		return nil
+	case <-s.stopped:
+		return errors.New("session is stopped")
+	}
+}
+
+// stop shuts down websockets, routers and session event loop
+// Websockets are shut down first while event loop keeps draining their
+// channels
+func (s *session) stop() {
+	s.closeFeeds()
+	s.router.Shutdown()
+	s.histRouter.Shutdown()
+	s.bookRouter.Shutdown()
+	close(s.done)
+	<-s.stopped
+}
+
+// closeFeeds shuts down websocket of each feed
+func (s *session) closeFeeds() {
+	for _, f := range s.feeds {
+		f.ws.Shutdown()
+	}
+}